@@ -272,6 +272,9 @@ const components = {
     'crypto.jwks': {
         conformance: true,
     },
+    'lock.in-memory': {
+        conformance: true,
+    },
     'lock.redis.v6': {
         conformance: true,
         conformanceSetup: 'docker-compose.sh redisjson redis',
@@ -282,6 +285,10 @@ const components = {
         conformanceSetup: 'docker-compose.sh redis7 redis',
         sourcePkg: ['lock/redis', 'common/component/redis'],
     },
+    'lock.sqlite': {
+        conformance: true,
+        sourcePkg: ['lock/sqlite', 'common/component/sql'],
+    },
     'middleware.http.bearer': {
         certification: true,
     },
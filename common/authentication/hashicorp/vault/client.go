@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/net/http2"
+
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// DefaultAddress is the address of the Vault server used when none is configured.
+	DefaultAddress = "https://127.0.0.1:8200"
+	// TokenHeader is the HTTP header that contains the Vault token.
+	TokenHeader = "X-Vault-Token"
+	// RequestHeader is the HTTP header that Vault requires on requests, as protection against SSRF.
+	RequestHeader = "X-Vault-Request"
+)
+
+// ClientMetadata contains the metadata properties used to connect and authenticate to a Vault server.
+// These are shared by all components that use Vault.
+type ClientMetadata struct {
+	// Address of the Vault server. Defaults to "https://127.0.0.1:8200".
+	VaultAddr string `json:"vaultAddr" mapstructure:"vaultAddr"`
+	// Token for authentication within Vault.
+	// Either this or vaultTokenMountPath is required.
+	VaultToken string `json:"vaultToken" mapstructure:"vaultToken"`
+	// Path to a file containing the token.
+	// Either this or vaultToken is required.
+	VaultTokenMountPath string `json:"vaultTokenMountPath" mapstructure:"vaultTokenMountPath"`
+	// Inlined contents of the CA certificate to use, in PEM format.
+	CaPem string `json:"caPem" mapstructure:"caPem"`
+	// Path to a folder holding the CA certificates to use, in PEM format.
+	CaPath string `json:"caPath" mapstructure:"caPath"`
+	// Path to the CA certificate to use, in PEM format.
+	CaCert string `json:"caCert" mapstructure:"caCert"`
+	// Skip TLS verification.
+	SkipVerify bool `json:"skipVerify" mapstructure:"skipVerify"`
+	// Name of the server requested during the TLS handshake.
+	TLSServerName string `json:"tlsServerName" mapstructure:"tlsServerName"`
+}
+
+// Address returns the address of the Vault server.
+func (m ClientMetadata) Address() string {
+	if m.VaultAddr == "" {
+		return DefaultAddress
+	}
+	return m.VaultAddr
+}
+
+// Token returns the token used to authenticate with Vault, reading it from vaultTokenMountPath if needed.
+func (m ClientMetadata) Token() (string, error) {
+	return ReadToken(m.VaultToken, m.VaultTokenMountPath)
+}
+
+// HTTPClient returns a HTTP client configured with the TLS options.
+func (m ClientMetadata) HTTPClient(logger logger.Logger) (*http.Client, error) {
+	return NewHTTPClient(TLSConfig{
+		CAPem:      m.CaPem,
+		CAPath:     m.CaPath,
+		CACert:     m.CaCert,
+		SkipVerify: m.SkipVerify,
+		ServerName: m.TLSServerName,
+	}, logger)
+}
+
+// TLSConfig is the TLS configuration used to interact with Vault.
+type TLSConfig struct {
+	CAPem      string
+	CAPath     string
+	CACert     string
+	SkipVerify bool
+	ServerName string
+}
+
+// ReadToken returns the token, or reads it from the file at tokenMountPath.
+// Exactly one of token and tokenMountPath must be set.
+func ReadToken(token string, tokenMountPath string) (string, error) {
+	// Test that at least one of them are set if not return error
+	if token == "" && tokenMountPath == "" {
+		return "", errors.New("token mount path and token not set")
+	}
+
+	// Test that both are not set. If so return error
+	if token != "" && tokenMountPath != "" {
+		return "", errors.New("token mount path and token both set")
+	}
+
+	if token != "" {
+		return token, nil
+	}
+
+	data, err := os.ReadFile(tokenMountPath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read vault token from mount path %s err: %s", tokenMountPath, err)
+	}
+	return string(bytes.TrimSpace(data)), nil
+}
+
+// NewHTTPClient returns a HTTP client for Vault that uses the TLS configuration.
+func NewHTTPClient(config TLSConfig, logger logger.Logger) (*http.Client, error) {
+	tlsClientConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.SkipVerify {
+		logger.Infof("hashicorp vault: you are using 'skipVerify' to skip server config verify which is unsafe!")
+	}
+
+	tlsClientConfig.InsecureSkipVerify = config.SkipVerify
+	if !config.SkipVerify {
+		rootCAPools, err := getRootCAsPools(config.CAPem, config.CAPath, config.CACert)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsClientConfig.RootCAs = rootCAPools
+
+		if config.ServerName != "" {
+			tlsClientConfig.ServerName = config.ServerName
+		}
+	}
+
+	// Setup http transport
+	transport := &http.Transport{
+		TLSClientConfig: tlsClientConfig,
+	}
+
+	// Configure http2 client
+	err := http2.ConfigureTransport(transport)
+	if err != nil {
+		return nil, errors.New("failed to configure http2")
+	}
+
+	return &http.Client{
+		Transport: transport,
+	}, nil
+}
+
+// getRootCAsPools returns root CAs when you give it CA Pem file, CA path, and CA Certificate. Default is system certificates.
+func getRootCAsPools(vaultCAPem string, vaultCAPath string, vaultCACert string) (*x509.CertPool, error) {
+	if vaultCAPem != "" {
+		certPool := x509.NewCertPool()
+		cert := []byte(vaultCAPem)
+		if ok := certPool.AppendCertsFromPEM(cert); !ok {
+			return nil, errors.New("couldn't read PEM")
+		}
+
+		return certPool, nil
+	}
+
+	if vaultCAPath != "" {
+		certPool := x509.NewCertPool()
+		if err := readCertificateFolder(certPool, vaultCAPath); err != nil {
+			return nil, err
+		}
+
+		return certPool, nil
+	}
+
+	if vaultCACert != "" {
+		certPool := x509.NewCertPool()
+		if err := readCertificateFile(certPool, vaultCACert); err != nil {
+			return nil, err
+		}
+
+		return certPool, nil
+	}
+
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read system certs: %s", err)
+	}
+
+	return certPool, nil
+}
+
+// readCertificateFile reads the certificate at given path.
+func readCertificateFile(certPool *x509.CertPool, path string) error {
+	// Read certificate file
+	pemFile, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("couldn't read CA file from disk: %s", err)
+	}
+
+	if ok := certPool.AppendCertsFromPEM(pemFile); !ok {
+		return errors.New("couldn't read PEM")
+	}
+
+	return nil
+}
+
+// readCertificateFolder scans a folder for certificates.
+func readCertificateFolder(certPool *x509.CertPool, path string) error {
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if info.IsDir() {
+			return nil
+		}
+
+		return readCertificateFile(certPool, p)
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't read certificates at %s: %s", path, err)
+	}
+
+	return nil
+}
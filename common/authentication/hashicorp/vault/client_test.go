@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+func TestClientMetadata(t *testing.T) {
+	t.Run("decode", func(t *testing.T) {
+		m := ClientMetadata{}
+		err := kitmd.DecodeMetadata(map[string]string{
+			"vaultAddr":     "https://vault:8200",
+			"vaultToken":    "mytoken",
+			"skipVerify":    "true",
+			"tlsServerName": "vault",
+		}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "https://vault:8200", m.Address())
+		assert.True(t, m.SkipVerify)
+		assert.Equal(t, "vault", m.TLSServerName)
+
+		token, err := m.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "mytoken", token)
+
+		client, err := m.HTTPClient(logger.NewLogger("test"))
+		require.NoError(t, err)
+		assert.NotNil(t, client)
+	})
+
+	t.Run("default address", func(t *testing.T) {
+		assert.Equal(t, DefaultAddress, ClientMetadata{}.Address())
+	})
+
+	t.Run("invalid CA", func(t *testing.T) {
+		_, err := ClientMetadata{CaPem: "not a PEM"}.HTTPClient(logger.NewLogger("test"))
+		require.Error(t, err)
+	})
+}
+
+func TestReadToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("filetoken\n"), 0o600))
+
+	token, err := ReadToken("", tokenFile)
+	require.NoError(t, err)
+	assert.Equal(t, "filetoken", token)
+
+	token, err = ReadToken("mytoken", "")
+	require.NoError(t, err)
+	assert.Equal(t, "mytoken", token)
+
+	_, err = ReadToken("", "")
+	require.ErrorContains(t, err, "not set")
+	_, err = ReadToken("mytoken", tokenFile)
+	require.ErrorContains(t, err, "both set")
+	_, err = ReadToken("", filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
@@ -53,6 +53,7 @@ type PostgreSQL struct {
 	migrateFn     func(context.Context, pginterfaces.PGXPoolConn, MigrateOptions) error
 	setQueryFn    func(*state.SetRequest, SetQueryOptions) string
 	etagColumn    string
+	keyCollation  string
 	enableAzureAD bool
 	enableAWSIAM  bool
 
@@ -60,9 +61,12 @@ type PostgreSQL struct {
 }
 
 type Options struct {
-	MigrateFn     func(context.Context, pginterfaces.PGXPoolConn, MigrateOptions) error
-	SetQueryFn    func(*state.SetRequest, SetQueryOptions) string
-	ETagColumn    string
+	MigrateFn  func(context.Context, pginterfaces.PGXPoolConn, MigrateOptions) error
+	SetQueryFn func(*state.SetRequest, SetQueryOptions) string
+	ETagColumn string
+	// Collation with which keys are compared to list them, which must order keys by their bytes and be used by an index on the key column, such as "C".
+	// If empty, the collation of the key column is used, which must order keys by their bytes (as in CockroachDB).
+	KeyCollation  string
 	EnableAzureAD bool
 	EnableAWSIAM  bool
 }
@@ -85,6 +89,7 @@ func NewPostgreSQLStateStore(logger logger.Logger, opts Options) state.Store {
 		migrateFn:     opts.MigrateFn,
 		setQueryFn:    opts.SetQueryFn,
 		etagColumn:    opts.ETagColumn,
+		keyCollation:  opts.KeyCollation,
 		enableAzureAD: opts.EnableAzureAD,
 		enableAWSIAM:  opts.EnableAWSIAM,
 	}
@@ -182,6 +187,7 @@ func (p *PostgreSQL) Features() []state.Feature {
 		state.FeatureETag,
 		state.FeatureTransactional,
 		state.FeatureTTL,
+		state.FeatureKeyListing,
 	}
 }
 
@@ -421,6 +427,53 @@ func readRow(row pgx.Row) (key string, value []byte, etagS *string, expireTime *
 	return key, value, etagS, expireTime, nil
 }
 
+// ListKeys returns the keys in the store, sorted by their bytes.
+// The continuation token is the last key of the previous page.
+func (p *PostgreSQL) ListKeys(parentCtx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys with the prefix are selected as a range rather than with LIKE, which can't use the index unless the collation is "C"
+	key := "key"
+	if p.keyCollation != "" {
+		key += ` COLLATE "` + p.keyCollation + `"`
+	}
+	args := []any{req.Prefix, req.ContinuationToken}
+	query := `SELECT key
+		FROM ` + p.metadata.TableName + `
+		WHERE
+			` + key + ` >= $1
+			AND ` + key + ` > $2
+			AND (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP)`
+	if end := commonsql.PrefixRangeEnd(req.Prefix); end != "" {
+		args = append(args, end)
+		query += `
+			AND ` + key + ` < $` + strconv.Itoa(len(args))
+	}
+	args = append(args, req.PageSize+1)
+	query += `
+		ORDER BY ` + key + `
+		LIMIT $` + strconv.Itoa(len(args))
+
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+	rows, err := p.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := &state.ListKeysResponse{}
+	res.Keys, res.ContinuationToken, err = stateutils.ReadKeysPage(rows, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // Delete removes an item from the state store.
 func (p *PostgreSQL) Delete(ctx context.Context, req *state.DeleteRequest) (err error) {
 	return p.doDelete(ctx, p.db, req)
@@ -16,7 +16,9 @@ package postgresql
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -40,6 +42,7 @@ func NewPostgreSQLQueryStateStore(logger logger.Logger, opts Options) state.Stor
 			migrateFn:     opts.MigrateFn,
 			setQueryFn:    opts.SetQueryFn,
 			etagColumn:    opts.ETagColumn,
+			keyCollation:  opts.KeyCollation,
 			enableAzureAD: opts.EnableAzureAD,
 		},
 	}
@@ -54,6 +57,7 @@ func (p *PostgreSQLQuery) Features() []state.Feature {
 		state.FeatureTransactional,
 		state.FeatureQueryAPI,
 		state.FeatureTTL,
+		state.FeatureKeyListing,
 	}
 }
 
@@ -69,6 +73,20 @@ func (p *PostgreSQLQuery) Query(parentCtx context.Context, req *state.QueryReque
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
+
+	if len(q.aggregations) > 0 {
+		aggregates, token, err := q.executeAggregation(parentCtx, p.db)
+		if err != nil {
+			return &state.QueryResponse{}, err
+		}
+
+		return &state.QueryResponse{
+			Results:    []state.QueryItem{},
+			Aggregates: aggregates,
+			Token:      token,
+		}, nil
+	}
+
 	data, token, err := q.execute(parentCtx, p.db)
 	if err != nil {
 		return &state.QueryResponse{}, err
@@ -81,12 +99,15 @@ func (p *PostgreSQLQuery) Query(parentCtx context.Context, req *state.QueryReque
 }
 
 type Query struct {
-	query      string
-	params     []interface{}
-	limit      int
-	skip       *int64
-	tableName  string
-	etagColumn string
+	query        string
+	params       []interface{}
+	limit        int
+	skip         *int64
+	tableName    string
+	etagColumn   string
+	projection   []string
+	aggregations []query.Aggregation
+	groupBy      []string
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -203,6 +224,26 @@ func (q *Query) visitFilters(op string, filters []query.Filter) (string, error)
 				return "", err
 			}
 			arr = append(arr, str)
+		case *query.NOT:
+			if str, err = q.VisitNOT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LIKE:
+			if str, err = q.VisitLIKE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.EXISTS:
+			if str, err = q.VisitEXISTS(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.CONTAINS:
+			if str, err = q.VisitCONTAINS(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
 		default:
 			return "", fmt.Errorf("unsupported filter type %#v", f)
 		}
@@ -221,26 +262,93 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 	return q.visitFilters("OR", f.Filters)
 }
 
+func (q *Query) VisitNOT(f *query.NOT) (string, error) {
+	str, err := q.visitFilters("AND", []query.Filter{f.Filter})
+	if err != nil {
+		return "", err
+	}
+	return "NOT COALESCE(" + str + ", FALSE)", nil
+}
+
+func (q *Query) VisitLIKE(f *query.LIKE) (string, error) {
+	// The pattern uses the same wildcards and escape character as LIKE in PostgreSQL, so it's passed as-is
+	position := q.addParamValueAndReturnPosition(f.Pattern)
+	filterField := translateFieldToFilter(f.Key)
+	return filterField + " LIKE $" + strconv.Itoa(position) + ` ESCAPE '\'`, nil
+}
+
+func (q *Query) VisitEXISTS(f *query.EXISTS) (string, error) {
+	// The "->" operator returns NULL when the field doesn't exist, and a JSON null when the field is null
+	return translateFieldToJSON(f.Key) + " IS NOT NULL", nil
+}
+
+func (q *Query) VisitCONTAINS(f *query.CONTAINS) (string, error) {
+	// The value is compared as JSON, so its type must match the type of the array elements
+	val, err := json.Marshal([]any{f.Val})
+	if err != nil {
+		return "", fmt.Errorf("invalid value for CONTAINS operator: %w", err)
+	}
+	q.params = append(q.params, string(val))
+	return translateFieldToJSON(f.Key) + " @> $" + strconv.Itoa(len(q.params)) + "::jsonb", nil
+}
+
+func (q *Query) VisitProjection(fields []string) error {
+	q.projection = fields
+	return nil
+}
+
+func (q *Query) VisitAggregations(aggregations []query.Aggregation, groupBy []string) error {
+	q.aggregations = aggregations
+	q.groupBy = groupBy
+	return nil
+}
+
 func (q *Query) Finalize(filters string, qq *query.Query) error {
-	q.query = fmt.Sprintf("SELECT key, value, %s as etag FROM "+q.tableName, q.etagColumn)
+	switch {
+	case len(q.aggregations) > 0:
+		// The values of the groups and of the aggregations are returned as JSON, so their types are preserved
+		q.query = "SELECT "
+		if len(q.groupBy) > 0 {
+			q.query += "jsonb_build_array(" + translateFieldsToJSON(q.groupBy) + "), "
+		}
+		q.query += "jsonb_build_object(" + translateAggregations(q.aggregations) + ") FROM " + q.tableName
+	case len(q.projection) > 0:
+		q.query = fmt.Sprintf("SELECT key, %s as value, %s as etag FROM "+q.tableName, translateProjection(nil, q.projection), q.etagColumn)
+	default:
+		q.query = fmt.Sprintf("SELECT key, value, %s as etag FROM "+q.tableName, q.etagColumn)
+	}
 
 	if filters != "" {
 		q.query += " WHERE " + filters
 	}
 
-	if len(qq.Sort) > 0 {
-		q.query += " ORDER BY "
+	if len(q.groupBy) > 0 {
+		q.query += " GROUP BY " + translateFieldsToJSON(q.groupBy)
+	}
 
-		for sortIndex, sortItem := range qq.Sort {
-			if sortIndex > 0 {
-				q.query += ", "
-			}
-			q.query += translateFieldToFilter(sortItem.Key)
-			if sortItem.Order != "" {
-				q.query += " " + sortItem.Order
-			}
+	orderBy := make([]string, 0, len(qq.Sort)+len(q.groupBy))
+	for _, sortItem := range qq.Sort {
+		var item string
+		if len(q.aggregations) > 0 {
+			// Sort on the same expressions used in the GROUP BY clause
+			item = translateFieldToJSON(sortItem.Key)
+		} else {
+			item = translateFieldToFilter(sortItem.Key)
+		}
+		if sortItem.Order != "" {
+			item += " " + sortItem.Order
+		}
+		orderBy = append(orderBy, item)
+	}
+	// Groups are also sorted by the fields they're grouped by, so their order (and pages) are deterministic
+	for _, key := range q.groupBy {
+		if !slices.ContainsFunc(qq.Sort, func(s query.Sorting) bool { return s.Key == key }) {
+			orderBy = append(orderBy, translateFieldToJSON(key))
 		}
 	}
+	if len(orderBy) > 0 {
+		q.query += " ORDER BY " + strings.Join(orderBy, ", ")
+	}
 
 	if qq.Page.Limit > 0 {
 		q.query += " LIMIT " + strconv.Itoa(qq.Page.Limit)
@@ -288,16 +396,62 @@ func (q *Query) execute(ctx context.Context, db pginterfaces.DBQuerier) ([]state
 		return nil, "", err
 	}
 
-	var token string
-	if q.limit != 0 {
-		var skip int64
-		if q.skip != nil {
-			skip = *q.skip
+	return ret, q.nextToken(len(ret)), nil
+}
+
+func (q *Query) executeAggregation(ctx context.Context, db pginterfaces.DBQuerier) ([]state.QueryAggregate, string, error) {
+	rows, err := db.Query(ctx, q.query, q.params...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	ret := []state.QueryAggregate{}
+	for rows.Next() {
+		var group, values []byte
+		if len(q.groupBy) > 0 {
+			err = rows.Scan(&group, &values)
+		} else {
+			err = rows.Scan(&values)
+		}
+		if err != nil {
+			return nil, "", err
 		}
-		token = strconv.FormatInt(skip+int64(len(ret)), 10)
+
+		result := state.QueryAggregate{}
+		if err = json.Unmarshal(values, &result.Values); err != nil {
+			return nil, "", fmt.Errorf("failed to parse aggregation values: %w", err)
+		}
+		if len(q.groupBy) > 0 {
+			var groupVals []any
+			if err = json.Unmarshal(group, &groupVals); err != nil || len(groupVals) != len(q.groupBy) {
+				return nil, "", fmt.Errorf("failed to parse aggregation group: %w", err)
+			}
+			result.Group = make(map[string]any, len(q.groupBy))
+			for i, key := range q.groupBy {
+				result.Group[key] = groupVals[i]
+			}
+		}
+		ret = append(ret, result)
 	}
 
-	return ret, token, nil
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	return ret, q.nextToken(len(ret)), nil
+}
+
+// nextToken returns the token for the next page of results, which is set only if the query has a limit.
+func (q *Query) nextToken(count int) string {
+	if q.limit == 0 {
+		return ""
+	}
+	var skip int64
+	if q.skip != nil {
+		skip = *q.skip
+	}
+	return strconv.FormatInt(skip+int64(count), 10)
 }
 
 func (q *Query) addParamValueAndReturnPosition(value interface{}) int {
@@ -326,6 +480,75 @@ func translateFieldToFilter(key string) string {
 	return filterField
 }
 
+// Returns the expression that extracts a field as JSON, rather than as text like translateFieldToFilter.
+func translateFieldToJSON(key string) string {
+	filterField := "value"
+	for _, fieldPart := range strings.Split(key, ".") {
+		filterField += "->'" + fieldPart + "'"
+	}
+
+	return filterField
+}
+
+// Returns the list of expressions that extract the fields as JSON, separated by commas.
+func translateFieldsToJSON(keys []string) string {
+	fields := make([]string, len(keys))
+	for i, key := range keys {
+		fields[i] = translateFieldToJSON(key)
+	}
+	return strings.Join(fields, ", ")
+}
+
+// Returns the arguments of jsonb_build_object for the aggregations, with the alias of each aggregation as key.
+// SUM, MIN, MAX and AVG only consider numeric values, and COUNT with a key counts the items where the field is not null.
+func translateAggregations(aggregations []query.Aggregation) string {
+	args := make([]string, len(aggregations))
+	for i, a := range aggregations {
+		var expr string
+		switch {
+		case a.Op == query.COUNT && a.Key == "":
+			expr = "COUNT(*)"
+		case a.Op == query.COUNT:
+			expr = "COUNT(NULLIF(" + translateFieldToJSON(a.Key) + ", 'null'::jsonb))"
+		default:
+			field := translateFieldToJSON(a.Key)
+			expr = a.Op + "(CASE WHEN jsonb_typeof(" + field + ") = 'number' THEN (" + translateFieldToFilter(a.Key) + ")::numeric END)"
+		}
+		args[i] = "'" + a.Alias + "', " + expr
+	}
+	return strings.Join(args, ", ")
+}
+
+// Returns the expression that builds a JSON object with the projected fields, preserving their paths.
+// Fields that don't exist are returned as null.
+func translateProjection(prefix []string, fields []string) string {
+	// Group the fields by their first part, preserving the order
+	var names []string
+	children := map[string][]string{}
+	for _, field := range fields {
+		name, rest, _ := strings.Cut(field, ".")
+		if _, ok := children[name]; !ok {
+			names = append(names, name)
+		}
+		if rest != "" {
+			children[name] = append(children[name], rest)
+		} else {
+			children[name] = nil
+		}
+	}
+
+	args := make([]string, len(names))
+	for i, name := range names {
+		path := append(slices.Clone(prefix), name)
+		if len(children[name]) == 0 {
+			args[i] = "'" + name + "', " + translateFieldToJSON(strings.Join(path, "."))
+		} else {
+			args[i] = "'" + name + "', " + translateProjection(path, children[name])
+		}
+	}
+	return "jsonb_build_object(" + strings.Join(args, ", ") + ")"
+}
+
 func (q *Query) whereFieldEqual(key string, value interface{}) string {
 	position := q.addParamValueAndReturnPosition(value)
 	filterField := translateFieldToFilter(key)
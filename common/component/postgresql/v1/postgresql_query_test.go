@@ -61,6 +61,24 @@ func TestPostgresqlQueryBuildQuery(t *testing.T) {
 			input: "../../../../tests/state/query/q8.json",
 			query: "SELECT key, value, xmin as etag FROM state WHERE (value->'person'->>'org'>=$1 OR (value->'person'->>'org'<$2 AND (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
 		},
+		{
+			input: "../../../../tests/state/query/q9.json",
+			query: "SELECT key, value, xmin as etag FROM state WHERE (value->'person'->>'name' LIKE $1 ESCAPE '\\' AND value->'person'->'org' IS NOT NULL AND NOT COALESCE((value->>'state'=$2), FALSE) AND value->'tags' @> $3::jsonb) ORDER BY value->'person'->>'name' LIMIT 2",
+		},
+		{
+			input: "../../../../tests/state/query/q10-projection.json",
+			query: "SELECT key, jsonb_build_object('person', jsonb_build_object('name', value->'person'->'name', 'org', value->'person'->'org'), 'state', value->'state') as value, xmin as etag FROM state WHERE value->>'state'=$1 ORDER BY value->'person'->>'name' LIMIT 2",
+		},
+		{
+			input: "../../../../tests/state/query/q11-aggregation.json",
+			query: "SELECT jsonb_build_array(value->'state', value->'person'->'org'), jsonb_build_object(" +
+				"'total', COUNT(*), " +
+				"'sum_id', SUM(CASE WHEN jsonb_typeof(value->'person'->'id') = 'number' THEN (value->'person'->>'id')::numeric END), " +
+				"'avg_id', AVG(CASE WHEN jsonb_typeof(value->'person'->'id') = 'number' THEN (value->'person'->>'id')::numeric END), " +
+				"'min_id', MIN(CASE WHEN jsonb_typeof(value->'person'->'id') = 'number' THEN (value->'person'->>'id')::numeric END), " +
+				"'max_id', MAX(CASE WHEN jsonb_typeof(value->'person'->'id') = 'number' THEN (value->'person'->>'id')::numeric END)" +
+				") FROM state WHERE (value->>'state'=$1 OR value->>'state'=$2) GROUP BY value->'state', value->'person'->'org' ORDER BY value->'state' DESC, value->'person'->'org'",
+		},
 	}
 	for _, test := range tests {
 		data, err := os.ReadFile(test.input)
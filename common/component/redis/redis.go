@@ -80,6 +80,7 @@ type RedisClient interface {
 	Close() error
 	PingResult(ctx context.Context) (string, error)
 	ConfigurationSubscribe(ctx context.Context, args *ConfigurationSubscribeArgs)
+	PSubscribe(ctx context.Context, pattern string, handler func(channel string, payload string)) error
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (*bool, error)
 	EvalInt(ctx context.Context, script string, keys []string, args ...interface{}) (*int, error, error)
 	XAdd(ctx context.Context, stream string, maxLenApprox int64, values map[string]interface{}) (string, error)
@@ -86,6 +86,8 @@ type Settings struct {
 	// == state only properties ==
 	TTLInSeconds *int   `mapstructure:"ttlInSeconds" mdonly:"state"`
 	QueryIndexes string `mapstructure:"queryIndexes" mdonly:"state"`
+	// Allows watches to enable the keyspace notifications they require in the server, with CONFIG SET
+	EnableKeyspaceNotifications bool `mapstructure:"enableKeyspaceNotifications" mdonly:"state"`
 
 	// == pubsub only properties ==
 	// The consumer identifier
@@ -102,6 +102,35 @@ func (c v9Client) ConfigurationSubscribe(ctx context.Context, args *Configuratio
 	}
 }
 
+// PSubscribe subscribes to the channels matching the pattern, and invokes handler for each message in background until ctx is canceled.
+// It returns once the subscription is confirmed by the server.
+func (c v9Client) PSubscribe(ctx context.Context, pattern string, handler func(channel string, payload string)) error {
+	p := c.client.PSubscribe(ctx, pattern)
+	_, err := p.Receive(ctx)
+	if err != nil {
+		p.Close()
+		return err
+	}
+
+	go func() {
+		defer p.Close()
+		ch := p.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				handler(msg.Channel, msg.Payload)
+			}
+		}
+	}()
+
+	return nil
+}
+
 func (c v9Client) Get(ctx context.Context, key string) (string, error) {
 	return c.client.Get(ctx, key).Result()
 }
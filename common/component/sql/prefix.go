@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// EscapeLike escapes the characters with special meaning in patterns of LIKE, with escape as the escape character.
+// PostgreSQL uses "\" by default; other databases must set the escape character with ESCAPE in the query.
+func EscapeLike(s string, escape rune) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, c := range s {
+		if c == escape || c == '%' || c == '_' {
+			b.WriteRune(escape)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// PrefixRangeEnd returns a string greater than all the strings that start with prefix, comparing bytes, and smaller than all the other (valid UTF-8) strings greater than prefix.
+// The last character is incremented rather than the last byte, so the result is valid UTF-8 if prefix is.
+// It returns an empty string if there's none, such as when prefix is empty.
+func PrefixRangeEnd(prefix string) string {
+	for i := len(prefix); i > 0; {
+		r, size := utf8.DecodeLastRuneInString(prefix[:i])
+		i -= size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// Not valid UTF-8: increment the byte
+			if prefix[i] < 0xff {
+				return prefix[:i] + string([]byte{prefix[i] + 1})
+			}
+		case r < utf8.MaxRune:
+			r++
+			if utf16.IsSurrogate(r) {
+				r = 0xe000
+			}
+			return prefix[:i] + string(r)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, "app||", EscapeLike("app||", '\\'))
+	assert.Equal(t, `app\_1\%\\||`, EscapeLike(`app_1%\||`, '\\'))
+	assert.Equal(t, `app!_1!%\!!||`, EscapeLike(`app_1%\!||`, '!'))
+}
+
+func TestPrefixRangeEnd(t *testing.T) {
+	assert.Equal(t, "app||}", PrefixRangeEnd("app|||"))
+	assert.Equal(t, "b", PrefixRangeEnd("a\xff"))
+	assert.Equal(t, "", PrefixRangeEnd("\xff\xff"))
+	assert.Equal(t, "", PrefixRangeEnd(""))
+	assert.Equal(t, "app¿|}", PrefixRangeEnd("app¿||"))
+	assert.Equal(t, "appÀ", PrefixRangeEnd("app¿"))
+	assert.Equal(t, "a\ue000", PrefixRangeEnd("a\ud7ff"))
+	assert.Equal(t, "b", PrefixRangeEnd("a\U0010ffff"))
+}
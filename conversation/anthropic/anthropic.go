@@ -28,6 +28,10 @@ import (
 )
 
 type Anthropic struct {
+	conversation.History
+	conversation.Scrubber
+	conversation.Cache
+
 	llm llms.Model
 
 	logger logger.Logger
@@ -50,6 +54,16 @@ func (a *Anthropic) Init(ctx context.Context, meta conversation.Metadata) error
 		return err
 	}
 
+	err = a.InitHistory(m.HistoryMetadata)
+	if err != nil {
+		return err
+	}
+
+	err = a.InitScrubber(m.ScrubMetadata)
+	if err != nil {
+		return err
+	}
+
 	model := defaultModel
 	if m.Model != "" {
 		model = m.Model
@@ -66,7 +80,7 @@ func (a *Anthropic) Init(ctx context.Context, meta conversation.Metadata) error
 	a.llm = llm
 
 	if m.CacheTTL != "" {
-		cachedModel, cacheErr := conversation.CacheModel(ctx, m.CacheTTL, a.llm)
+		cachedModel, cacheErr := a.InitCache(ctx, m.CacheTTL, model, a.llm, a.logger)
 		if cacheErr != nil {
 			return cacheErr
 		}
@@ -84,44 +98,12 @@ func (a *Anthropic) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
 }
 
 func (a *Anthropic) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
-	messages := make([]llms.MessageContent, 0, len(r.Inputs))
-
-	for _, input := range r.Inputs {
-		role := conversation.ConvertLangchainRole(input.Role)
-
-		messages = append(messages, llms.MessageContent{
-			Role: role,
-			Parts: []llms.ContentPart{
-				llms.TextPart(input.Message),
-			},
-		})
-	}
-
-	opts := []llms.CallOption{}
-
-	if r.Temperature > 0 {
-		opts = append(opts, conversation.LangchainTemperature(r.Temperature))
-	}
-
-	resp, err := a.llm.GenerateContent(ctx, messages, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	outputs := make([]conversation.ConversationResult, 0, len(resp.Choices))
-
-	for i := range resp.Choices {
-		outputs = append(outputs, conversation.ConversationResult{
-			Result:     resp.Choices[i].Content,
-			Parameters: r.Parameters,
-		})
-	}
-
-	res = &conversation.ConversationResponse{
-		Outputs: outputs,
-	}
+	return conversation.LangchainConverse(ctx, a.llm, &a.History, &a.Scrubber, a.logger, r, nil)
+}
 
-	return res, nil
+// ConverseStream sends the response to fn while it's generated.
+func (a *Anthropic) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, fn conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return conversation.LangchainConverse(ctx, a.llm, &a.History, &a.Scrubber, a.logger, r, fn)
 }
 
 func (a *Anthropic) Close() error {
@@ -30,6 +30,10 @@ import (
 )
 
 type AWSBedrock struct {
+	conversation.History
+	conversation.Scrubber
+	conversation.Cache
+
 	model string
 	llm   llms.Model
 
@@ -44,6 +48,9 @@ type AWSBedrockMetadata struct {
 	SessionToken string `json:"sessionToken"`
 	Model        string `json:"model"`
 	CacheTTL     string `json:"cacheTTL"`
+
+	conversation.HistoryMetadata `json:",inline" mapstructure:",squash"`
+	conversation.ScrubMetadata   `json:",inline" mapstructure:",squash"`
 }
 
 func NewAWSBedrock(logger logger.Logger) conversation.Conversation {
@@ -61,6 +68,16 @@ func (b *AWSBedrock) Init(ctx context.Context, meta conversation.Metadata) error
 		return err
 	}
 
+	err = b.InitHistory(m.HistoryMetadata)
+	if err != nil {
+		return err
+	}
+
+	err = b.InitScrubber(m.ScrubMetadata)
+	if err != nil {
+		return err
+	}
+
 	awsConfig, err := awsAuth.GetConfigV2(m.AccessKey, m.SecretKey, m.SessionToken, m.Region, m.Endpoint)
 	if err != nil {
 		return err
@@ -84,7 +101,7 @@ func (b *AWSBedrock) Init(ctx context.Context, meta conversation.Metadata) error
 	b.llm = llm
 
 	if m.CacheTTL != "" {
-		cachedModel, cacheErr := conversation.CacheModel(ctx, m.CacheTTL, b.llm)
+		cachedModel, cacheErr := b.InitCache(ctx, m.CacheTTL, b.model, b.llm, b.logger)
 		if cacheErr != nil {
 			return cacheErr
 		}
@@ -101,44 +118,12 @@ func (b *AWSBedrock) GetComponentMetadata() (metadataInfo metadata.MetadataMap)
 }
 
 func (b *AWSBedrock) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
-	messages := make([]llms.MessageContent, 0, len(r.Inputs))
-
-	for _, input := range r.Inputs {
-		role := conversation.ConvertLangchainRole(input.Role)
-
-		messages = append(messages, llms.MessageContent{
-			Role: role,
-			Parts: []llms.ContentPart{
-				llms.TextPart(input.Message),
-			},
-		})
-	}
-
-	opts := []llms.CallOption{}
-
-	if r.Temperature > 0 {
-		opts = append(opts, conversation.LangchainTemperature(r.Temperature))
-	}
-
-	resp, err := b.llm.GenerateContent(ctx, messages, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	outputs := make([]conversation.ConversationResult, 0, len(resp.Choices))
-
-	for i := range resp.Choices {
-		outputs = append(outputs, conversation.ConversationResult{
-			Result:     resp.Choices[i].Content,
-			Parameters: r.Parameters,
-		})
-	}
-
-	res = &conversation.ConversationResponse{
-		Outputs: outputs,
-	}
+	return conversation.LangchainConverse(ctx, b.llm, &b.History, &b.Scrubber, b.logger, r, nil)
+}
 
-	return res, nil
+// ConverseStream sends the response to fn while it's generated.
+func (b *AWSBedrock) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, fn conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return conversation.LangchainConverse(ctx, b.llm, &b.History, &b.Scrubber, b.logger, r, fn)
 }
 
 func (b *AWSBedrock) Close() error {
@@ -16,6 +16,7 @@ package conversation
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 
 	"google.golang.org/protobuf/types/known/anypb"
@@ -33,9 +34,48 @@ type Conversation interface {
 	io.Closer
 }
 
+// StreamingConversation is an optional interface for conversation components that can stream the response while it's generated.
+type StreamingConversation interface {
+	// ConverseStream invokes fn with each chunk of the response as soon as it's generated.
+	// Once the model has finished, it returns the complete response, including the usage summary.
+	ConverseStream(ctx context.Context, req *ConversationRequest, fn StreamFunc) (*ConversationResponse, error)
+}
+
+// StreamFunc is invoked with each chunk of a streamed response.
+// Returning an error stops the stream.
+type StreamFunc func(ctx context.Context, chunk *ConversationStreamChunk) error
+
+// ConversationStreamChunk is an incremental part of a streamed response.
+type ConversationStreamChunk struct {
+	Content string `json:"content"`
+}
+
 type ConversationInput struct {
 	Message string `json:"string"`
 	Role    Role   `json:"role"`
+
+	// Tool calls requested by the model in a previous assistant message.
+	ToolCalls []ToolCall `json:"toolCalls,omitempty"`
+	// For tool messages, the ID of the tool call whose result is in Message.
+	ToolCallID string `json:"toolCallId,omitempty"`
+	// For tool and function messages, the name of the tool that was called.
+	Name string `json:"name,omitempty"`
+}
+
+// Tool is a function that the model can request to call.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// JSON schema of the arguments of the function.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolCall is a request from the model to call a tool.
+type ToolCall struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Arguments of the call, as a JSON object.
+	Arguments string `json:"arguments"`
 }
 
 type ConversationRequest struct {
@@ -43,6 +83,7 @@ type ConversationRequest struct {
 	Parameters          map[string]*anypb.Any `json:"parameters"`
 	ConversationContext string                `json:"conversationContext"`
 	Temperature         float64               `json:"temperature"`
+	Tools               []Tool                `json:"tools,omitempty"`
 
 	// from metadata
 	Key       string   `json:"key"`
@@ -54,11 +95,36 @@ type ConversationRequest struct {
 type ConversationResult struct {
 	Result     string                `json:"result"`
 	Parameters map[string]*anypb.Any `json:"parameters"`
+	ToolCalls  []ToolCall            `json:"toolCalls,omitempty"`
+	// Reason why the model stopped generating, normalized to one of the FinishReason constants when possible.
+	FinishReason string `json:"finishReason,omitempty"`
 }
 
 type ConversationResponse struct {
 	ConversationContext string               `json:"conversationContext"`
 	Outputs             []ConversationResult `json:"outputs"`
+	Usage               *ConversationUsage   `json:"usage,omitempty"`
+	// Scrubbing rules that matched the inputs or outputs; nil if none matched.
+	Scrubbed *ScrubReport `json:"scrubbed,omitempty"`
+}
+
+// Finish reasons reported in ConversationResult.
+const (
+	// The model reached a natural stopping point or a stop sequence.
+	FinishReasonStop = "stop"
+	// The response was truncated because it reached the maximum number of tokens.
+	FinishReasonLength = "length"
+	// The model requested to call tools.
+	FinishReasonToolCalls = "tool_calls"
+	// The response was blocked or truncated by a content filter.
+	FinishReasonContentFilter = "content_filter"
+)
+
+// ConversationUsage contains the number of tokens used by a request.
+type ConversationUsage struct {
+	PromptTokens     int64 `json:"promptTokens"`
+	CompletionTokens int64 `json:"completionTokens"`
+	TotalTokens      int64 `json:"totalTokens"`
 }
 
 type Role string
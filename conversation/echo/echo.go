@@ -17,6 +17,8 @@ package echo
 import (
 	"context"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/dapr/components-contrib/conversation"
 	"github.com/dapr/components-contrib/metadata"
@@ -57,23 +59,86 @@ func (e *Echo) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
 }
 
 // Converse returns inputs directly.
+// If tools are declared, user messages that mention a tool request a call to it.
 func (e *Echo) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
 	outputs := make([]conversation.ConversationResult, 0, len(r.Inputs))
 
-	for _, input := range r.Inputs {
+	for i, input := range r.Inputs {
+		calls := toolCalls(i, input, r.Tools)
+		finishReason := conversation.FinishReasonStop
+		if len(calls) > 0 {
+			finishReason = conversation.FinishReasonToolCalls
+		}
+
 		outputs = append(outputs, conversation.ConversationResult{
-			Result:     input.Message,
-			Parameters: r.Parameters,
+			Result:       input.Message,
+			Parameters:   r.Parameters,
+			ToolCalls:    calls,
+			FinishReason: finishReason,
 		})
 	}
 
 	res = &conversation.ConversationResponse{
 		Outputs: outputs,
+		Usage:   usage(r),
 	}
 
 	return res, nil
 }
 
+// ConverseStream returns inputs directly, sending them to fn one word at a time.
+func (e *Echo) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, fn conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	for _, input := range r.Inputs {
+		for _, word := range strings.SplitAfter(input.Message, " ") {
+			if word == "" {
+				continue
+			}
+			err = fn(ctx, &conversation.ConversationStreamChunk{
+				Content: word,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return e.Converse(ctx, r)
+}
+
+// toolCalls emulates tool calling: a user message that mentions the name of a tool requests a call to that tool, with no arguments.
+func toolCalls(idx int, input conversation.ConversationInput, tools []conversation.Tool) []conversation.ToolCall {
+	if input.Role != "" && input.Role != conversation.RoleUser {
+		return nil
+	}
+
+	var res []conversation.ToolCall
+	for _, t := range tools {
+		if t.Name == "" || !strings.Contains(input.Message, t.Name) {
+			continue
+		}
+		res = append(res, conversation.ToolCall{
+			ID:        "call_" + strconv.Itoa(idx) + "_" + strconv.Itoa(len(res)),
+			Name:      t.Name,
+			Arguments: "{}",
+		})
+	}
+	return res
+}
+
+// usage emulates the token usage, counting each word as a token.
+func usage(r *conversation.ConversationRequest) *conversation.ConversationUsage {
+	var tokens int64
+	for _, input := range r.Inputs {
+		tokens += int64(len(strings.Fields(input.Message)))
+	}
+
+	return &conversation.ConversationUsage{
+		PromptTokens:     tokens,
+		CompletionTokens: tokens,
+		TotalTokens:      2 * tokens,
+	}
+}
+
 func (e *Echo) Close() error {
 	return nil
 }
@@ -2,6 +2,7 @@ package echo
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/dapr/components-contrib/conversation"
@@ -26,3 +27,101 @@ func TestConverse(t *testing.T) {
 	assert.Len(t, r.Outputs, 1)
 	assert.Equal(t, "hello", r.Outputs[0].Result)
 }
+
+func TestConverseStream(t *testing.T) {
+	e := NewEcho(logger.NewLogger("echo test"))
+	e.Init(context.Background(), conversation.Metadata{})
+
+	chunks := []string{}
+	r, err := e.(conversation.StreamingConversation).ConverseStream(context.Background(), &conversation.ConversationRequest{
+		Inputs: []conversation.ConversationInput{
+			{
+				Message: "hello dapr world",
+			},
+		},
+	}, func(ctx context.Context, chunk *conversation.ConversationStreamChunk) error {
+		chunks = append(chunks, chunk.Content)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello ", "dapr ", "world"}, chunks)
+	assert.Len(t, r.Outputs, 1)
+	assert.Equal(t, "hello dapr world", r.Outputs[0].Result)
+	require.NotNil(t, r.Usage)
+	assert.Equal(t, int64(3), r.Usage.PromptTokens)
+	assert.Equal(t, int64(3), r.Usage.CompletionTokens)
+	assert.Equal(t, int64(6), r.Usage.TotalTokens)
+
+	t.Run("error stops the stream", func(t *testing.T) {
+		_, err := e.(conversation.StreamingConversation).ConverseStream(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{
+				{
+					Message: "hello dapr world",
+				},
+			},
+		}, func(ctx context.Context, chunk *conversation.ConversationStreamChunk) error {
+			return errors.New("stop")
+		})
+		require.EqualError(t, err, "stop")
+	})
+}
+
+func TestConverseTools(t *testing.T) {
+	e := NewEcho(logger.NewLogger("echo test"))
+	e.Init(context.Background(), conversation.Metadata{})
+
+	r, err := e.Converse(context.Background(), &conversation.ConversationRequest{
+		Inputs: []conversation.ConversationInput{
+			{
+				Message: "call get_weather and get_time",
+				Role:    conversation.RoleUser,
+			},
+			{
+				Message:    "sunny",
+				Role:       conversation.RoleTool,
+				ToolCallID: "call_0_0",
+				Name:       "get_weather",
+			},
+			{
+				Message: "no tools here",
+			},
+		},
+		Tools: []conversation.Tool{
+			{Name: "get_weather", Description: "Get the weather", Parameters: []byte(`{"type":"object"}`)},
+			{Name: "get_time", Description: "Get the time"},
+			{Name: "get_date", Description: "Get the date"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, r.Outputs, 3)
+	assert.Equal(t, []conversation.ToolCall{
+		{ID: "call_0_0", Name: "get_weather", Arguments: "{}"},
+		{ID: "call_0_1", Name: "get_time", Arguments: "{}"},
+	}, r.Outputs[0].ToolCalls)
+	assert.Empty(t, r.Outputs[1].ToolCalls)
+	assert.Equal(t, "sunny", r.Outputs[1].Result)
+	assert.Empty(t, r.Outputs[2].ToolCalls)
+	assert.Equal(t, conversation.FinishReasonToolCalls, r.Outputs[0].FinishReason)
+	assert.Equal(t, conversation.FinishReasonStop, r.Outputs[2].FinishReason)
+}
+
+func TestConverseUsage(t *testing.T) {
+	e := NewEcho(logger.NewLogger("echo test"))
+	e.Init(context.Background(), conversation.Metadata{})
+
+	r, err := e.Converse(context.Background(), &conversation.ConversationRequest{
+		Inputs: []conversation.ConversationInput{
+			{Message: "hello dapr"},
+			{Message: "what is the time?"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, r.Outputs, 2)
+	assert.Equal(t, conversation.FinishReasonStop, r.Outputs[0].FinishReason)
+	assert.Equal(t, conversation.FinishReasonStop, r.Outputs[1].FinishReason)
+	assert.Equal(t, &conversation.ConversationUsage{
+		PromptTokens:     6,
+		CompletionTokens: 6,
+		TotalTokens:      12,
+	}, r.Usage)
+}
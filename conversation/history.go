@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+)
+
+const (
+	historyKeyPrefix = "conversation-history||"
+
+	// Number of attempts to save the history when it's updated concurrently
+	historySaveAttempts = 3
+)
+
+// HistoryMetadata contains the properties that configure the conversation history.
+// They have effect only when a state store is set with SetHistoryStore, so they're not documented in the metadata of the components.
+type HistoryMetadata struct {
+	// Maximum number of turns of the history that are sent to the model and stored. 0 keeps all turns.
+	HistoryMaxTurns int `json:"historyMaxTurns" mdignore:"true"`
+	// Maximum number of tokens of the history that are sent to the model and stored, estimated from the length of the messages. 0 means no limit.
+	HistoryMaxTokens int `json:"historyMaxTokens" mdignore:"true"`
+	// Time-to-live of the history of a conversation in the state store, as a Go duration. Empty means no expiration.
+	HistoryTTL string `json:"historyTTL" mdignore:"true"`
+}
+
+// HistoryConversation is implemented by conversation components that can keep the history of conversations in a state store.
+// This is a hook for applications that use the components as a library: the Dapr runtime doesn't set a state store, so components configured through a component definition don't keep any history.
+type HistoryConversation interface {
+	// SetHistoryStore sets the state store, which must be initialized, used to keep the history of conversations keyed by ConversationContext.
+	SetHistoryStore(store state.Store)
+}
+
+// History keeps the turns of conversations in a state store, keyed by the conversation context.
+// Components embed History to implement HistoryConversation; until a state store is set, no history is kept.
+// System messages are not stored, so they must be sent with each request.
+type History struct {
+	store    state.Store
+	metadata HistoryMetadata
+	ttl      time.Duration
+}
+
+// historyTurn is a request and the response of the model.
+type historyTurn struct {
+	Inputs []ConversationInput `json:"inputs"`
+	Output *ConversationInput  `json:"output,omitempty"`
+}
+
+// SetHistoryStore sets the state store used to keep the history.
+func (h *History) SetHistoryStore(store state.Store) {
+	h.store = store
+}
+
+// InitHistory validates and sets the configuration of the history.
+func (h *History) InitHistory(md HistoryMetadata) error {
+	if md.HistoryMaxTurns < 0 {
+		return errors.New("historyMaxTurns must not be negative")
+	}
+	if md.HistoryMaxTokens < 0 {
+		return errors.New("historyMaxTokens must not be negative")
+	}
+
+	h.ttl = 0
+	if md.HistoryTTL != "" {
+		d, err := time.ParseDuration(md.HistoryTTL)
+		if err != nil {
+			return fmt.Errorf("failed to parse historyTTL duration: %w", err)
+		}
+		if d < time.Second {
+			return errors.New("historyTTL must be at least 1s")
+		}
+		h.ttl = d
+	}
+
+	h.metadata = md
+	return nil
+}
+
+// LoadHistory returns the inputs of a request, preceded by the history of its conversation.
+// If no state store is set or the request has no conversation context, the inputs of the request are returned as-is.
+func (h *History) LoadHistory(ctx context.Context, r *ConversationRequest) ([]ConversationInput, error) {
+	if h.store == nil || r.ConversationContext == "" {
+		return r.Inputs, nil
+	}
+
+	turns, _, err := h.getTurns(ctx, r.ConversationContext)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make([]ConversationInput, 0, 2*len(turns)+len(r.Inputs))
+	for _, t := range turns {
+		inputs = append(inputs, t.Inputs...)
+		if t.Output != nil {
+			inputs = append(inputs, *t.Output)
+		}
+	}
+	return append(inputs, r.Inputs...), nil
+}
+
+// SaveHistory appends the inputs of a request and the first output of its response to the history of the conversation, and sets the conversation context in the response.
+// If no state store is set or the request has no conversation context, it does nothing.
+func (h *History) SaveHistory(ctx context.Context, r *ConversationRequest, res *ConversationResponse) (err error) {
+	if h.store == nil || r.ConversationContext == "" {
+		return nil
+	}
+	res.ConversationContext = r.ConversationContext
+
+	turn := historyTurn{
+		Inputs: make([]ConversationInput, 0, len(r.Inputs)),
+	}
+	for _, input := range r.Inputs {
+		if input.Role != RoleSystem {
+			turn.Inputs = append(turn.Inputs, input)
+		}
+	}
+	if len(res.Outputs) > 0 {
+		turn.Output = &ConversationInput{
+			Message:   res.Outputs[0].Result,
+			Role:      RoleAssistant,
+			ToolCalls: res.Outputs[0].ToolCalls,
+		}
+	}
+
+	// Retry if the history was updated by another request in the meanwhile
+	for range historySaveAttempts {
+		var (
+			turns []historyTurn
+			etag  *string
+		)
+		turns, etag, err = h.getTurns(ctx, r.ConversationContext)
+		if err != nil {
+			return err
+		}
+
+		err = h.setTurns(ctx, r.ConversationContext, h.truncate(append(turns, turn)), etag)
+		var etagErr *state.ETagError
+		if !errors.As(err, &etagErr) {
+			return err
+		}
+	}
+	return err
+}
+
+// truncate removes the oldest turns that exceed the maximum number of turns or tokens.
+func (h *History) truncate(turns []historyTurn) []historyTurn {
+	if h.metadata.HistoryMaxTurns > 0 && len(turns) > h.metadata.HistoryMaxTurns {
+		turns = turns[len(turns)-h.metadata.HistoryMaxTurns:]
+	}
+
+	if h.metadata.HistoryMaxTokens > 0 {
+		tokens := 0
+		for i := len(turns) - 1; i >= 0; i-- {
+			tokens += turns[i].estimateTokens()
+			if tokens > h.metadata.HistoryMaxTokens {
+				return turns[i+1:]
+			}
+		}
+	}
+
+	return turns
+}
+
+func (h *History) getTurns(ctx context.Context, contextID string) ([]historyTurn, *string, error) {
+	res, err := h.store.Get(ctx, &state.GetRequest{
+		Key: historyKeyPrefix + contextID,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load conversation history: %w", err)
+	}
+	if res == nil || len(res.Data) == 0 {
+		return nil, nil, nil
+	}
+
+	var turns []historyTurn
+	err = json.Unmarshal(res.Data, &turns)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse conversation history: %w", err)
+	}
+	return turns, res.ETag, nil
+}
+
+func (h *History) setTurns(ctx context.Context, contextID string, turns []historyTurn, etag *string) error {
+	req := &state.SetRequest{
+		Key:   historyKeyPrefix + contextID,
+		Value: turns,
+	}
+	if state.FeatureETag.IsPresent(h.store.Features()) {
+		// Fail if the history was updated after it was loaded
+		req.ETag = etag
+		req.Options.Concurrency = state.FirstWrite
+	}
+	if h.ttl > 0 {
+		req.Metadata = map[string]string{
+			metadata.TTLInSecondsMetadataKey: strconv.FormatInt(int64(h.ttl.Seconds()), 10),
+		}
+	}
+
+	err := h.store.Set(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to save conversation history: %w", err)
+	}
+	return nil
+}
+
+// estimateTokens returns the approximate number of tokens of a turn, assuming 4 characters per token.
+func (t historyTurn) estimateTokens() int {
+	chars := 0
+	for _, input := range t.Inputs {
+		chars += len(input.Message)
+	}
+	if t.Output != nil {
+		chars += len(t.Output.Message)
+	}
+	return (chars + 3) / 4
+}
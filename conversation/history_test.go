@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+func newTestHistory(t *testing.T, md HistoryMetadata) *History {
+	t.Helper()
+
+	store := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	require.NoError(t, store.Init(context.Background(), state.Metadata{}))
+	t.Cleanup(func() {
+		store.(interface{ Close() error }).Close()
+	})
+
+	h := &History{}
+	require.NoError(t, h.InitHistory(md))
+	h.SetHistoryStore(store)
+	return h
+}
+
+// converse simulates a component that replies with the given message.
+func converse(t *testing.T, h *History, r *ConversationRequest, reply string) []ConversationInput {
+	t.Helper()
+
+	inputs, err := h.LoadHistory(context.Background(), r)
+	require.NoError(t, err)
+
+	res := &ConversationResponse{
+		Outputs: []ConversationResult{{Result: reply}},
+	}
+	require.NoError(t, h.SaveHistory(context.Background(), r, res))
+	if h.store != nil {
+		assert.Equal(t, r.ConversationContext, res.ConversationContext)
+	}
+
+	return inputs
+}
+
+func TestHistory(t *testing.T) {
+	t.Run("no store", func(t *testing.T) {
+		h := &History{}
+		r := &ConversationRequest{
+			Inputs:              []ConversationInput{{Message: "hi", Role: RoleUser}},
+			ConversationContext: "ctx",
+		}
+
+		inputs := converse(t, h, r, "hello")
+		assert.Equal(t, r.Inputs, inputs)
+	})
+
+	t.Run("no conversation context", func(t *testing.T) {
+		h := newTestHistory(t, HistoryMetadata{})
+		r := &ConversationRequest{
+			Inputs: []ConversationInput{{Message: "hi", Role: RoleUser}},
+		}
+
+		converse(t, h, r, "hello")
+		inputs := converse(t, h, r, "hello")
+		assert.Equal(t, r.Inputs, inputs)
+	})
+
+	t.Run("previous turns are prepended", func(t *testing.T) {
+		h := newTestHistory(t, HistoryMetadata{})
+
+		converse(t, h, &ConversationRequest{
+			Inputs: []ConversationInput{
+				{Message: "be brief", Role: RoleSystem},
+				{Message: "my name is Dapr", Role: RoleUser},
+			},
+			ConversationContext: "ctx",
+		}, "nice to meet you")
+
+		inputs := converse(t, h, &ConversationRequest{
+			Inputs:              []ConversationInput{{Message: "what's my name?", Role: RoleUser}},
+			ConversationContext: "ctx",
+		}, "Dapr")
+
+		assert.Equal(t, []ConversationInput{
+			{Message: "my name is Dapr", Role: RoleUser},
+			{Message: "nice to meet you", Role: RoleAssistant},
+			{Message: "what's my name?", Role: RoleUser},
+		}, inputs)
+
+		// Other conversations are not affected
+		inputs = converse(t, h, &ConversationRequest{
+			Inputs:              []ConversationInput{{Message: "hi", Role: RoleUser}},
+			ConversationContext: "other",
+		}, "hello")
+		assert.Len(t, inputs, 1)
+	})
+
+	t.Run("tool calls are kept", func(t *testing.T) {
+		h := newTestHistory(t, HistoryMetadata{})
+		r := &ConversationRequest{
+			Inputs:              []ConversationInput{{Message: "weather in Paris?", Role: RoleUser}},
+			ConversationContext: "ctx",
+		}
+
+		_, err := h.LoadHistory(context.Background(), r)
+		require.NoError(t, err)
+		toolCalls := []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"Paris"}`}}
+		require.NoError(t, h.SaveHistory(context.Background(), r, &ConversationResponse{
+			Outputs: []ConversationResult{{ToolCalls: toolCalls}},
+		}))
+
+		inputs := converse(t, h, &ConversationRequest{
+			Inputs:              []ConversationInput{{Message: "sunny", Role: RoleTool, ToolCallID: "call_1", Name: "get_weather"}},
+			ConversationContext: "ctx",
+		}, "It's sunny")
+
+		require.Len(t, inputs, 3)
+		assert.EqualValues(t, RoleAssistant, inputs[1].Role)
+		assert.Equal(t, toolCalls, inputs[1].ToolCalls)
+		assert.EqualValues(t, RoleTool, inputs[2].Role)
+	})
+
+	t.Run("truncate by turns", func(t *testing.T) {
+		h := newTestHistory(t, HistoryMetadata{HistoryMaxTurns: 2})
+
+		var inputs []ConversationInput
+		for _, msg := range []string{"one", "two", "three", "four"} {
+			inputs = converse(t, h, &ConversationRequest{
+				Inputs:              []ConversationInput{{Message: msg, Role: RoleUser}},
+				ConversationContext: "ctx",
+			}, msg)
+		}
+
+		assert.Equal(t, []ConversationInput{
+			{Message: "two", Role: RoleUser},
+			{Message: "two", Role: RoleAssistant},
+			{Message: "three", Role: RoleUser},
+			{Message: "three", Role: RoleAssistant},
+			{Message: "four", Role: RoleUser},
+		}, inputs)
+	})
+
+	t.Run("truncate by tokens", func(t *testing.T) {
+		// Each turn is 16 characters, or 4 tokens
+		h := newTestHistory(t, HistoryMetadata{HistoryMaxTokens: 10})
+
+		var inputs []ConversationInput
+		for _, msg := range []string{"aaaaaaaa", "bbbbbbbb", "cccccccc", "dddddddd"} {
+			inputs = converse(t, h, &ConversationRequest{
+				Inputs:              []ConversationInput{{Message: msg, Role: RoleUser}},
+				ConversationContext: "ctx",
+			}, msg)
+		}
+
+		require.Len(t, inputs, 5)
+		assert.Equal(t, "bbbbbbbb", inputs[0].Message)
+		assert.Equal(t, "dddddddd", inputs[4].Message)
+	})
+}
+
+func TestInitHistory(t *testing.T) {
+	h := &History{}
+
+	require.NoError(t, h.InitHistory(HistoryMetadata{HistoryTTL: "1h"}))
+	assert.Equal(t, time.Hour, h.ttl)
+
+	require.Error(t, h.InitHistory(HistoryMetadata{HistoryMaxTurns: -1}))
+	require.Error(t, h.InitHistory(HistoryMetadata{HistoryMaxTokens: -1}))
+	require.Error(t, h.InitHistory(HistoryMetadata{HistoryTTL: "soon"}))
+	require.Error(t, h.InitHistory(HistoryMetadata{HistoryTTL: "10ms"}))
+}
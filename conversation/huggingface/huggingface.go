@@ -16,6 +16,7 @@ package huggingface
 
 import (
 	"context"
+	"errors"
 	"reflect"
 
 	"github.com/dapr/components-contrib/conversation"
@@ -28,6 +29,10 @@ import (
 )
 
 type Huggingface struct {
+	conversation.History
+	conversation.Scrubber
+	conversation.Cache
+
 	llm llms.Model
 
 	logger logger.Logger
@@ -50,6 +55,16 @@ func (h *Huggingface) Init(ctx context.Context, meta conversation.Metadata) erro
 		return err
 	}
 
+	err = h.InitHistory(m.HistoryMetadata)
+	if err != nil {
+		return err
+	}
+
+	err = h.InitScrubber(m.ScrubMetadata)
+	if err != nil {
+		return err
+	}
+
 	model := defaultModel
 	if m.Model != "" {
 		model = m.Model
@@ -66,7 +81,7 @@ func (h *Huggingface) Init(ctx context.Context, meta conversation.Metadata) erro
 	h.llm = llm
 
 	if m.CacheTTL != "" {
-		cachedModel, cacheErr := conversation.CacheModel(ctx, m.CacheTTL, h.llm)
+		cachedModel, cacheErr := h.InitCache(ctx, m.CacheTTL, model, h.llm, h.logger)
 		if cacheErr != nil {
 			return cacheErr
 		}
@@ -84,44 +99,20 @@ func (h *Huggingface) GetComponentMetadata() (metadataInfo metadata.MetadataMap)
 }
 
 func (h *Huggingface) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
-	messages := make([]llms.MessageContent, 0, len(r.Inputs))
-
-	for _, input := range r.Inputs {
-		role := conversation.ConvertLangchainRole(input.Role)
-
-		messages = append(messages, llms.MessageContent{
-			Role: role,
-			Parts: []llms.ContentPart{
-				llms.TextPart(input.Message),
-			},
-		})
-	}
-
-	opts := []llms.CallOption{}
-
-	if r.Temperature > 0 {
-		opts = append(opts, conversation.LangchainTemperature(r.Temperature))
-	}
-
-	resp, err := h.llm.GenerateContent(ctx, messages, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	outputs := make([]conversation.ConversationResult, 0, len(resp.Choices))
+	return h.converse(ctx, r, nil)
+}
 
-	for i := range resp.Choices {
-		outputs = append(outputs, conversation.ConversationResult{
-			Result:     resp.Choices[i].Content,
-			Parameters: r.Parameters,
-		})
-	}
+// ConverseStream sends the response to fn while it's generated.
+func (h *Huggingface) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, fn conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return h.converse(ctx, r, fn)
+}
 
-	res = &conversation.ConversationResponse{
-		Outputs: outputs,
+func (h *Huggingface) converse(ctx context.Context, r *conversation.ConversationRequest, fn conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	if len(r.Tools) > 0 {
+		return nil, errors.New("tools are not supported by the Hugging Face component")
 	}
 
-	return res, nil
+	return conversation.LangchainConverse(ctx, h.llm, &h.History, &h.Scrubber, h.logger, r, fn)
 }
 
 func (h *Huggingface) Close() error {
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/dapr/kit/logger"
+)
+
+// LangchainConverse sends a request to a langchain model, preceded by the history of its conversation, and returns the response.
+// If fn is not nil, the response is streamed to fn while it's generated.
+// The request and the response are scrubbed by scrubber, and the response is added to history.
+// Failing to save the history doesn't fail the request, as the model has already replied: the error is logged instead.
+func LangchainConverse(ctx context.Context, model llms.Model, history *History, scrubber *Scrubber, log logger.Logger, r *ConversationRequest, fn StreamFunc, opts ...llms.CallOption) (*ConversationResponse, error) {
+	r, scrubbed := scrubber.ScrubRequest(r)
+
+	inputs, err := history.LoadHistory(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	messages := LangchainMessages(inputs)
+
+	if r.Temperature > 0 {
+		opts = append(opts, LangchainTemperature(r.Temperature))
+	}
+
+	if len(r.Tools) > 0 {
+		tools, toolsErr := LangchainTools(r.Tools)
+		if toolsErr != nil {
+			return nil, toolsErr
+		}
+		opts = append(opts, llms.WithTools(tools))
+	}
+
+	resp, err := LangchainGenerateContent(ctx, model, messages, scrubber.ScrubStream(fn), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]ConversationResult, 0, len(resp.Choices))
+
+	for i := range resp.Choices {
+		outputs = append(outputs, ConversationResult{
+			Result:       resp.Choices[i].Content,
+			Parameters:   r.Parameters,
+			ToolCalls:    LangchainToolCalls(resp.Choices[i]),
+			FinishReason: LangchainFinishReason(resp.Choices[i]),
+		})
+	}
+
+	res := &ConversationResponse{
+		Outputs: outputs,
+		Usage:   LangchainUsage(resp),
+	}
+
+	err = scrubber.ScrubResponse(ctx, res, scrubbed, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = history.SaveHistory(ctx, r, res)
+	if err != nil {
+		log.Errorf("Failed to save the history of conversation %q: %v", r.ConversationContext, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+// readOnlyStore is a state store that fails all writes.
+type readOnlyStore struct {
+	state.Store
+}
+
+func (s readOnlyStore) Set(ctx context.Context, req *state.SetRequest) error {
+	return errors.New("read-only")
+}
+
+func TestLangchainConverse(t *testing.T) {
+	model := &fakeModel{
+		resp: &llms.ContentResponse{
+			Choices: []*llms.ContentChoice{
+				{Content: "hello", StopReason: "stop"},
+			},
+		},
+	}
+	r := &ConversationRequest{
+		Inputs:              []ConversationInput{{Message: "hi", Role: RoleUser}},
+		ConversationContext: "ctx",
+	}
+	log := logger.NewLogger("test")
+
+	t.Run("saves the history", func(t *testing.T) {
+		h := newTestHistory(t, HistoryMetadata{})
+		res, err := LangchainConverse(context.Background(), model, h, &Scrubber{}, log, r, nil)
+		require.NoError(t, err)
+		require.Len(t, res.Outputs, 1)
+		assert.Equal(t, "hello", res.Outputs[0].Result)
+		assert.Equal(t, "ctx", res.ConversationContext)
+
+		inputs, err := h.LoadHistory(context.Background(), &ConversationRequest{ConversationContext: "ctx"})
+		require.NoError(t, err)
+		assert.Equal(t, []ConversationInput{
+			{Message: "hi", Role: RoleUser},
+			{Message: "hello", Role: RoleAssistant},
+		}, inputs)
+	})
+
+	t.Run("history errors don't fail the response", func(t *testing.T) {
+		h := newTestHistory(t, HistoryMetadata{})
+		h.SetHistoryStore(readOnlyStore{h.store})
+		res, err := LangchainConverse(context.Background(), model, h, &Scrubber{}, log, r, nil)
+		require.NoError(t, err)
+		require.Len(t, res.Outputs, 1)
+		assert.Equal(t, "hello", res.Outputs[0].Result)
+	})
+}
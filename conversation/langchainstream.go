@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// LangchainGenerateContent invokes a langchain model.
+// If fn is not nil, the response is streamed to fn while it's generated.
+// Models that don't stream, such as cached models returning a previous response, send the content of the first choice as a single chunk.
+func LangchainGenerateContent(ctx context.Context, model llms.Model, messages []llms.MessageContent, fn StreamFunc, opts ...llms.CallOption) (*llms.ContentResponse, error) {
+	if fn == nil {
+		return model.GenerateContent(ctx, messages, opts...)
+	}
+
+	streamed := false
+	opts = append(opts, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		streamed = true
+		return fn(ctx, &ConversationStreamChunk{
+			Content: string(chunk),
+		})
+	}))
+
+	resp, err := model.GenerateContent(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !streamed && len(resp.Choices) > 0 && resp.Choices[0].Content != "" {
+		err = fn(ctx, &ConversationStreamChunk{
+			Content: resp.Choices[0].Content,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModel is a langchain model that returns a fixed response, streaming it if requested.
+type fakeModel struct {
+	chunks []string
+	resp   *llms.ContentResponse
+}
+
+func (m *fakeModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+	if opts.StreamingFunc != nil {
+		for _, c := range m.chunks {
+			err := opts.StreamingFunc(ctx, []byte(c))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m.resp, nil
+}
+
+func (m *fakeModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func TestLangchainGenerateContent(t *testing.T) {
+	resp := &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{
+			{Content: "hello world"},
+		},
+	}
+
+	collect := func(chunks *[]string) StreamFunc {
+		return func(ctx context.Context, chunk *ConversationStreamChunk) error {
+			*chunks = append(*chunks, chunk.Content)
+			return nil
+		}
+	}
+
+	t.Run("without streaming", func(t *testing.T) {
+		res, err := LangchainGenerateContent(context.Background(), &fakeModel{chunks: []string{"hello ", "world"}, resp: resp}, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, resp, res)
+	})
+
+	t.Run("streams chunks", func(t *testing.T) {
+		var chunks []string
+		res, err := LangchainGenerateContent(context.Background(), &fakeModel{chunks: []string{"hello ", "", "world"}, resp: resp}, nil, collect(&chunks))
+		require.NoError(t, err)
+		assert.Equal(t, resp, res)
+		assert.Equal(t, []string{"hello ", "world"}, chunks)
+	})
+
+	t.Run("sends the whole content if the model doesn't stream", func(t *testing.T) {
+		var chunks []string
+		res, err := LangchainGenerateContent(context.Background(), &fakeModel{resp: resp}, nil, collect(&chunks))
+		require.NoError(t, err)
+		assert.Equal(t, resp, res)
+		assert.Equal(t, []string{"hello world"}, chunks)
+	})
+
+	t.Run("error stops the stream", func(t *testing.T) {
+		_, err := LangchainGenerateContent(context.Background(), &fakeModel{chunks: []string{"hello ", "world"}, resp: resp}, nil, func(ctx context.Context, chunk *ConversationStreamChunk) error {
+			return errors.New("stop")
+		})
+		require.EqualError(t, err, "stop")
+	})
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+const langchainToolTypeFunction = "function"
+
+// LangchainMessages converts the inputs of a request to langchain messages, including tool calls and their results.
+func LangchainMessages(inputs []ConversationInput) []llms.MessageContent {
+	messages := make([]llms.MessageContent, 0, len(inputs))
+
+	for _, input := range inputs {
+		role := ConvertLangchainRole(input.Role)
+
+		var parts []llms.ContentPart
+		switch {
+		case role == llms.ChatMessageTypeTool:
+			parts = []llms.ContentPart{
+				llms.ToolCallResponse{
+					ToolCallID: input.ToolCallID,
+					Name:       input.Name,
+					Content:    input.Message,
+				},
+			}
+		case len(input.ToolCalls) > 0:
+			parts = make([]llms.ContentPart, 0, len(input.ToolCalls)+1)
+			if input.Message != "" {
+				parts = append(parts, llms.TextPart(input.Message))
+			}
+			for _, tc := range input.ToolCalls {
+				parts = append(parts, llms.ToolCall{
+					ID:   tc.ID,
+					Type: langchainToolTypeFunction,
+					FunctionCall: &llms.FunctionCall{
+						Name:      tc.Name,
+						Arguments: tc.Arguments,
+					},
+				})
+			}
+		default:
+			parts = []llms.ContentPart{
+				llms.TextPart(input.Message),
+			}
+		}
+
+		messages = append(messages, llms.MessageContent{
+			Role:  role,
+			Parts: parts,
+		})
+	}
+
+	return messages
+}
+
+// LangchainTools converts the tools declared in a request to langchain tools.
+func LangchainTools(tools []Tool) ([]llms.Tool, error) {
+	res := make([]llms.Tool, len(tools))
+	for i, t := range tools {
+		if t.Name == "" {
+			return nil, errors.New("tool name must not be empty")
+		}
+
+		// Parameters are passed as a map because some providers can't use raw JSON
+		var params map[string]any
+		if len(t.Parameters) > 0 {
+			err := json.Unmarshal(t.Parameters, &params)
+			if err != nil {
+				return nil, fmt.Errorf("parameters of tool %q must be a JSON schema object: %w", t.Name, err)
+			}
+		}
+
+		res[i] = llms.Tool{
+			Type: langchainToolTypeFunction,
+			Function: &llms.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  params,
+			},
+		}
+	}
+	return res, nil
+}
+
+// LangchainToolCalls returns the tool calls requested by the model in a langchain response choice.
+func LangchainToolCalls(choice *llms.ContentChoice) []ToolCall {
+	if choice == nil {
+		return nil
+	}
+
+	var res []ToolCall
+	for _, tc := range choice.ToolCalls {
+		if tc.FunctionCall == nil {
+			continue
+		}
+		res = append(res, ToolCall{
+			ID:        tc.ID,
+			Name:      tc.FunctionCall.Name,
+			Arguments: tc.FunctionCall.Arguments,
+		})
+	}
+
+	// Legacy function calls are returned without an ID
+	if len(res) == 0 && choice.FuncCall != nil {
+		res = append(res, ToolCall{
+			Name:      choice.FuncCall.Name,
+			Arguments: choice.FuncCall.Arguments,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLangchainMessages(t *testing.T) {
+	messages := LangchainMessages([]ConversationInput{
+		{Message: "what's the weather?", Role: RoleUser},
+		{
+			Role: RoleAssistant,
+			ToolCalls: []ToolCall{
+				{ID: "call_1", Name: "get_weather", Arguments: `{"city":"Rome"}`},
+			},
+		},
+		{Message: "sunny", Role: RoleTool, ToolCallID: "call_1", Name: "get_weather"},
+	})
+
+	assert.Equal(t, []llms.MessageContent{
+		{
+			Role:  llms.ChatMessageTypeHuman,
+			Parts: []llms.ContentPart{llms.TextPart("what's the weather?")},
+		},
+		{
+			Role: llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{
+				llms.ToolCall{
+					ID:           "call_1",
+					Type:         "function",
+					FunctionCall: &llms.FunctionCall{Name: "get_weather", Arguments: `{"city":"Rome"}`},
+				},
+			},
+		},
+		{
+			Role: llms.ChatMessageTypeTool,
+			Parts: []llms.ContentPart{
+				llms.ToolCallResponse{ToolCallID: "call_1", Name: "get_weather", Content: "sunny"},
+			},
+		},
+	}, messages)
+}
+
+func TestLangchainTools(t *testing.T) {
+	t.Run("valid tools", func(t *testing.T) {
+		tools, err := LangchainTools([]Tool{
+			{Name: "get_weather", Description: "Get the weather", Parameters: []byte(`{"type":"object","properties":{"city":{"type":"string"}}}`)},
+			{Name: "get_time", Description: "Get the time"},
+		})
+		require.NoError(t, err)
+		require.Len(t, tools, 2)
+		assert.Equal(t, "function", tools[0].Type)
+		assert.Equal(t, "get_weather", tools[0].Function.Name)
+		assert.Equal(t, "Get the weather", tools[0].Function.Description)
+		assert.Equal(t, map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city": map[string]any{"type": "string"},
+			},
+		}, tools[0].Function.Parameters)
+		assert.Nil(t, tools[1].Function.Parameters)
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		_, err := LangchainTools([]Tool{{Description: "no name"}})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := LangchainTools([]Tool{{Name: "t", Parameters: []byte(`[1,2]`)}})
+		require.ErrorContains(t, err, "must be a JSON schema object")
+	})
+}
+
+func TestLangchainToolCalls(t *testing.T) {
+	assert.Nil(t, LangchainToolCalls(nil))
+
+	assert.Equal(t, []ToolCall{
+		{ID: "call_1", Name: "get_weather", Arguments: `{"city":"Rome"}`},
+	}, LangchainToolCalls(&llms.ContentChoice{
+		ToolCalls: []llms.ToolCall{
+			{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "get_weather", Arguments: `{"city":"Rome"}`}},
+		},
+	}))
+
+	assert.Equal(t, []ToolCall{
+		{Name: "get_time", Arguments: "{}"},
+	}, LangchainToolCalls(&llms.ContentChoice{
+		FuncCall: &llms.FunctionCall{Name: "get_time", Arguments: "{}"},
+	}))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// LangchainUsage returns the token usage reported by a langchain model, or nil if the model didn't report it.
+// Each provider uses different keys in the generation info, so all the known ones are checked.
+func LangchainUsage(resp *llms.ContentResponse) *ConversationUsage {
+	if resp == nil {
+		return nil
+	}
+
+	var (
+		usage ConversationUsage
+		found bool
+	)
+	for _, choice := range resp.Choices {
+		if choice == nil || len(choice.GenerationInfo) == 0 {
+			continue
+		}
+		prompt, okPrompt := generationInfoInt(choice.GenerationInfo, "PromptTokens", "InputTokens", "prompt_tokens", "input_tokens", "input_token_count")
+		completion, okCompletion := generationInfoInt(choice.GenerationInfo, "CompletionTokens", "OutputTokens", "completion_tokens", "output_tokens", "output_token_count")
+		total, okTotal := generationInfoInt(choice.GenerationInfo, "TotalTokens", "total_tokens")
+		if !okPrompt && !okCompletion && !okTotal {
+			continue
+		}
+		if !okTotal {
+			total = prompt + completion
+		}
+
+		// Providers that return multiple choices report the usage of the whole request in each of them
+		found = true
+		usage.PromptTokens = max(usage.PromptTokens, prompt)
+		usage.CompletionTokens = max(usage.CompletionTokens, completion)
+		usage.TotalTokens = max(usage.TotalTokens, total)
+	}
+	if !found {
+		return nil
+	}
+	return &usage
+}
+
+func generationInfoInt(info map[string]any, keys ...string) (int64, bool) {
+	for _, k := range keys {
+		switch v := info[k].(type) {
+		case int:
+			return int64(v), true
+		case int32:
+			return int64(v), true
+		case int64:
+			return v, true
+		case float64:
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// LangchainFinishReason returns the reason why the model stopped generating a langchain response choice.
+// Known provider-specific reasons are normalized to the FinishReason constants; unknown ones are returned as-is.
+func LangchainFinishReason(choice *llms.ContentChoice) string {
+	if choice == nil {
+		return ""
+	}
+
+	reason := choice.StopReason
+	if reason == "" {
+		for _, k := range []string{"StopReason", "stop_reason", "finish_reason", "completion_reason"} {
+			if v, ok := choice.GenerationInfo[k].(string); ok && v != "" {
+				reason = v
+				break
+			}
+		}
+	}
+
+	switch strings.ToLower(reason) {
+	case "":
+		if len(choice.ToolCalls) > 0 || choice.FuncCall != nil {
+			return FinishReasonToolCalls
+		}
+		return ""
+	case "stop", "end_turn", "stop_sequence", "complete", "finish", "eos_token":
+		return FinishReasonStop
+	case "length", "max_tokens", "model_length":
+		return FinishReasonLength
+	case "tool_calls", "tool_use", "function_call":
+		return FinishReasonToolCalls
+	case "content_filter", "content_filtered", "safety", "guardrail_intervened":
+		return FinishReasonContentFilter
+	default:
+		return reason
+	}
+}
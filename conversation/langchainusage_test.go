@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLangchainUsage(t *testing.T) {
+	t.Run("no usage", func(t *testing.T) {
+		assert.Nil(t, LangchainUsage(nil))
+		assert.Nil(t, LangchainUsage(&llms.ContentResponse{
+			Choices: []*llms.ContentChoice{
+				{Content: "hi", GenerationInfo: map[string]any{"StopReason": "stop"}},
+			},
+		}))
+	})
+
+	t.Run("total reported", func(t *testing.T) {
+		usage := LangchainUsage(&llms.ContentResponse{
+			Choices: []*llms.ContentChoice{
+				{GenerationInfo: map[string]any{"PromptTokens": 10, "CompletionTokens": 5, "TotalTokens": 15}},
+				{GenerationInfo: map[string]any{"PromptTokens": 10, "CompletionTokens": 5, "TotalTokens": 15}},
+			},
+		})
+		assert.Equal(t, &ConversationUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, usage)
+	})
+
+	t.Run("total computed", func(t *testing.T) {
+		usage := LangchainUsage(&llms.ContentResponse{
+			Choices: []*llms.ContentChoice{
+				{GenerationInfo: map[string]any{"input_tokens": int32(7), "output_tokens": float64(3)}},
+			},
+		})
+		assert.Equal(t, &ConversationUsage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}, usage)
+	})
+}
+
+func TestLangchainFinishReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		choice *llms.ContentChoice
+		want   string
+	}{
+		{name: "nil choice", choice: nil, want: ""},
+		{name: "openai stop", choice: &llms.ContentChoice{StopReason: "stop"}, want: FinishReasonStop},
+		{name: "anthropic end turn", choice: &llms.ContentChoice{StopReason: "end_turn"}, want: FinishReasonStop},
+		{name: "openai length", choice: &llms.ContentChoice{StopReason: "length"}, want: FinishReasonLength},
+		{name: "anthropic max tokens", choice: &llms.ContentChoice{StopReason: "max_tokens"}, want: FinishReasonLength},
+		{name: "anthropic tool use", choice: &llms.ContentChoice{StopReason: "tool_use"}, want: FinishReasonToolCalls},
+		{name: "from generation info", choice: &llms.ContentChoice{GenerationInfo: map[string]any{"stop_reason": "max_tokens"}}, want: FinishReasonLength},
+		{name: "inferred from tool calls", choice: &llms.ContentChoice{ToolCalls: []llms.ToolCall{{ID: "1"}}}, want: FinishReasonToolCalls},
+		{name: "unknown", choice: &llms.ContentChoice{StopReason: "recitation"}, want: "recitation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, LangchainFinishReason(tt.choice))
+		})
+	}
+}
@@ -26,4 +26,7 @@ type LangchainMetadata struct {
 	Key      string `json:"key"`
 	Model    string `json:"model"`
 	CacheTTL string `json:"cacheTTL"`
+
+	HistoryMetadata `json:",inline" mapstructure:",squash"`
+	ScrubMetadata   `json:",inline" mapstructure:",squash"`
 }
@@ -28,6 +28,10 @@ import (
 )
 
 type Mistral struct {
+	conversation.History
+	conversation.Scrubber
+	conversation.Cache
+
 	llm llms.Model
 
 	logger logger.Logger
@@ -50,6 +54,16 @@ func (m *Mistral) Init(ctx context.Context, meta conversation.Metadata) error {
 		return err
 	}
 
+	err = m.InitHistory(md.HistoryMetadata)
+	if err != nil {
+		return err
+	}
+
+	err = m.InitScrubber(md.ScrubMetadata)
+	if err != nil {
+		return err
+	}
+
 	model := defaultModel
 	if md.Model != "" {
 		model = md.Model
@@ -66,7 +80,7 @@ func (m *Mistral) Init(ctx context.Context, meta conversation.Metadata) error {
 	m.llm = llm
 
 	if md.CacheTTL != "" {
-		cachedModel, cacheErr := conversation.CacheModel(ctx, md.CacheTTL, m.llm)
+		cachedModel, cacheErr := m.InitCache(ctx, md.CacheTTL, model, m.llm, m.logger)
 		if cacheErr != nil {
 			return cacheErr
 		}
@@ -83,44 +97,12 @@ func (m *Mistral) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
 }
 
 func (m *Mistral) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
-	messages := make([]llms.MessageContent, 0, len(r.Inputs))
-
-	for _, input := range r.Inputs {
-		role := conversation.ConvertLangchainRole(input.Role)
-
-		messages = append(messages, llms.MessageContent{
-			Role: role,
-			Parts: []llms.ContentPart{
-				llms.TextPart(input.Message),
-			},
-		})
-	}
-
-	opts := []llms.CallOption{}
-
-	if r.Temperature > 0 {
-		opts = append(opts, conversation.LangchainTemperature(r.Temperature))
-	}
-
-	resp, err := m.llm.GenerateContent(ctx, messages, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	outputs := make([]conversation.ConversationResult, 0, len(resp.Choices))
-
-	for i := range resp.Choices {
-		outputs = append(outputs, conversation.ConversationResult{
-			Result:     resp.Choices[i].Content,
-			Parameters: r.Parameters,
-		})
-	}
-
-	res = &conversation.ConversationResponse{
-		Outputs: outputs,
-	}
+	return conversation.LangchainConverse(ctx, m.llm, &m.History, &m.Scrubber, m.logger, r, nil)
+}
 
-	return res, nil
+// ConverseStream sends the response to fn while it's generated.
+func (m *Mistral) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, fn conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return conversation.LangchainConverse(ctx, m.llm, &m.History, &m.Scrubber, m.logger, r, fn)
 }
 
 func (m *Mistral) Close() error {
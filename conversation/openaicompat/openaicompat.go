@@ -0,0 +1,246 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package openaicompat
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/dapr/components-contrib/conversation"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+	kmeta "github.com/dapr/kit/metadata"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+const (
+	apiTypeOpenAI = "openai"
+	apiTypeOllama = "ollama"
+
+	defaultOllamaEndpoint = "http://localhost:11434"
+	defaultAPIKeyHeader   = "Authorization"
+
+	// The langchain OpenAI client requires a token, but the API key header is set by apiKeyTransport
+	placeholderToken = "unused"
+)
+
+// OpenAICompat is a conversation component for self-hosted models served by OpenAI-compatible APIs, such as vLLM, or by Ollama.
+type OpenAICompat struct {
+	conversation.History
+	conversation.Scrubber
+	conversation.Cache
+
+	llm     llms.Model
+	apiType string
+
+	logger logger.Logger
+}
+
+type OpenAICompatMetadata struct {
+	conversation.LangchainMetadata `json:",inline" mapstructure:",squash"`
+
+	// Base URL of the API, for example "http://localhost:8000/v1" for OpenAI-compatible APIs.
+	Endpoint string `json:"endpoint"`
+	// Type of the API: "openai" (the default) or "ollama".
+	APIType string `json:"apiType"`
+	// Name of the header used to send the API key. With the default "Authorization" header, the key is sent as a bearer token.
+	APIKeyHeader string `json:"apiKeyHeader"`
+	// PEM-encoded CA certificate used to verify the server.
+	CACert string `json:"caCert"`
+	// PEM-encoded client certificate and key, for mutual TLS.
+	ClientCert string `json:"clientCert"`
+	ClientKey  string `json:"clientKey"`
+	// Skip the verification of the server certificate. Don't use in production.
+	SkipVerify bool `json:"skipVerify"`
+}
+
+func NewOpenAICompat(logger logger.Logger) conversation.Conversation {
+	o := &OpenAICompat{
+		logger: logger,
+	}
+
+	return o
+}
+
+func (o *OpenAICompat) Init(ctx context.Context, meta conversation.Metadata) error {
+	md := OpenAICompatMetadata{}
+	err := kmeta.DecodeMetadata(meta.Properties, &md)
+	if err != nil {
+		return err
+	}
+
+	err = o.InitHistory(md.HistoryMetadata)
+	if err != nil {
+		return err
+	}
+
+	err = o.InitScrubber(md.ScrubMetadata)
+	if err != nil {
+		return err
+	}
+
+	if md.Model == "" {
+		return errors.New("model is required")
+	}
+
+	httpClient, err := md.httpClient()
+	if err != nil {
+		return err
+	}
+
+	o.apiType = strings.ToLower(md.APIType)
+	switch o.apiType {
+	case "", apiTypeOpenAI:
+		o.apiType = apiTypeOpenAI
+		if md.Endpoint == "" {
+			return errors.New("endpoint is required")
+		}
+
+		o.llm, err = openai.New(
+			openai.WithModel(md.Model),
+			openai.WithBaseURL(md.Endpoint),
+			openai.WithToken(placeholderToken),
+			openai.WithHTTPClient(httpClient),
+		)
+	case apiTypeOllama:
+		endpoint := defaultOllamaEndpoint
+		if md.Endpoint != "" {
+			endpoint = md.Endpoint
+		}
+
+		o.llm, err = ollama.New(
+			ollama.WithModel(md.Model),
+			ollama.WithServerURL(endpoint),
+			ollama.WithHTTPClient(httpClient),
+		)
+	default:
+		return fmt.Errorf("invalid apiType %q: must be %q or %q", md.APIType, apiTypeOpenAI, apiTypeOllama)
+	}
+	if err != nil {
+		return err
+	}
+
+	if md.CacheTTL != "" {
+		cachedModel, cacheErr := o.InitCache(ctx, md.CacheTTL, md.Model, o.llm, o.logger)
+		if cacheErr != nil {
+			return cacheErr
+		}
+
+		o.llm = cachedModel
+	}
+	return nil
+}
+
+// httpClient returns the HTTP client configured with the TLS settings and the API key.
+func (md *OpenAICompatMetadata) httpClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: md.SkipVerify, //nolint:gosec
+	}
+
+	if md.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(md.CACert)) {
+			return nil, errors.New("failed to parse caCert")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if md.ClientCert != "" || md.ClientKey != "" {
+		if md.ClientCert == "" || md.ClientKey == "" {
+			return nil, errors.New("clientCert and clientKey must be set together")
+		}
+		cert, err := tls.X509KeyPair([]byte(md.ClientCert), []byte(md.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	header := defaultAPIKeyHeader
+	if md.APIKeyHeader != "" {
+		header = md.APIKeyHeader
+	}
+
+	return &http.Client{
+		Transport: &apiKeyTransport{
+			base:   transport,
+			header: header,
+			key:    md.Key,
+		},
+	}, nil
+}
+
+// apiKeyTransport replaces the authorization header set by the langchain clients with the configured API key.
+type apiKeyTransport struct {
+	base   http.RoundTripper
+	header string
+	key    string
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrip must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Del(defaultAPIKeyHeader)
+
+	if t.key != "" {
+		if http.CanonicalHeaderKey(t.header) == defaultAPIKeyHeader {
+			req.Header.Set(defaultAPIKeyHeader, "Bearer "+t.key)
+		} else {
+			req.Header.Set(t.header, t.key)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+func (o *OpenAICompat) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := OpenAICompatMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.ConversationType)
+	return
+}
+
+func (o *OpenAICompat) Converse(ctx context.Context, r *conversation.ConversationRequest) (res *conversation.ConversationResponse, err error) {
+	return o.converse(ctx, r, nil)
+}
+
+// ConverseStream sends the response to fn while it's generated.
+func (o *OpenAICompat) ConverseStream(ctx context.Context, r *conversation.ConversationRequest, fn conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	return o.converse(ctx, r, fn)
+}
+
+func (o *OpenAICompat) converse(ctx context.Context, r *conversation.ConversationRequest, fn conversation.StreamFunc) (res *conversation.ConversationResponse, err error) {
+	if len(r.Tools) > 0 && o.apiType == apiTypeOllama {
+		return nil, errors.New("tools are not supported with the ollama API type")
+	}
+
+	return conversation.LangchainConverse(ctx, o.llm, &o.History, &o.Scrubber, o.logger, r, fn)
+}
+
+func (o *OpenAICompat) Close() error {
+	return nil
+}
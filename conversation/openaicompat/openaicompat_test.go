@@ -0,0 +1,306 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/conversation"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// openAIHandler responds to chat completions like an OpenAI-compatible API, and records the last request.
+type openAIHandler struct {
+	header http.Header
+	body   map[string]any
+}
+
+func (h *openAIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/chat/completions" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	h.header = r.Header.Clone()
+	h.body = nil
+	_ = json.NewDecoder(r.Body).Decode(&h.body)
+
+	if h.body["stream"] == true {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"hello "}}]}
+
+data: {"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"from llama"},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{
+		"id": "chatcmpl-1",
+		"object": "chat.completion",
+		"model": "llama3",
+		"choices": [{"index": 0, "message": {"role": "assistant", "content": "hello from llama"}, "finish_reason": "stop"}],
+		"usage": {"prompt_tokens": 5, "completion_tokens": 3, "total_tokens": 8}
+	}`))
+}
+
+func newComponent(t *testing.T, props map[string]string) *OpenAICompat {
+	t.Helper()
+
+	o := NewOpenAICompat(logger.NewLogger("test")).(*OpenAICompat)
+	err := o.Init(context.Background(), conversation.Metadata{Base: metadata.Base{Properties: props}})
+	require.NoError(t, err)
+	return o
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[string]string
+		err   string
+	}{
+		{
+			name:  "missing model",
+			props: map[string]string{"endpoint": "http://localhost:8000/v1"},
+			err:   "model is required",
+		},
+		{
+			name:  "missing endpoint",
+			props: map[string]string{"model": "llama3"},
+			err:   "endpoint is required",
+		},
+		{
+			name:  "invalid api type",
+			props: map[string]string{"model": "llama3", "apiType": "grpc"},
+			err:   "invalid apiType",
+		},
+		{
+			name:  "invalid ca certificate",
+			props: map[string]string{"model": "llama3", "endpoint": "https://localhost/v1", "caCert": "not a certificate"},
+			err:   "failed to parse caCert",
+		},
+		{
+			name:  "client certificate without key",
+			props: map[string]string{"model": "llama3", "endpoint": "https://localhost/v1", "clientCert": "cert"},
+			err:   "clientCert and clientKey must be set together",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOpenAICompat(logger.NewLogger("test"))
+			err := o.Init(context.Background(), conversation.Metadata{Base: metadata.Base{Properties: tt.props}})
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("ollama endpoint defaults to localhost", func(t *testing.T) {
+		newComponent(t, map[string]string{"model": "llama3", "apiType": "ollama"})
+	})
+}
+
+func TestConverseOpenAI(t *testing.T) {
+	h := &openAIHandler{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	t.Run("bearer API key", func(t *testing.T) {
+		o := newComponent(t, map[string]string{
+			"endpoint": srv.URL + "/v1",
+			"model":    "llama3",
+			"key":      "secret",
+		})
+
+		res, err := o.Converse(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{{Message: "hi", Role: conversation.RoleUser}},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, res.Outputs, 1)
+		assert.Equal(t, "hello from llama", res.Outputs[0].Result)
+		assert.Equal(t, conversation.FinishReasonStop, res.Outputs[0].FinishReason)
+		require.NotNil(t, res.Usage)
+		assert.Equal(t, int64(8), res.Usage.TotalTokens)
+
+		assert.Equal(t, "Bearer secret", h.header.Get("Authorization"))
+		assert.Equal(t, "llama3", h.body["model"])
+	})
+
+	t.Run("custom API key header", func(t *testing.T) {
+		o := newComponent(t, map[string]string{
+			"endpoint":     srv.URL + "/v1",
+			"model":        "llama3",
+			"key":          "secret",
+			"apiKeyHeader": "X-Api-Key",
+		})
+
+		_, err := o.Converse(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{{Message: "hi", Role: conversation.RoleUser}},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "secret", h.header.Get("X-Api-Key"))
+		assert.Empty(t, h.header.Get("Authorization"))
+	})
+
+	t.Run("no API key", func(t *testing.T) {
+		o := newComponent(t, map[string]string{
+			"endpoint": srv.URL + "/v1",
+			"model":    "llama3",
+		})
+
+		_, err := o.Converse(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{{Message: "hi", Role: conversation.RoleUser}},
+		})
+		require.NoError(t, err)
+
+		assert.Empty(t, h.header.Get("Authorization"))
+	})
+
+	t.Run("scrub PII", func(t *testing.T) {
+		o := newComponent(t, map[string]string{
+			"endpoint": srv.URL + "/v1",
+			"model":    "llama3",
+			"scrubPII": "true",
+		})
+
+		res, err := o.Converse(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{{Message: "I'm jane@example.com", Role: conversation.RoleUser}},
+		})
+		require.NoError(t, err)
+
+		messages, _ := h.body["messages"].([]any)
+		require.Len(t, messages, 1)
+		assert.Equal(t, "I'm [REDACTED:email]", messages[0].(map[string]any)["content"])
+		require.NotNil(t, res.Scrubbed)
+		assert.Equal(t, map[string]int{conversation.ScrubRuleEmail: 1}, res.Scrubbed.Inputs)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		o := newComponent(t, map[string]string{
+			"endpoint": srv.URL + "/v1",
+			"model":    "llama3",
+		})
+
+		var streamed string
+		res, err := o.ConverseStream(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{{Message: "hi", Role: conversation.RoleUser}},
+		}, func(ctx context.Context, chunk *conversation.ConversationStreamChunk) error {
+			streamed += chunk.Content
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "hello from llama", res.Outputs[0].Result)
+		assert.Equal(t, res.Outputs[0].Result, streamed)
+		stream, _ := h.body["stream"].(bool)
+		assert.True(t, stream)
+	})
+}
+
+func TestConverseTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(&openAIHandler{})
+	defer srv.Close()
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	req := &conversation.ConversationRequest{
+		Inputs: []conversation.ConversationInput{{Message: "hi", Role: conversation.RoleUser}},
+	}
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		o := newComponent(t, map[string]string{
+			"endpoint": srv.URL + "/v1",
+			"model":    "llama3",
+		})
+
+		_, err := o.Converse(context.Background(), req)
+		require.Error(t, err)
+	})
+
+	t.Run("ca certificate", func(t *testing.T) {
+		o := newComponent(t, map[string]string{
+			"endpoint": srv.URL + "/v1",
+			"model":    "llama3",
+			"caCert":   caCert,
+		})
+
+		res, err := o.Converse(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "hello from llama", res.Outputs[0].Result)
+	})
+
+	t.Run("skip verify", func(t *testing.T) {
+		o := newComponent(t, map[string]string{
+			"endpoint":   srv.URL + "/v1",
+			"model":      "llama3",
+			"skipVerify": "true",
+		})
+
+		_, err := o.Converse(context.Background(), req)
+		require.NoError(t, err)
+	})
+}
+
+func TestConverseOllama(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"hello from ollama"},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":3}` + "\n"))
+	}))
+	defer srv.Close()
+
+	o := newComponent(t, map[string]string{
+		"endpoint": srv.URL,
+		"model":    "llama3",
+		"apiType":  "ollama",
+	})
+
+	res, err := o.Converse(context.Background(), &conversation.ConversationRequest{
+		Inputs: []conversation.ConversationInput{{Message: "hi", Role: conversation.RoleUser}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, res.Outputs, 1)
+	assert.Equal(t, "hello from ollama", res.Outputs[0].Result)
+	assert.Equal(t, "llama3", body["model"])
+
+	t.Run("tools are not supported", func(t *testing.T) {
+		_, err := o.Converse(context.Background(), &conversation.ConversationRequest{
+			Inputs: []conversation.ConversationInput{{Message: "hi", Role: conversation.RoleUser}},
+			Tools:  []conversation.Tool{{Name: "get_weather"}},
+		})
+		require.ErrorContains(t, err, "tools are not supported")
+	})
+}
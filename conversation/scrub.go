@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Names of the built-in scrubbing rules.
+const (
+	ScrubRuleEmail      = "email"
+	ScrubRulePhone      = "phone"
+	ScrubRuleCreditCard = "creditCard"
+	ScrubRuleIPAddress  = "ipAddress"
+)
+
+// ScrubMetadata contains the properties that configure the scrubbing of PII from prompts and responses.
+type ScrubMetadata struct {
+	// Mask PII in the inputs with the built-in rules.
+	ScrubPII bool `json:"scrubPII"`
+	// Comma-separated list of the built-in rules to apply. Empty applies all of them.
+	ScrubPIIRules string `json:"scrubPIIRules"`
+	// JSON object mapping the names of custom rules to regular expressions. Custom rules are applied even if scrubPII is false.
+	ScrubCustomRules string `json:"scrubCustomRules"`
+	// Mask PII in the outputs too.
+	ScrubOutputs bool `json:"scrubOutputs"`
+}
+
+// ScrubReport contains the number of matches of each scrubbing rule.
+type ScrubReport struct {
+	Inputs  map[string]int `json:"inputs,omitempty"`
+	Outputs map[string]int `json:"outputs,omitempty"`
+}
+
+type scrubRule struct {
+	name string
+	re   *regexp.Regexp
+	// Optional check of a match, to reduce false positives
+	valid func(match string) bool
+}
+
+// Built-in rules, in the order they are applied.
+// Credit cards and IP addresses are masked before phone numbers, which could match parts of them.
+var builtinScrubRules = []scrubRule{
+	{
+		name: ScrubRuleEmail,
+		re:   regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`),
+	},
+	{
+		name:  ScrubRuleCreditCard,
+		re:    regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		valid: luhnValid,
+	},
+	{
+		name: ScrubRuleIPAddress,
+		re:   regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`),
+		valid: func(match string) bool {
+			return net.ParseIP(match) != nil
+		},
+	},
+	{
+		name: ScrubRulePhone,
+		re:   regexp.MustCompile(`\+\d{8,15}\b|(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)[ .-]?|\b\d{2,4}[ .-])\d{3,4}[ .-]?\d{3,4}\b`),
+	},
+}
+
+// Scrubber masks PII in the inputs and outputs of conversations.
+// Components embed Scrubber; until it's initialized with some rules, it does nothing.
+type Scrubber struct {
+	rules   []scrubRule
+	outputs bool
+}
+
+// InitScrubber validates and sets the configuration of the scrubber.
+func (s *Scrubber) InitScrubber(md ScrubMetadata) error {
+	s.rules = nil
+	s.outputs = md.ScrubOutputs
+
+	if md.ScrubPII {
+		if md.ScrubPIIRules == "" {
+			s.rules = append(s.rules, builtinScrubRules...)
+		} else {
+			for _, name := range strings.Split(md.ScrubPIIRules, ",") {
+				name = strings.TrimSpace(name)
+				i := slices.IndexFunc(builtinScrubRules, func(r scrubRule) bool {
+					return strings.EqualFold(r.name, name)
+				})
+				if i < 0 {
+					return fmt.Errorf("invalid scrubbing rule %q in scrubPIIRules", name)
+				}
+				s.rules = append(s.rules, builtinScrubRules[i])
+			}
+		}
+	}
+
+	if md.ScrubCustomRules != "" {
+		var custom map[string]string
+		err := json.Unmarshal([]byte(md.ScrubCustomRules), &custom)
+		if err != nil {
+			return fmt.Errorf("scrubCustomRules must be a JSON object of rule names and regular expressions: %w", err)
+		}
+
+		// Apply custom rules in a deterministic order
+		names := make([]string, 0, len(custom))
+		for name := range custom {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			if name == "" || slices.ContainsFunc(builtinScrubRules, func(r scrubRule) bool { return r.name == name }) {
+				return fmt.Errorf("invalid name %q for custom scrubbing rule", name)
+			}
+			re, err := regexp.Compile(custom[name])
+			if err != nil {
+				return fmt.Errorf("invalid regular expression for custom scrubbing rule %q: %w", name, err)
+			}
+			s.rules = append(s.rules, scrubRule{
+				name: name,
+				re:   re,
+			})
+		}
+	}
+
+	return nil
+}
+
+// ScrubRequest returns a copy of the request with PII masked in the inputs, and the report of the rules that matched.
+// Both the messages, including the results of tool calls, and the arguments of the tool calls requested in previous messages are scrubbed.
+// If no rule matched, the request is returned as-is.
+func (s *Scrubber) ScrubRequest(r *ConversationRequest) (*ConversationRequest, *ScrubReport) {
+	if len(s.rules) == 0 {
+		return r, &ScrubReport{}
+	}
+
+	report := &ScrubReport{}
+	var inputs []ConversationInput
+	for i, input := range r.Inputs {
+		msg, msgScrubbed := s.scrub(input.Message, &report.Inputs)
+		toolCalls, toolCallsScrubbed := s.scrubToolCalls(input.ToolCalls, &report.Inputs)
+		if !msgScrubbed && !toolCallsScrubbed {
+			continue
+		}
+		if inputs == nil {
+			inputs = slices.Clone(r.Inputs)
+		}
+		inputs[i].Message = msg
+		if toolCallsScrubbed {
+			inputs[i].ToolCalls = toolCalls
+		}
+	}
+	if inputs == nil {
+		return r, report
+	}
+
+	scrubbed := *r
+	scrubbed.Inputs = inputs
+	return &scrubbed, report
+}
+
+// ScrubStream returns the function that must receive the chunks of the response while it's generated.
+// When outputs are scrubbed, chunks are not streamed, as PII could be split across them: ScrubResponse sends the scrubbed response as a single chunk instead.
+func (s *Scrubber) ScrubStream(fn StreamFunc) StreamFunc {
+	if s.scrubOutputs() {
+		return nil
+	}
+	return fn
+}
+
+// ScrubResponse masks PII in the outputs of the response, including the arguments of tool calls, if configured, and sets the report of the rules that matched.
+// If the response is streamed, fn must be the function passed to ScrubStream.
+func (s *Scrubber) ScrubResponse(ctx context.Context, res *ConversationResponse, report *ScrubReport, fn StreamFunc) error {
+	if s.scrubOutputs() {
+		for i := range res.Outputs {
+			if msg, ok := s.scrub(res.Outputs[i].Result, &report.Outputs); ok {
+				res.Outputs[i].Result = msg
+			}
+			if toolCalls, ok := s.scrubToolCalls(res.Outputs[i].ToolCalls, &report.Outputs); ok {
+				res.Outputs[i].ToolCalls = toolCalls
+			}
+		}
+
+		if fn != nil && len(res.Outputs) > 0 && res.Outputs[0].Result != "" {
+			err := fn(ctx, &ConversationStreamChunk{
+				Content: res.Outputs[0].Result,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(report.Inputs) > 0 || len(report.Outputs) > 0 {
+		res.Scrubbed = report
+	}
+	return nil
+}
+
+func (s *Scrubber) scrubOutputs() bool {
+	return s.outputs && len(s.rules) > 0
+}
+
+// scrub masks the matches of all rules in msg, counting them in matches.
+// It returns false if no rule matched.
+func (s *Scrubber) scrub(msg string, matches *map[string]int) (string, bool) {
+	found := false
+	for _, rule := range s.rules {
+		msg = rule.re.ReplaceAllStringFunc(msg, func(match string) string {
+			if rule.valid != nil && !rule.valid(match) {
+				return match
+			}
+			if *matches == nil {
+				*matches = map[string]int{}
+			}
+			(*matches)[rule.name]++
+			found = true
+			return "[REDACTED:" + rule.name + "]"
+		})
+	}
+	return msg, found
+}
+
+// scrubToolCalls masks the matches of all rules in the arguments of the tool calls, counting them in matches.
+// It returns a copy of the tool calls, or false if no rule matched.
+func (s *Scrubber) scrubToolCalls(calls []ToolCall, matches *map[string]int) ([]ToolCall, bool) {
+	var scrubbed []ToolCall
+	for i, call := range calls {
+		args, ok := s.scrub(call.Arguments, matches)
+		if !ok {
+			continue
+		}
+		if scrubbed == nil {
+			scrubbed = slices.Clone(calls)
+		}
+		scrubbed[i].Arguments = args
+	}
+	return scrubbed, scrubbed != nil
+}
+
+// luhnValid returns true if the digits in s have a valid Luhn checksum, as card numbers do.
+func luhnValid(s string) bool {
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrubBuiltinRules(t *testing.T) {
+	s := &Scrubber{}
+	require.NoError(t, s.InitScrubber(ScrubMetadata{ScrubPII: true}))
+
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+		rule     string
+	}{
+		{
+			name:     "email",
+			message:  "write to jane.doe+test@example.co.uk today",
+			expected: "write to [REDACTED:email] today",
+			rule:     ScrubRuleEmail,
+		},
+		{
+			name:     "credit card",
+			message:  "my card is 4111 1111 1111 1111.",
+			expected: "my card is [REDACTED:creditCard].",
+			rule:     ScrubRuleCreditCard,
+		},
+		{
+			name:     "credit card without separators",
+			message:  "card 5500005555555559",
+			expected: "card [REDACTED:creditCard]",
+			rule:     ScrubRuleCreditCard,
+		},
+		{
+			name:     "IPv4 address",
+			message:  "server 192.168.1.20 is down",
+			expected: "server [REDACTED:ipAddress] is down",
+			rule:     ScrubRuleIPAddress,
+		},
+		{
+			name:     "IPv6 address",
+			message:  "server 2001:db8::8a2e:370:7334 is down",
+			expected: "server [REDACTED:ipAddress] is down",
+			rule:     ScrubRuleIPAddress,
+		},
+		{
+			name:     "phone number",
+			message:  "call me at (555) 123-4567",
+			expected: "call me at [REDACTED:phone]",
+			rule:     ScrubRulePhone,
+		},
+		{
+			name:     "international phone number",
+			message:  "call +44 20 7946 0958 or +15551234567",
+			expected: "call [REDACTED:phone] or [REDACTED:phone]",
+			rule:     ScrubRulePhone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, report := s.ScrubRequest(&ConversationRequest{
+				Inputs: []ConversationInput{{Message: tt.message, Role: RoleUser}},
+			})
+			assert.Equal(t, tt.expected, r.Inputs[0].Message)
+			assert.Contains(t, report.Inputs, tt.rule)
+		})
+	}
+
+	t.Run("no false positives", func(t *testing.T) {
+		for _, msg := range []string{
+			"order 1234567890123456 shipped", // fails the Luhn check
+			"the meeting is on 2024-10-16 at 10:30:00",
+			"version 1.2.3 was released",
+		} {
+			r, report := s.ScrubRequest(&ConversationRequest{
+				Inputs: []ConversationInput{{Message: msg, Role: RoleUser}},
+			})
+			assert.Equal(t, msg, r.Inputs[0].Message)
+			assert.Empty(t, report.Inputs)
+		}
+	})
+}
+
+func TestScrubber(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		s := &Scrubber{}
+		require.NoError(t, s.InitScrubber(ScrubMetadata{ScrubOutputs: true}))
+
+		req := &ConversationRequest{
+			Inputs: []ConversationInput{{Message: "jane@example.com", Role: RoleUser}},
+		}
+		r, report := s.ScrubRequest(req)
+		assert.Same(t, req, r)
+
+		res := &ConversationResponse{Outputs: []ConversationResult{{Result: "jane@example.com"}}}
+		require.NoError(t, s.ScrubResponse(context.Background(), res, report, nil))
+		assert.Equal(t, "jane@example.com", res.Outputs[0].Result)
+		assert.Nil(t, res.Scrubbed)
+	})
+
+	t.Run("request is not modified", func(t *testing.T) {
+		s := &Scrubber{}
+		require.NoError(t, s.InitScrubber(ScrubMetadata{ScrubPII: true}))
+
+		req := &ConversationRequest{
+			Inputs: []ConversationInput{
+				{Message: "be helpful", Role: RoleSystem},
+				{Message: "I'm jane@example.com", Role: RoleUser},
+			},
+		}
+		r, report := s.ScrubRequest(req)
+		assert.Equal(t, "I'm jane@example.com", req.Inputs[1].Message)
+		assert.Equal(t, "be helpful", r.Inputs[0].Message)
+		assert.Equal(t, "I'm [REDACTED:email]", r.Inputs[1].Message)
+
+		// Outputs are not scrubbed by default
+		res := &ConversationResponse{Outputs: []ConversationResult{{Result: "Hi jane@example.com"}}}
+		require.NoError(t, s.ScrubResponse(context.Background(), res, report, nil))
+		assert.Equal(t, "Hi jane@example.com", res.Outputs[0].Result)
+		assert.Equal(t, &ScrubReport{Inputs: map[string]int{ScrubRuleEmail: 1}}, res.Scrubbed)
+	})
+
+	t.Run("selected and custom rules", func(t *testing.T) {
+		s := &Scrubber{}
+		require.NoError(t, s.InitScrubber(ScrubMetadata{
+			ScrubPII:         true,
+			ScrubPIIRules:    "email, IPADDRESS",
+			ScrubCustomRules: `{"employeeId": "EMP-[0-9]{6}"}`,
+		}))
+
+		r, report := s.ScrubRequest(&ConversationRequest{
+			Inputs: []ConversationInput{{Message: "EMP-123456 at 10.0.0.1, jane@example.com, EMP-654321, (555) 123-4567", Role: RoleUser}},
+		})
+		assert.Equal(t, "[REDACTED:employeeId] at [REDACTED:ipAddress], [REDACTED:email], [REDACTED:employeeId], (555) 123-4567", r.Inputs[0].Message)
+		assert.Equal(t, map[string]int{"employeeId": 2, ScrubRuleIPAddress: 1, ScrubRuleEmail: 1}, report.Inputs)
+	})
+
+	t.Run("scrub streamed outputs", func(t *testing.T) {
+		s := &Scrubber{}
+		require.NoError(t, s.InitScrubber(ScrubMetadata{
+			ScrubCustomRules: `{"secret": "s3cr3t"}`,
+			ScrubOutputs:     true,
+		}))
+
+		var chunks []string
+		fn := func(ctx context.Context, chunk *ConversationStreamChunk) error {
+			chunks = append(chunks, chunk.Content)
+			return nil
+		}
+		assert.Nil(t, s.ScrubStream(fn))
+
+		_, report := s.ScrubRequest(&ConversationRequest{
+			Inputs: []ConversationInput{{Message: "what's the password?", Role: RoleUser}},
+		})
+		res := &ConversationResponse{Outputs: []ConversationResult{{Result: "it's s3cr3t"}}}
+		require.NoError(t, s.ScrubResponse(context.Background(), res, report, fn))
+
+		assert.Equal(t, "it's [REDACTED:secret]", res.Outputs[0].Result)
+		assert.Equal(t, []string{"it's [REDACTED:secret]"}, chunks)
+		assert.Equal(t, &ScrubReport{Outputs: map[string]int{"secret": 1}}, res.Scrubbed)
+	})
+
+	t.Run("tool calls and results", func(t *testing.T) {
+		s := &Scrubber{}
+		require.NoError(t, s.InitScrubber(ScrubMetadata{ScrubPII: true, ScrubOutputs: true}))
+
+		req := &ConversationRequest{
+			Inputs: []ConversationInput{
+				{Message: "find my account", Role: RoleUser},
+				{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "lookup", Arguments: `{"email":"jane@example.com"}`}}},
+				{Message: `{"phone":"+14155552671"}`, Role: RoleTool, ToolCallID: "call_1", Name: "lookup"},
+			},
+		}
+		r, report := s.ScrubRequest(req)
+		assert.Equal(t, `{"email":"jane@example.com"}`, req.Inputs[1].ToolCalls[0].Arguments)
+		assert.Equal(t, `{"email":"[REDACTED:email]"}`, r.Inputs[1].ToolCalls[0].Arguments)
+		assert.Equal(t, `{"phone":"[REDACTED:phone]"}`, r.Inputs[2].Message)
+
+		res := &ConversationResponse{Outputs: []ConversationResult{{
+			ToolCalls: []ToolCall{{ID: "call_2", Name: "notify", Arguments: `{"to":"john@example.com"}`}},
+		}}}
+		require.NoError(t, s.ScrubResponse(context.Background(), res, report, nil))
+		assert.Equal(t, `{"to":"[REDACTED:email]"}`, res.Outputs[0].ToolCalls[0].Arguments)
+		assert.Equal(t, &ScrubReport{
+			Inputs:  map[string]int{ScrubRuleEmail: 1, ScrubRulePhone: 1},
+			Outputs: map[string]int{ScrubRuleEmail: 1},
+		}, res.Scrubbed)
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		s := &Scrubber{}
+		require.Error(t, s.InitScrubber(ScrubMetadata{ScrubPII: true, ScrubPIIRules: "ssn"}))
+		require.Error(t, s.InitScrubber(ScrubMetadata{ScrubCustomRules: "EMP-[0-9]+"}))
+		require.Error(t, s.InitScrubber(ScrubMetadata{ScrubCustomRules: `{"id": "("}`}))
+		require.Error(t, s.InitScrubber(ScrubMetadata{ScrubCustomRules: `{"email": "@"}`}))
+	})
+}
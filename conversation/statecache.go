@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package conversation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+const cacheKeyPrefix = "conversation-cache||"
+
+// CacheConversation is implemented by conversation components whose prompt cache can be kept in a state store.
+// This is a hook for applications that use the components as a library: the Dapr runtime doesn't set a state store, so components configured through a component definition cache responses in memory.
+type CacheConversation interface {
+	// SetCacheStore sets the state store, which must be initialized, used to cache the responses of the model.
+	// Responses are cached only if the component is configured with a cacheTTL.
+	SetCacheStore(store state.Store)
+	// CacheStats returns the number of hits and misses of the cache backed by the state store.
+	CacheStats() CacheStats
+}
+
+// CacheStats contains the counters of a prompt cache.
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// Cache keeps the responses of the model in a state store, so they are shared by all replicas and survive restarts.
+// Components embed Cache to implement CacheConversation; until a state store is set, responses are cached in memory.
+type Cache struct {
+	store  atomic.Pointer[state.Store]
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// SetCacheStore sets the state store used to cache responses.
+func (c *Cache) SetCacheStore(store state.Store) {
+	c.store.Store(&store)
+}
+
+// CacheStats returns the number of hits and misses of the cache backed by the state store.
+func (c *Cache) CacheStats() CacheStats {
+	return CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// InitCache returns the model wrapped in a prompt cache with the given TTL.
+// Responses are cached in the state store once it's set, and in memory until then.
+// If ttl is empty, the model is returned as-is.
+func (c *Cache) InitCache(ctx context.Context, ttl string, modelName string, model llms.Model, logger logger.Logger) (llms.Model, error) {
+	if ttl == "" {
+		return model, nil
+	}
+
+	memoryModel, err := CacheModel(ctx, ttl, model)
+	if err != nil {
+		return model, err
+	}
+
+	// CacheModel has already validated the TTL
+	d, _ := time.ParseDuration(ttl)
+
+	return &stateCacheModel{
+		cache:       c,
+		model:       model,
+		memoryModel: memoryModel,
+		modelName:   modelName,
+		ttl:         d,
+		logger:      logger,
+	}, nil
+}
+
+// stateCacheModel is a langchain model that caches responses in the state store of a Cache.
+type stateCacheModel struct {
+	cache       *Cache
+	model       llms.Model
+	memoryModel llms.Model
+	modelName   string
+	ttl         time.Duration
+	logger      logger.Logger
+}
+
+var _ llms.Model = (*stateCacheModel)(nil)
+
+func (m *stateCacheModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	store := m.cache.store.Load()
+	if store == nil {
+		return m.memoryModel.GenerateContent(ctx, messages, options...)
+	}
+
+	key, err := m.key(messages, options)
+	if err != nil {
+		m.logger.Warnf("Failed to compute the key of the prompt cache: %v", err)
+		return m.model.GenerateContent(ctx, messages, options...)
+	}
+
+	resp := m.get(ctx, *store, key)
+	if resp != nil {
+		m.cache.hits.Add(1)
+		return resp, nil
+	}
+	m.cache.misses.Add(1)
+
+	resp, err = m.model.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.set(ctx, *store, key, resp)
+	return resp, nil
+}
+
+func (m *stateCacheModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// key returns the cache key of a request, hashed from the model, the options that affect the response and the messages.
+func (m *stateCacheModel) key(messages []llms.MessageContent, options []llms.CallOption) (string, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	model := m.modelName
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	b, err := json.Marshal(struct {
+		Model       string                `json:"model"`
+		Temperature float64               `json:"temperature"`
+		MaxTokens   int                   `json:"maxTokens,omitempty"`
+		Tools       []llms.Tool           `json:"tools,omitempty"`
+		Messages    []llms.MessageContent `json:"messages"`
+	}{
+		Model:       model,
+		Temperature: opts.Temperature,
+		MaxTokens:   opts.MaxTokens,
+		Tools:       opts.Tools,
+		Messages:    messages,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.Sum256(b)
+	return cacheKeyPrefix + hex.EncodeToString(h[:]), nil
+}
+
+// get returns the cached response, or nil if there's none.
+// Errors are logged and treated as misses, so the model is still invoked if the state store is unavailable.
+func (m *stateCacheModel) get(ctx context.Context, store state.Store, key string) *llms.ContentResponse {
+	res, err := store.Get(ctx, &state.GetRequest{
+		Key: key,
+	})
+	if err != nil {
+		m.logger.Warnf("Failed to get response from the prompt cache: %v", err)
+		return nil
+	}
+	if res == nil || len(res.Data) == 0 {
+		return nil
+	}
+
+	var resp llms.ContentResponse
+	err = json.Unmarshal(res.Data, &resp)
+	if err != nil {
+		m.logger.Warnf("Failed to parse response from the prompt cache: %v", err)
+		return nil
+	}
+	if len(resp.Choices) == 0 {
+		return nil
+	}
+	return &resp
+}
+
+func (m *stateCacheModel) set(ctx context.Context, store state.Store, key string, resp *llms.ContentResponse) {
+	if len(resp.Choices) == 0 {
+		return
+	}
+
+	err := store.Set(ctx, &state.SetRequest{
+		Key:   key,
+		Value: resp,
+		Metadata: map[string]string{
+			metadata.TTLInSecondsMetadataKey: strconv.FormatInt(int64(math.Ceil(m.ttl.Seconds())), 10),
+		},
+	})
+	if err != nil {
+		m.logger.Warnf("Failed to save response in the prompt cache: %v", err)
+	}
+}
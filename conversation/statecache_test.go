@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+// countingModel is a fakeModel that counts how many times it's invoked.
+type countingModel struct {
+	fakeModel
+	calls int
+}
+
+func (m *countingModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.calls++
+	return m.fakeModel.GenerateContent(ctx, messages, options...)
+}
+
+// recordingStore is a state store that records the last set request.
+type recordingStore struct {
+	state.Store
+	lastSet *state.SetRequest
+}
+
+func (s *recordingStore) Set(ctx context.Context, req *state.SetRequest) error {
+	s.lastSet = req
+	return s.Store.Set(ctx, req)
+}
+
+func TestStateCache(t *testing.T) {
+	log := logger.NewLogger("test")
+
+	store := inmemory.NewInMemoryStateStore(log)
+	require.NoError(t, store.Init(context.Background(), state.Metadata{}))
+	defer store.(interface{ Close() error }).Close()
+	recorder := &recordingStore{Store: store}
+
+	model := &countingModel{
+		fakeModel: fakeModel{
+			chunks: []string{"hello ", "world"},
+			resp: &llms.ContentResponse{
+				Choices: []*llms.ContentChoice{
+					{Content: "hello world", StopReason: "stop", GenerationInfo: map[string]any{"TotalTokens": 5}},
+				},
+			},
+		},
+	}
+
+	// Two replicas sharing the same state store
+	newReplica := func(modelName string) (*Cache, llms.Model) {
+		c := &Cache{}
+		c.SetCacheStore(recorder)
+		cached, err := c.InitCache(context.Background(), "90s", modelName, model, log)
+		require.NoError(t, err)
+		return c, cached
+	}
+	cache1, model1 := newReplica("gpt-4o")
+	cache2, model2 := newReplica("gpt-4o")
+
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")}
+
+	t.Run("miss", func(t *testing.T) {
+		resp, err := model1.GenerateContent(context.Background(), messages, llms.WithTemperature(0.5))
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", resp.Choices[0].Content)
+		assert.Equal(t, 1, model.calls)
+		assert.Equal(t, CacheStats{Hits: 0, Misses: 1}, cache1.CacheStats())
+
+		require.NotNil(t, recorder.lastSet)
+		assert.Equal(t, "90", recorder.lastSet.Metadata[metadata.TTLInSecondsMetadataKey])
+	})
+
+	t.Run("hit from another replica", func(t *testing.T) {
+		resp, err := model2.GenerateContent(context.Background(), messages, llms.WithTemperature(0.5))
+		require.NoError(t, err)
+		assert.Equal(t, 1, model.calls)
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 0}, cache2.CacheStats())
+
+		require.Len(t, resp.Choices, 1)
+		assert.Equal(t, "hello world", resp.Choices[0].Content)
+		assert.Equal(t, FinishReasonStop, LangchainFinishReason(resp.Choices[0]))
+		assert.Equal(t, int64(5), LangchainUsage(resp).TotalTokens)
+	})
+
+	t.Run("streamed hit is sent in a single chunk", func(t *testing.T) {
+		var chunks []string
+		_, err := LangchainGenerateContent(context.Background(), model2, messages, func(ctx context.Context, chunk *ConversationStreamChunk) error {
+			chunks = append(chunks, chunk.Content)
+			return nil
+		}, llms.WithTemperature(0.5))
+		require.NoError(t, err)
+		assert.Equal(t, 1, model.calls)
+		assert.Equal(t, []string{"hello world"}, chunks)
+	})
+
+	t.Run("key depends on temperature, model and messages", func(t *testing.T) {
+		_, err := model1.GenerateContent(context.Background(), messages, llms.WithTemperature(0.7))
+		require.NoError(t, err)
+		assert.Equal(t, 2, model.calls)
+
+		_, other := newReplica("gpt-4o-mini")
+		_, err = other.GenerateContent(context.Background(), messages, llms.WithTemperature(0.5))
+		require.NoError(t, err)
+		assert.Equal(t, 3, model.calls)
+
+		_, err = model1.GenerateContent(context.Background(), []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hello")}, llms.WithTemperature(0.5))
+		require.NoError(t, err)
+		assert.Equal(t, 4, model.calls)
+		assert.Equal(t, CacheStats{Hits: 0, Misses: 3}, cache1.CacheStats())
+	})
+
+	t.Run("no TTL", func(t *testing.T) {
+		c := &Cache{}
+		cached, err := c.InitCache(context.Background(), "", "gpt-4o", model, log)
+		require.NoError(t, err)
+		assert.Same(t, model, cached)
+	})
+}
@@ -19,3 +19,8 @@ import (
 
 // Feature names a feature that can be implemented by the crypto provider components.
 type Feature = features.Feature[SubtleCrypto]
+
+const (
+	// FeatureKeyManagement is the feature for crypto providers that implement KeyManager, and can generate and rotate keys.
+	FeatureKeyManagement Feature = "KEY_MANAGEMENT"
+)
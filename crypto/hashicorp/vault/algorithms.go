@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	internals "github.com/dapr/kit/crypto"
+)
+
+// Types of keys in the Transit secrets engine.
+const (
+	keyTypeAES128GCM96      = "aes128-gcm96"
+	keyTypeAES256GCM96      = "aes256-gcm96"
+	keyTypeChaCha20Poly1305 = "chacha20-poly1305"
+	keyTypeEd25519          = "ed25519"
+	keyTypeECDSAP256        = "ecdsa-p256"
+	keyTypeECDSAP384        = "ecdsa-p384"
+	keyTypeECDSAP521        = "ecdsa-p521"
+	keyTypeRSAPrefix        = "rsa-"
+)
+
+// Transit determines the encryption algorithm from the type of the key, so each algorithm is mapped to the types of key that implement it.
+// Note that Transit uses its own ciphertext format, which includes the nonce, the authentication tag and the version of the key.
+var encryptionAlgorithms = map[string]func(keyType string) bool{
+	internals.Algorithm_A128GCM:      isKeyType(keyTypeAES128GCM96),
+	internals.Algorithm_A256GCM:      isKeyType(keyTypeAES256GCM96),
+	internals.Algorithm_C20P:         isKeyType(keyTypeChaCha20Poly1305),
+	internals.Algorithm_RSA_OAEP_256: isRSAKey,
+}
+
+// signatureAlgorithm contains the parameters of a signature algorithm for the Transit sign and verify endpoints.
+type signatureAlgorithm struct {
+	keyType            func(keyType string) bool
+	hashAlgorithm      string
+	signatureAlgorithm string
+}
+
+var signatureAlgorithms = map[string]signatureAlgorithm{
+	internals.Algorithm_RS256: {keyType: isRSAKey, hashAlgorithm: "sha2-256", signatureAlgorithm: "pkcs1v15"},
+	internals.Algorithm_RS384: {keyType: isRSAKey, hashAlgorithm: "sha2-384", signatureAlgorithm: "pkcs1v15"},
+	internals.Algorithm_RS512: {keyType: isRSAKey, hashAlgorithm: "sha2-512", signatureAlgorithm: "pkcs1v15"},
+	internals.Algorithm_PS256: {keyType: isRSAKey, hashAlgorithm: "sha2-256", signatureAlgorithm: "pss"},
+	internals.Algorithm_PS384: {keyType: isRSAKey, hashAlgorithm: "sha2-384", signatureAlgorithm: "pss"},
+	internals.Algorithm_PS512: {keyType: isRSAKey, hashAlgorithm: "sha2-512", signatureAlgorithm: "pss"},
+	internals.Algorithm_ES256: {keyType: isKeyType(keyTypeECDSAP256), hashAlgorithm: "sha2-256"},
+	internals.Algorithm_ES384: {keyType: isKeyType(keyTypeECDSAP384), hashAlgorithm: "sha2-384"},
+	internals.Algorithm_ES512: {keyType: isKeyType(keyTypeECDSAP521), hashAlgorithm: "sha2-512"},
+	// EdDSA signs the full message, so there's no hash algorithm
+	internals.Algorithm_EdDSA: {keyType: isKeyType(keyTypeEd25519)},
+}
+
+var (
+	encryptionAlgsList = slices.Sorted(maps.Keys(encryptionAlgorithms))
+	signatureAlgsList  = slices.Sorted(maps.Keys(signatureAlgorithms))
+)
+
+func isKeyType(expect string) func(keyType string) bool {
+	return func(keyType string) bool {
+		return keyType == expect
+	}
+}
+
+func isRSAKey(keyType string) bool {
+	return strings.HasPrefix(keyType, keyTypeRSAPrefix)
+}
@@ -0,0 +1,597 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	vaultauth "github.com/dapr/components-contrib/common/authentication/hashicorp/vault"
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+// vaultCrypto is a crypto provider that performs all operations with keys stored in the Transit secrets engine of HashiCorp Vault.
+type vaultCrypto struct {
+	keyCache *contribCrypto.PubKeyCache
+	md       vaultMetadata
+	client   *http.Client
+	logger   logger.Logger
+
+	// Types of the keys, which are cached as they can't change
+	keyTypes sync.Map
+}
+
+// NewHashiCorpVaultCrypto returns a new crypto provider backed by the Transit secrets engine of HashiCorp Vault.
+func NewHashiCorpVaultCrypto(logger logger.Logger) contribCrypto.SubtleCrypto {
+	return &vaultCrypto{
+		logger: logger,
+	}
+}
+
+// Init creates the HTTP client for Vault.
+func (v *vaultCrypto) Init(_ context.Context, metadata contribCrypto.Metadata) error {
+	// Init the metadata
+	err := v.md.InitWithMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	// Create a cache for keys
+	v.keyCache = contribCrypto.NewPubKeyCache(v.getKeyCacheFn)
+
+	v.client, err = v.md.HTTPClient(v.logger)
+	if err != nil {
+		return fmt.Errorf("couldn't create client using config: %w", err)
+	}
+
+	return nil
+}
+
+// Features returns the features available in this crypto provider.
+func (v *vaultCrypto) Features() []contribCrypto.Feature {
+	return []contribCrypto.Feature{} // No Feature supported.
+}
+
+// GetKey returns the public part of a key stored in Vault.
+// This method returns an error if the key is symmetric.
+// The key argument can be in the format "name" or "name/version".
+func (v *vaultCrypto) GetKey(parentCtx context.Context, key string) (pubKey jwk.Key, err error) {
+	kid, err := newKeyID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the key is cacheable, get it from the cache
+	if kid.Cacheable() {
+		return v.keyCache.GetKey(parentCtx, key)
+	}
+
+	return v.getKeyFromVault(parentCtx, kid)
+}
+
+func (v *vaultCrypto) getKeyFromVault(parentCtx context.Context, kid keyID) (pubKey jwk.Key, err error) {
+	info, err := v.getKeyInfo(parentCtx, kid.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	version := kid.Version
+	if version == 0 {
+		version = info.LatestVersion
+	}
+	var versionInfo struct {
+		PublicKey string `json:"public_key"`
+	}
+	// For symmetric keys, the value is the creation time of the version, so the public key is empty
+	_ = json.Unmarshal(info.Keys[strconv.Itoa(version)], &versionInfo)
+	if versionInfo.PublicKey == "" {
+		return nil, fmt.Errorf("key '%s' version %d: %w", kid.Name, version, contribCrypto.ErrKeyNotFound)
+	}
+
+	pk, err := parsePublicKey(info.Type, versionInfo.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	pubKey, err = jwk.FromRaw(pk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from public key: %w", err)
+	}
+	err = pubKey.Set(jwk.KeyIDKey, kid.Name+"/"+strconv.Itoa(version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set key ID: %w", err)
+	}
+	return pubKey, nil
+}
+
+// Handler for the getKeyCacheFn method
+func (v *vaultCrypto) getKeyCacheFn(ctx context.Context, key string) func(resolve func(jwk.Key), reject func(error)) {
+	return func(resolve func(jwk.Key), reject func(error)) {
+		kid, err := newKeyID(key)
+		if err != nil {
+			reject(err)
+			return
+		}
+		pk, err := v.getKeyFromVault(ctx, kid)
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(pk)
+	}
+}
+
+// Encrypt a small message and returns the ciphertext.
+// The nonce is generated by Vault and included in the ciphertext, together with the authentication tag and the version of the key.
+// The key argument can be in the format "name" or "name/version".
+func (v *vaultCrypto) Encrypt(parentCtx context.Context, plaintext []byte, algorithm string, key string, _ []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	kid, err := v.checkEncryptionKey(parentCtx, key, algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ciphertext, err = v.encrypt(parentCtx, kid, plaintext, associatedData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	return ciphertext, nil, nil
+}
+
+func (v *vaultCrypto) encrypt(parentCtx context.Context, kid keyID, plaintext []byte, associatedData []byte) ([]byte, error) {
+	var res struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	err := v.doRequest(parentCtx, "encrypt/"+url.PathEscape(kid.Name), encryptRequest{
+		Plaintext:      base64.StdEncoding.EncodeToString(plaintext),
+		AssociatedData: encodeOptional(associatedData),
+		KeyVersion:     kid.Version,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Data.Ciphertext == "" {
+		return nil, errors.New("response from Vault does not contain a valid ciphertext")
+	}
+	return []byte(res.Data.Ciphertext), nil
+}
+
+// Decrypt a small message and returns the plaintext.
+// The version of the key is included in the ciphertext, so the version in the key argument, if any, is ignored.
+func (v *vaultCrypto) Decrypt(parentCtx context.Context, ciphertext []byte, algorithm string, key string, _ []byte, _ []byte, associatedData []byte) (plaintext []byte, err error) {
+	kid, err := v.checkEncryptionKey(parentCtx, key, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err = v.decrypt(parentCtx, kid, ciphertext, associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+func (v *vaultCrypto) decrypt(parentCtx context.Context, kid keyID, ciphertext []byte, associatedData []byte) ([]byte, error) {
+	var res struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	err := v.doRequest(parentCtx, "decrypt/"+url.PathEscape(kid.Name), decryptRequest{
+		Ciphertext:     string(ciphertext),
+		AssociatedData: encodeOptional(associatedData),
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(res.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("response from Vault does not contain a valid plaintext: %w", err)
+	}
+	return plaintext, nil
+}
+
+// WrapKey wraps a symmetric key, encrypting it with Vault.
+// The key argument can be in the format "name" or "name/version".
+func (v *vaultCrypto) WrapKey(parentCtx context.Context, plaintextKey jwk.Key, algorithm string, key string, _ []byte, associatedData []byte) (wrappedKey []byte, tag []byte, err error) {
+	// Only symmetric keys can be wrapped, like the keys returned by the Transit datakey endpoint
+	if plaintextKey.KeyType() != jwa.OctetSeq {
+		return nil, nil, errors.New("cannot wrap asymmetric keys")
+	}
+	plaintext, err := internals.SerializeKey(plaintextKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot serialize key: %w", err)
+	}
+
+	kid, err := v.checkEncryptionKey(parentCtx, key, algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrappedKey, err = v.encrypt(parentCtx, kid, plaintext, associatedData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap key: %w", err)
+	}
+	return wrappedKey, nil, nil
+}
+
+// UnwrapKey unwraps a key.
+// This can also unwrap the keys returned by the Transit datakey endpoint and by GenerateDataKey.
+func (v *vaultCrypto) UnwrapKey(parentCtx context.Context, wrappedKey []byte, algorithm string, key string, _ []byte, _ []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
+	kid, err := v.checkEncryptionKey(parentCtx, key, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := v.decrypt(parentCtx, kid, wrappedKey, associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key: %w", err)
+	}
+
+	// We allow wrapping/unwrapping only symmetric keys, so no need to try and decode an ASN.1 DER-encoded sequence
+	plaintextKey, err = jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+	return plaintextKey, nil
+}
+
+// GenerateDataKey uses the Transit datakey endpoint to generate a new symmetric key of the given size in bits (128, 256 or 512).
+// It returns the key and the key wrapped with the named key, which can be unwrapped with UnwrapKey.
+func (v *vaultCrypto) GenerateDataKey(parentCtx context.Context, key string, bits int) (plaintextKey jwk.Key, wrappedKey []byte, err error) {
+	kid, err := newKeyID(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res struct {
+		Data struct {
+			Plaintext  string `json:"plaintext"`
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	err = v.doRequest(parentCtx, "datakey/plaintext/"+url.PathEscape(kid.Name), dataKeyRequest{
+		Bits:       bits,
+		KeyVersion: kid.Version,
+	}, &res)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(res.Data.Plaintext)
+	if err != nil || res.Data.Ciphertext == "" {
+		return nil, nil, errors.New("response from Vault does not contain a valid data key")
+	}
+	plaintextKey, err = jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+	return plaintextKey, []byte(res.Data.Ciphertext), nil
+}
+
+// Sign a digest.
+// When using EdDSA, the full message must be passed as digest.
+// The key argument can be in the format "name" or "name/version".
+func (v *vaultCrypto) Sign(parentCtx context.Context, digest []byte, algorithmStr string, key string) (signature []byte, err error) {
+	algorithm, ok := signatureAlgorithms[algorithmStr]
+	if !ok {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+	kid, err := v.checkKey(parentCtx, key, algorithm.keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	err = v.doRequest(parentCtx, "sign/"+url.PathEscape(kid.Name), algorithm.request(digest, kid.Version), &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+
+	// Signatures are in the format "vault:v1:<base64>"; the raw signature is returned so it can be verified with the public key
+	idx := strings.LastIndexByte(res.Data.Signature, ':')
+	signature, err = base64.StdEncoding.DecodeString(res.Data.Signature[idx+1:])
+	if err != nil || len(signature) == 0 {
+		return nil, errors.New("response from Vault does not contain a valid signature")
+	}
+	return signature, nil
+}
+
+// Verify a signature.
+// When using EdDSA, the full message must be passed as digest.
+// The key argument can be in the format "name" or "name/version"; if the version is omitted, the latest version of the key is used.
+func (v *vaultCrypto) Verify(parentCtx context.Context, digest []byte, signature []byte, algorithmStr string, key string) (valid bool, err error) {
+	algorithm, ok := signatureAlgorithms[algorithmStr]
+	if !ok {
+		return false, fmt.Errorf("invalid algorithm: %s", algorithmStr)
+	}
+	kid, err := newKeyID(key)
+	if err != nil {
+		return false, err
+	}
+
+	// Vault needs to know the version of the key used to create the signature
+	info, err := v.getKeyInfo(parentCtx, kid.Name)
+	if err != nil {
+		return false, err
+	}
+	if !algorithm.keyType(info.Type) {
+		return false, fmt.Errorf("key cannot be used with algorithm '%s'", algorithmStr)
+	}
+	version := kid.Version
+	if version == 0 {
+		version = info.LatestVersion
+	}
+
+	req := algorithm.request(digest, 0)
+	req.Signature = "vault:v" + strconv.Itoa(version) + ":" + base64.StdEncoding.EncodeToString(signature)
+
+	var res struct {
+		Data struct {
+			Valid bool `json:"valid"`
+		} `json:"data"`
+	}
+	err = v.doRequest(parentCtx, "verify/"+url.PathEscape(kid.Name), req, &res)
+	if err != nil {
+		return false, fmt.Errorf("failed to validate the signature: %w", err)
+	}
+	return res.Data.Valid, nil
+}
+
+// checkEncryptionKey returns the ID of the key after checking that it can be used with the encryption algorithm.
+func (v *vaultCrypto) checkEncryptionKey(parentCtx context.Context, key string, algorithm string) (keyID, error) {
+	keyTypeFn, ok := encryptionAlgorithms[algorithm]
+	if !ok {
+		return keyID{}, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+	return v.checkKey(parentCtx, key, keyTypeFn)
+}
+
+// checkKey returns the ID of the key after checking that its type is accepted by keyTypeFn.
+func (v *vaultCrypto) checkKey(parentCtx context.Context, key string, keyTypeFn func(keyType string) bool) (keyID, error) {
+	kid, err := newKeyID(key)
+	if err != nil {
+		return keyID{}, err
+	}
+
+	keyType, ok := v.keyTypes.Load(kid.Name)
+	if !ok {
+		info, err := v.getKeyInfo(parentCtx, kid.Name)
+		if err != nil {
+			return keyID{}, err
+		}
+		keyType = info.Type
+	}
+	if !keyTypeFn(keyType.(string)) {
+		return keyID{}, errors.New("key cannot be used with this algorithm")
+	}
+	return kid, nil
+}
+
+// keyInfo is the information on a key returned by Vault.
+type keyInfo struct {
+	Type          string                     `json:"type"`
+	LatestVersion int                        `json:"latest_version"`
+	Keys          map[string]json.RawMessage `json:"keys"`
+}
+
+func (v *vaultCrypto) getKeyInfo(parentCtx context.Context, name string) (*keyInfo, error) {
+	var res struct {
+		Data keyInfo `json:"data"`
+	}
+	err := v.doRequestWithMethod(parentCtx, http.MethodGet, "keys/"+url.PathEscape(name), nil, &res)
+	if err != nil {
+		v.keyTypes.Delete(name)
+		return nil, fmt.Errorf("failed to get key '%s': %w", name, err)
+	}
+
+	v.keyTypes.Store(name, res.Data.Type)
+	return &res.Data, nil
+}
+
+func (v *vaultCrypto) doRequest(parentCtx context.Context, path string, body any, out any) error {
+	return v.doRequestWithMethod(parentCtx, http.MethodPost, path, body, out)
+}
+
+// doRequestWithMethod invokes an endpoint of the Transit secrets engine.
+// A 404 response is returned as ErrKeyNotFound.
+func (v *vaultCrypto) doRequestWithMethod(parentCtx context.Context, method string, path string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to serialize request: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, v.md.RequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, v.md.Address()+"/v1/"+v.md.EnginePath+"/"+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("couldn't generate request: %w", err)
+	}
+	req.Header.Set(vaultauth.TokenHeader, v.md.token)
+	req.Header.Set(vaultauth.RequestHeader, "true")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error from Vault: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var errRes struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&errRes)
+		if res.StatusCode == http.StatusNotFound {
+			return contribCrypto.ErrKeyNotFound
+		}
+		return fmt.Errorf("error from Vault, status code %d: %s", res.StatusCode, strings.Join(errRes.Errors, "; "))
+	}
+
+	if out != nil {
+		err = json.NewDecoder(res.Body).Decode(out)
+		if err != nil {
+			return fmt.Errorf("couldn't decode response body: %w", err)
+		}
+	}
+	return nil
+}
+
+func (v *vaultCrypto) Close() error {
+	return nil
+}
+
+func (v *vaultCrypto) SupportedEncryptionAlgorithms() []string {
+	return encryptionAlgsList
+}
+
+func (v *vaultCrypto) SupportedSignatureAlgorithms() []string {
+	return signatureAlgsList
+}
+
+func (v *vaultCrypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := vaultMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
+	return
+}
+
+type encryptRequest struct {
+	Plaintext      string `json:"plaintext"`
+	AssociatedData string `json:"associated_data,omitempty"`
+	KeyVersion     int    `json:"key_version,omitempty"`
+}
+
+type decryptRequest struct {
+	Ciphertext     string `json:"ciphertext"`
+	AssociatedData string `json:"associated_data,omitempty"`
+}
+
+type dataKeyRequest struct {
+	Bits       int `json:"bits,omitempty"`
+	KeyVersion int `json:"key_version,omitempty"`
+}
+
+type signRequest struct {
+	Input               string `json:"input"`
+	Signature           string `json:"signature,omitempty"`
+	KeyVersion          int    `json:"key_version,omitempty"`
+	HashAlgorithm       string `json:"hash_algorithm,omitempty"`
+	Prehashed           bool   `json:"prehashed,omitempty"`
+	SignatureAlgorithm  string `json:"signature_algorithm,omitempty"`
+	MarshalingAlgorithm string `json:"marshaling_algorithm,omitempty"`
+}
+
+// request returns the body of a request to the sign or verify endpoints.
+func (a signatureAlgorithm) request(digest []byte, version int) signRequest {
+	req := signRequest{
+		Input:              base64.StdEncoding.EncodeToString(digest),
+		KeyVersion:         version,
+		HashAlgorithm:      a.hashAlgorithm,
+		SignatureAlgorithm: a.signatureAlgorithm,
+		// ECDSA signatures are ASN.1-encoded, like Dapr's
+		MarshalingAlgorithm: "asn1",
+	}
+	// Except with EdDSA, the input is the digest of the message
+	req.Prehashed = a.hashAlgorithm != ""
+	return req
+}
+
+// parsePublicKey parses a public key returned by Vault: ed25519 keys are base64-encoded, while others are PEM-encoded.
+func parsePublicKey(keyType string, publicKey string) (any, error) {
+	if keyType == keyTypeEd25519 {
+		b, err := base64.StdEncoding.DecodeString(publicKey)
+		if err != nil || len(b) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key")
+		}
+		return ed25519.PublicKey(b), nil
+	}
+
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, errors.New("invalid PEM-encoded public key")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func encodeOptional(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+type keyID struct {
+	Name string
+	// Version of the key, or 0 for the latest
+	Version int
+}
+
+func newKeyID(val string) (keyID, error) {
+	obj := keyID{}
+	idx := strings.IndexRune(val, '/')
+	// Can't be on position 0, because the key name must be at least 1 character
+	if idx <= 0 {
+		obj.Name = val
+		return obj, nil
+	}
+
+	obj.Name = val[:idx]
+	version := val[idx+1:]
+	if strings.ToLower(version) == "latest" {
+		return obj, nil
+	}
+	var err error
+	obj.Version, err = strconv.Atoi(version)
+	if err != nil || obj.Version < 1 {
+		return keyID{}, fmt.Errorf("invalid version of key '%s': %s", obj.Name, version)
+	}
+	return obj, nil
+}
+
+// Cacheable returns true if the key can be cached locally.
+func (id keyID) Cacheable() bool {
+	return id.Version > 0
+}
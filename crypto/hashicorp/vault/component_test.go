@@ -0,0 +1,319 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/components-contrib/metadata"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+const testToken = "testtoken"
+
+// fakeTransit is a minimal implementation of the Transit secrets engine, with an AES-256-GCM key named "aes" and an ECDSA P-256 key named "ec".
+type fakeTransit struct {
+	aead  cipher.AEAD
+	ecKey *ecdsa.PrivateKey
+}
+
+func newFakeTransit(t *testing.T) *httptest.Server {
+	aesKey := make([]byte, 32)
+	_, err := rand.Read(aesKey)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(aesKey)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	f := &fakeTransit{aead: aead, ecKey: ecKey}
+	srv := httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (f *fakeTransit) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != testToken || r.Header.Get("X-Vault-Request") != "true" {
+		writeResponse(w, http.StatusForbidden, map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+
+	op, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/transit/"), "/")
+	if op == "datakey" {
+		name = strings.TrimPrefix(name, "plaintext/")
+	}
+	if name != "aes" && name != "ec" {
+		writeResponse(w, http.StatusNotFound, map[string]any{"errors": []string{}})
+		return
+	}
+
+	var req map[string]any
+	if r.Method == http.MethodPost {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+	}
+	str := func(key string) []byte {
+		s, _ := req[key].(string)
+		if idx := strings.LastIndexByte(s, ':'); idx >= 0 {
+			s = s[idx+1:]
+		}
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return b
+	}
+	encrypt := func(plaintext []byte) string {
+		nonce := make([]byte, f.aead.NonceSize())
+		_, _ = rand.Read(nonce)
+		return "vault:v1:" + base64.StdEncoding.EncodeToString(f.aead.Seal(nonce, nonce, plaintext, str("associated_data")))
+	}
+
+	switch {
+	case op == "keys" && name == "aes":
+		writeResponse(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"type":           keyTypeAES256GCM96,
+			"latest_version": 1,
+			"keys":           map[string]any{"1": 1700000000},
+		}})
+	case op == "keys" && name == "ec":
+		der, _ := x509.MarshalPKIXPublicKey(&f.ecKey.PublicKey)
+		writeResponse(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"type":           keyTypeECDSAP256,
+			"latest_version": 1,
+			"keys": map[string]any{"1": map[string]any{
+				"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+			}},
+		}})
+	case op == "encrypt" && name == "aes":
+		writeResponse(w, http.StatusOK, map[string]any{"data": map[string]any{"ciphertext": encrypt(str("plaintext"))}})
+	case op == "decrypt" && name == "aes":
+		ct := str("ciphertext")
+		if len(ct) < f.aead.NonceSize() {
+			writeResponse(w, http.StatusBadRequest, map[string]any{"errors": []string{"invalid ciphertext"}})
+			return
+		}
+		plaintext, err := f.aead.Open(nil, ct[:f.aead.NonceSize()], ct[f.aead.NonceSize():], str("associated_data"))
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, map[string]any{"errors": []string{"cipher: message authentication failed"}})
+			return
+		}
+		writeResponse(w, http.StatusOK, map[string]any{"data": map[string]any{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}})
+	case op == "datakey" && name == "aes":
+		bits, _ := req["bits"].(float64)
+		key := make([]byte, int(bits)/8)
+		_, _ = rand.Read(key)
+		writeResponse(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"plaintext":  base64.StdEncoding.EncodeToString(key),
+			"ciphertext": encrypt(key),
+		}})
+	case op == "sign" && name == "ec" && req["prehashed"] == true && req["marshaling_algorithm"] == "asn1":
+		sig, _ := ecdsa.SignASN1(rand.Reader, f.ecKey, str("input"))
+		writeResponse(w, http.StatusOK, map[string]any{"data": map[string]any{"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(sig)}})
+	case op == "verify" && name == "ec" && strings.HasPrefix(req["signature"].(string), "vault:v1:"):
+		valid := ecdsa.VerifyASN1(&f.ecKey.PublicKey, str("input"), str("signature"))
+		writeResponse(w, http.StatusOK, map[string]any{"data": map[string]any{"valid": valid}})
+	default:
+		writeResponse(w, http.StatusBadRequest, map[string]any{"errors": []string{"unsupported operation"}})
+	}
+}
+
+func writeResponse(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestVaultCrypto(t *testing.T) {
+	srv := newFakeTransit(t)
+
+	v := NewHashiCorpVaultCrypto(logger.NewLogger("test")).(*vaultCrypto)
+	err := v.Init(context.Background(), contribCrypto.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"vaultAddr":  srv.URL,
+		"vaultToken": testToken,
+	}}})
+	require.NoError(t, err)
+	defer v.Close()
+
+	t.Run("encrypt and decrypt", func(t *testing.T) {
+		message := []byte("hello world")
+		aad := []byte("aad")
+		ciphertext, tag, err := v.Encrypt(context.Background(), message, internals.Algorithm_A256GCM, "aes", nil, aad)
+		require.NoError(t, err)
+		assert.Nil(t, tag)
+		assert.True(t, strings.HasPrefix(string(ciphertext), "vault:v1:"))
+
+		plaintext, err := v.Decrypt(context.Background(), ciphertext, internals.Algorithm_A256GCM, "aes", nil, nil, aad)
+		require.NoError(t, err)
+		assert.Equal(t, message, plaintext)
+
+		_, err = v.Decrypt(context.Background(), ciphertext, internals.Algorithm_A256GCM, "aes", nil, nil, []byte("other"))
+		require.ErrorContains(t, err, "message authentication failed")
+	})
+
+	t.Run("wrong algorithm for key", func(t *testing.T) {
+		_, _, err := v.Encrypt(context.Background(), []byte("hello"), internals.Algorithm_A128GCM, "aes", nil, nil)
+		require.ErrorContains(t, err, "cannot be used with this algorithm")
+		_, _, err = v.Encrypt(context.Background(), []byte("hello"), internals.Algorithm_A256KW, "aes", nil, nil)
+		require.ErrorContains(t, err, "invalid algorithm")
+		_, err = v.Sign(context.Background(), make([]byte, 32), internals.Algorithm_ES256, "aes")
+		require.ErrorContains(t, err, "cannot be used with this algorithm")
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		_, _, err := v.Encrypt(context.Background(), []byte("hello"), internals.Algorithm_A256GCM, "missing", nil, nil)
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+		_, err = v.GetKey(context.Background(), "missing")
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+	})
+
+	t.Run("wrap and unwrap key", func(t *testing.T) {
+		rawKey := make([]byte, 16)
+		_, err := rand.Read(rawKey)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(rawKey)
+		require.NoError(t, err)
+
+		wrapped, _, err := v.WrapKey(context.Background(), key, internals.Algorithm_A256GCM, "aes", nil, nil)
+		require.NoError(t, err)
+		unwrapped, err := v.UnwrapKey(context.Background(), wrapped, internals.Algorithm_A256GCM, "aes", nil, nil, nil)
+		require.NoError(t, err)
+
+		var raw []byte
+		require.NoError(t, unwrapped.Raw(&raw))
+		assert.Equal(t, rawKey, raw)
+
+		ecKey, err := v.GetKey(context.Background(), "ec")
+		require.NoError(t, err)
+		_, _, err = v.WrapKey(context.Background(), ecKey, internals.Algorithm_A256GCM, "aes", nil, nil)
+		require.ErrorContains(t, err, "cannot wrap asymmetric keys")
+	})
+
+	t.Run("generate data key", func(t *testing.T) {
+		key, wrapped, err := v.GenerateDataKey(context.Background(), "aes", 256)
+		require.NoError(t, err)
+		var raw []byte
+		require.NoError(t, key.Raw(&raw))
+		assert.Len(t, raw, 32)
+
+		unwrapped, err := v.UnwrapKey(context.Background(), wrapped, internals.Algorithm_A256GCM, "aes", nil, nil, nil)
+		require.NoError(t, err)
+		var unwrappedRaw []byte
+		require.NoError(t, unwrapped.Raw(&unwrappedRaw))
+		assert.Equal(t, raw, unwrappedRaw)
+	})
+
+	t.Run("sign and verify", func(t *testing.T) {
+		digest := sha256.Sum256([]byte("hello world"))
+		signature, err := v.Sign(context.Background(), digest[:], internals.Algorithm_ES256, "ec")
+		require.NoError(t, err)
+
+		valid, err := v.Verify(context.Background(), digest[:], signature, internals.Algorithm_ES256, "ec")
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		otherDigest := sha256.Sum256([]byte("other message"))
+		valid, err = v.Verify(context.Background(), otherDigest[:], signature, internals.Algorithm_ES256, "ec/1")
+		require.NoError(t, err)
+		assert.False(t, valid)
+
+		// The signature can be verified with the public key too
+		pubKey, err := v.GetKey(context.Background(), "ec/1")
+		require.NoError(t, err)
+		assert.Equal(t, "ec/1", pubKey.KeyID())
+		valid, err = internals.VerifyPublicKey(digest[:], signature, internals.Algorithm_ES256, pubKey)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("get symmetric key", func(t *testing.T) {
+		_, err := v.GetKey(context.Background(), "aes")
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+	})
+}
+
+func TestInit(t *testing.T) {
+	srv := newFakeTransit(t)
+
+	t.Run("missing token", func(t *testing.T) {
+		v := NewHashiCorpVaultCrypto(logger.NewLogger("test"))
+		err := v.Init(context.Background(), contribCrypto.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"vaultAddr": srv.URL,
+		}}})
+		require.ErrorContains(t, err, "not set")
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		v := NewHashiCorpVaultCrypto(logger.NewLogger("test"))
+		err := v.Init(context.Background(), contribCrypto.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"vaultAddr":  srv.URL,
+			"vaultToken": "invalid",
+		}}})
+		require.NoError(t, err)
+		_, err = v.GetKey(context.Background(), "ec")
+		require.ErrorContains(t, err, "permission denied")
+	})
+
+	t.Run("engine path", func(t *testing.T) {
+		v := &vaultCrypto{}
+		err := v.md.InitWithMetadata(contribCrypto.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"vaultToken":     testToken,
+			"enginePath":     "/my-transit/",
+			"requestTimeout": "5s",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "my-transit", v.md.EnginePath)
+		assert.Equal(t, "https://127.0.0.1:8200", v.md.Address())
+		assert.Equal(t, testToken, v.md.token)
+	})
+}
+
+func TestKeyID(t *testing.T) {
+	kid, err := newKeyID("mykey")
+	require.NoError(t, err)
+	assert.Equal(t, keyID{Name: "mykey"}, kid)
+	assert.False(t, kid.Cacheable())
+
+	kid, err = newKeyID("mykey/latest")
+	require.NoError(t, err)
+	assert.Equal(t, keyID{Name: "mykey"}, kid)
+
+	kid, err = newKeyID("mykey/3")
+	require.NoError(t, err)
+	assert.Equal(t, keyID{Name: "mykey", Version: 3}, kid)
+	assert.True(t, kid.Cacheable())
+
+	_, err = newKeyID("mykey/v3")
+	require.Error(t, err)
+	_, err = newKeyID("mykey/0")
+	require.Error(t, err)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"strings"
+	"time"
+
+	vaultauth "github.com/dapr/components-contrib/common/authentication/hashicorp/vault"
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultEnginePath     = "transit"
+	defaultRequestTimeout = 30 * time.Second
+)
+
+type vaultMetadata struct {
+	// Address, authentication and TLS options, which are the same as the HashiCorp Vault secret store's.
+	vaultauth.ClientMetadata `mapstructure:",squash"`
+
+	// Path where the Transit secrets engine is mounted.
+	// Defaults to "transit".
+	EnginePath string `json:"enginePath" mapstructure:"enginePath"`
+
+	// Timeout for network requests, as a Go duration string (e.g. "30s")
+	// Defaults to "30s".
+	RequestTimeout time.Duration `json:"requestTimeout" mapstructure:"requestTimeout"`
+
+	// Internal properties
+	token string
+}
+
+func (m *vaultMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
+	m.reset()
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+
+	// Token
+	m.token, err = m.Token()
+	if err != nil {
+		return err
+	}
+
+	// Engine path
+	m.EnginePath = strings.Trim(m.EnginePath, "/")
+	if m.EnginePath == "" {
+		m.EnginePath = defaultEnginePath
+	}
+
+	// Set default requestTimeout if empty
+	if m.RequestTimeout < time.Second {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	return nil
+}
+
+// Reset the object
+func (m *vaultMetadata) reset() {
+	m.ClientMetadata = vaultauth.ClientMetadata{}
+	m.EnginePath = defaultEnginePath
+	m.RequestTimeout = defaultRequestTimeout
+
+	m.token = ""
+}
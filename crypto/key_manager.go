@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"context"
+)
+
+// Types of keys that can be generated with KeyManager.
+const (
+	KeyTypeRSA2048      = "rsa-2048"
+	KeyTypeRSA3072      = "rsa-3072"
+	KeyTypeRSA4096      = "rsa-4096"
+	KeyTypeECP256       = "ec-p256"
+	KeyTypeECP384       = "ec-p384"
+	KeyTypeECP521       = "ec-p521"
+	KeyTypeEd25519      = "ed25519"
+	KeyTypeSymmetric128 = "oct-128"
+	KeyTypeSymmetric192 = "oct-192"
+	KeyTypeSymmetric256 = "oct-256"
+)
+
+// KeyManager is an optional interface for SubtleCrypto components that can generate and rotate keys.
+// Keys managed this way have multiple versions, and can be referenced as "name" (the current version) or as "name/version".
+// When decrypting or unwrapping with "name" fails, components try the previous versions of the key.
+type KeyManager interface {
+	// GenerateKey creates a new key, which must not exist yet, and returns the name of its first version in the "name/version" form.
+	GenerateKey(ctx context.Context,
+		// Name of the key
+		keyName string,
+		// Type of the key, one of the KeyType constants
+		keyType string,
+	) (
+		// Name of the first version of the key
+		keyVersion string,
+		err error,
+	)
+
+	// RotateKey creates a new version of an existing key, of the same type, which becomes the current version.
+	RotateKey(ctx context.Context,
+		// Name of the key
+		keyName string,
+	) (
+		// Name of the new version of the key
+		keyVersion string,
+		err error,
+	)
+
+	// ListKeyVersions returns the versions of a key in the "name/version" form, from the oldest to the current one.
+	ListKeyVersions(ctx context.Context,
+		// Name of the key
+		keyName string,
+	) (
+		keyVersions []string,
+		err error,
+	)
+}
@@ -41,6 +41,9 @@ var (
 type LocalCryptoBaseComponent struct {
 	// RetrieveKeyFn is the function used to retrieve a key, and must be passed by concrete implementations
 	RetrieveKeyFn func(parentCtx context.Context, key string) (jwk.Key, error)
+	// PreviousKeyVersionsFn is an optional function that returns the previous versions of a key, from the newest to the oldest.
+	// If set, decrypting and unwrapping with a key that fails is retried with its previous versions.
+	PreviousKeyVersionsFn func(parentCtx context.Context, key string) ([]string, error)
 }
 
 func (k LocalCryptoBaseComponent) GetKey(parentCtx context.Context, key string) (pubKey jwk.Key, err error) {
@@ -85,6 +88,17 @@ func (k LocalCryptoBaseComponent) Encrypt(parentCtx context.Context, plaintext [
 }
 
 func (k LocalCryptoBaseComponent) Decrypt(parentCtx context.Context, ciphertext []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
+	err = k.withPreviousKeyVersions(parentCtx, keyName, func(keyName string) (dErr error) {
+		plaintext, dErr = k.decrypt(parentCtx, ciphertext, algorithm, keyName, nonce, tag, associatedData)
+		return dErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+func (k LocalCryptoBaseComponent) decrypt(parentCtx context.Context, ciphertext []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
 	// Retrieve the key
 	key, err := k.RetrieveKeyFn(parentCtx, keyName)
 	if err != nil {
@@ -137,6 +151,17 @@ func (k LocalCryptoBaseComponent) WrapKey(parentCtx context.Context, plaintextKe
 }
 
 func (k LocalCryptoBaseComponent) UnwrapKey(parentCtx context.Context, wrappedKey []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
+	err = k.withPreviousKeyVersions(parentCtx, keyName, func(keyName string) (uErr error) {
+		plaintextKey, uErr = k.unwrapKey(parentCtx, wrappedKey, algorithm, keyName, nonce, tag, associatedData)
+		return uErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plaintextKey, nil
+}
+
+func (k LocalCryptoBaseComponent) unwrapKey(parentCtx context.Context, wrappedKey []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
 	// Retrieve the key encryption key
 	kek, err := k.RetrieveKeyFn(parentCtx, keyName)
 	if err != nil {
@@ -212,6 +237,26 @@ func (k LocalCryptoBaseComponent) Verify(parentCtx context.Context, digest []byt
 	return valid, nil
 }
 
+// withPreviousKeyVersions invokes fn with keyName and, while it fails, with the previous versions of the key.
+// If all attempts fail, it returns the error of the first one.
+func (k LocalCryptoBaseComponent) withPreviousKeyVersions(parentCtx context.Context, keyName string, fn func(keyName string) error) error {
+	err := fn(keyName)
+	if err == nil || k.PreviousKeyVersionsFn == nil {
+		return err
+	}
+
+	versions, vErr := k.PreviousKeyVersionsFn(parentCtx, keyName)
+	if vErr != nil {
+		return err
+	}
+	for _, version := range versions {
+		if fn(version) == nil {
+			return nil
+		}
+	}
+	return err
+}
+
 func (k LocalCryptoBaseComponent) SupportedEncryptionAlgorithms() []string {
 	supportedAlgsOnce.Do(populateSupportedAlgs)
 	return supportedEncryptionAlgorithms
@@ -17,10 +17,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
@@ -35,16 +37,21 @@ type localStorageCrypto struct {
 	contribCrypto.LocalCryptoBaseComponent
 
 	md     localStorageMetadata
+	lock   sync.Mutex
 	logger logger.Logger
 }
 
+var _ contribCrypto.KeyManager = (*localStorageCrypto)(nil)
+
 // NewLocalStorageCrypto returns a new local storage crypto provider.
 // Keys are loaded from PEM or JSON (each containing an individual JWK) files from a local folder on disk.
+// Keys generated with the KeyManager interface are versioned, and stored in a sub-folder for each key.
 func NewLocalStorageCrypto(logger logger.Logger) contribCrypto.SubtleCrypto {
 	k := &localStorageCrypto{
 		logger: logger,
 	}
 	k.RetrieveKeyFn = k.retrieveKey
+	k.PreviousKeyVersionsFn = k.previousKeyVersions
 	return k
 }
 
@@ -61,7 +68,9 @@ func (l *localStorageCrypto) Init(_ context.Context, metadata contribCrypto.Meta
 
 // Features returns the features available in this crypto provider.
 func (l *localStorageCrypto) Features() []contribCrypto.Feature {
-	return []contribCrypto.Feature{} // No Feature supported.
+	return []contribCrypto.Feature{
+		contribCrypto.FeatureKeyManagement,
+	}
 }
 
 func (l *localStorageCrypto) Close() error {
@@ -69,18 +78,41 @@ func (l *localStorageCrypto) Close() error {
 }
 
 // Retrieves a key (public or private or symmetric) from a local file.
-// Parameter "key" must be the name of a file inside the "path"
+// Parameter "key" must be the name of a file inside the "path", the name of a versioned key (for its current version), or a version of a versioned key in the "name/version" form.
 func (l *localStorageCrypto) retrieveKey(parentCtx context.Context, key string) (jwk.Key, error) {
 	// Do not allow escaping the root path by including ".." in the key's name
 	if strings.Contains(key, "..") {
 		return nil, errors.New("invalid key path: cannot contain '..'")
 	}
 
-	// Load the file
 	path := filepath.Join(l.md.Path, key)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		// Versioned key: use the current version
+		versions, vErr := l.keyVersions(key)
+		if vErr != nil {
+			return nil, fmt.Errorf("failed to load key '%s': %w", key, vErr)
+		}
+		path = l.versionPath(key, versions[len(versions)-1])
+	case errors.Is(err, fs.ErrNotExist):
+		// Version of a versioned key
+		if name, version, ok := splitVersionName(key); ok {
+			path = l.versionPath(name, version)
+		}
+	}
+
+	return l.readKeyFile(path)
+}
+
+// Reads a key from a PEM or JSON file.
+func (l *localStorageCrypto) readKeyFile(path string) (jwk.Key, error) {
+	key, _ := filepath.Rel(l.md.Path, path)
+
+	// Load the file
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load key '%s': %w", key, err)
+		return nil, fmt.Errorf("failed to load key '%s': %w", filepath.ToSlash(key), err)
 	}
 
 	// Check if we can determine the file type from the extension
@@ -109,7 +141,7 @@ func (l *localStorageCrypto) retrieveKey(parentCtx context.Context, key string)
 	return jwkObj, nil
 }
 
-func (localStorageCrypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+func (*localStorageCrypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
 	metadataStruct := localStorageMetadata{}
 	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
 	return
@@ -0,0 +1,305 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstorage
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+)
+
+// Versioned keys are stored in a folder named after the key, with a JWK file for each version, such as "mykey/1.json".
+const versionFileExt = ".json"
+
+// GenerateKey creates a new versioned key.
+func (l *localStorageCrypto) GenerateKey(_ context.Context, keyName string, keyType string) (string, error) {
+	err := validateVersionedKeyName(keyName)
+	if err != nil {
+		return "", err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	err = os.Mkdir(filepath.Join(l.md.Path, keyName), 0o700)
+	if errors.Is(err, fs.ErrExist) {
+		return "", fmt.Errorf("key '%s' already exists", keyName)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to create folder for key '%s': %w", keyName, err)
+	}
+
+	keyVersion, err := l.writeKeyVersion(keyName, 1, keyType)
+	if err != nil {
+		// Remove the folder so the key can be generated again
+		_ = os.RemoveAll(filepath.Join(l.md.Path, keyName))
+		return "", err
+	}
+	return keyVersion, nil
+}
+
+// RotateKey creates a new version of a versioned key.
+func (l *localStorageCrypto) RotateKey(_ context.Context, keyName string) (string, error) {
+	err := validateVersionedKeyName(keyName)
+	if err != nil {
+		return "", err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	versions, err := l.keyVersions(keyName)
+	if err != nil {
+		return "", err
+	}
+	current := versions[len(versions)-1]
+
+	// The new version has the same type as the current one
+	key, err := l.readKeyFile(l.versionPath(keyName, current))
+	if err != nil {
+		return "", err
+	}
+	keyType, err := keyTypeOf(key)
+	if err != nil {
+		return "", err
+	}
+
+	return l.writeKeyVersion(keyName, current+1, keyType)
+}
+
+// ListKeyVersions returns the versions of a versioned key.
+func (l *localStorageCrypto) ListKeyVersions(_ context.Context, keyName string) ([]string, error) {
+	err := validateVersionedKeyName(keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := l.keyVersions(keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, len(versions))
+	for i, v := range versions {
+		res[i] = versionName(keyName, v)
+	}
+	return res, nil
+}
+
+// previousKeyVersions returns the versions before the current one of a versioned key referenced by name, from the newest to the oldest.
+// For all other keys, including specific versions of a versioned key, it returns nil.
+func (l *localStorageCrypto) previousKeyVersions(_ context.Context, keyName string) ([]string, error) {
+	if !l.isVersionedKey(keyName) {
+		return nil, nil
+	}
+
+	versions, err := l.keyVersions(keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(versions)-1)
+	for i := len(versions) - 2; i >= 0; i-- {
+		res = append(res, versionName(keyName, versions[i]))
+	}
+	return res, nil
+}
+
+// isVersionedKey returns true if keyName is the name of a versioned key.
+func (l *localStorageCrypto) isVersionedKey(keyName string) bool {
+	if strings.Contains(keyName, "..") {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(l.md.Path, keyName))
+	return err == nil && info.IsDir()
+}
+
+// keyVersions returns the versions of a versioned key, sorted from the oldest to the current one.
+func (l *localStorageCrypto) keyVersions(keyName string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(l.md.Path, keyName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("key '%s': %w", keyName, contribCrypto.ErrKeyNotFound)
+		}
+		return nil, fmt.Errorf("failed to list versions of key '%s': %w", keyName, err)
+	}
+
+	versions := make([]int, 0, len(entries))
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), versionFileExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		v, err := strconv.Atoi(name)
+		if err != nil || v < 1 {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("key '%s' has no versions: %w", keyName, contribCrypto.ErrKeyNotFound)
+	}
+
+	slices.Sort(versions)
+	return versions, nil
+}
+
+func (l *localStorageCrypto) versionPath(keyName string, version int) string {
+	return filepath.Join(l.md.Path, keyName, strconv.Itoa(version)+versionFileExt)
+}
+
+// writeKeyVersion generates a key and saves it as a version of a versioned key, returning the name of the version.
+func (l *localStorageCrypto) writeKeyVersion(keyName string, version int, keyType string) (string, error) {
+	keyVersion := versionName(keyName, version)
+
+	key, err := generateKey(keyType)
+	if err != nil {
+		return "", err
+	}
+	err = key.Set(jwk.KeyIDKey, keyVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to set key ID: %w", err)
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize key: %w", err)
+	}
+
+	// Fail if the version already exists, so it's never overwritten
+	f, err := os.OpenFile(l.versionPath(keyName, version), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create key '%s': %w", keyVersion, err)
+	}
+	_, err = f.Write(data)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write key '%s': %w", keyVersion, err)
+	}
+
+	return keyVersion, nil
+}
+
+func versionName(keyName string, version int) string {
+	return keyName + "/" + strconv.Itoa(version)
+}
+
+// splitVersionName splits a key name in the "name/version" form.
+func splitVersionName(keyName string) (string, int, bool) {
+	i := strings.LastIndexByte(keyName, '/')
+	if i <= 0 {
+		return "", 0, false
+	}
+	version, err := strconv.Atoi(keyName[i+1:])
+	if err != nil || version < 1 {
+		return "", 0, false
+	}
+	return keyName[:i], version, true
+}
+
+func validateVersionedKeyName(keyName string) error {
+	if keyName == "" || keyName == "." || strings.ContainsAny(keyName, `/\`) || strings.Contains(keyName, "..") {
+		return fmt.Errorf("invalid key name '%s': must not be empty or contain path separators", keyName)
+	}
+	return nil
+}
+
+// generateKey generates a new key of the given type.
+func generateKey(keyType string) (jwk.Key, error) {
+	var (
+		raw any
+		err error
+	)
+	switch strings.ToLower(keyType) {
+	case contribCrypto.KeyTypeRSA2048:
+		raw, err = rsa.GenerateKey(rand.Reader, 2048)
+	case contribCrypto.KeyTypeRSA3072:
+		raw, err = rsa.GenerateKey(rand.Reader, 3072)
+	case contribCrypto.KeyTypeRSA4096:
+		raw, err = rsa.GenerateKey(rand.Reader, 4096)
+	case contribCrypto.KeyTypeECP256:
+		raw, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case contribCrypto.KeyTypeECP384:
+		raw, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case contribCrypto.KeyTypeECP521:
+		raw, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case contribCrypto.KeyTypeEd25519:
+		_, raw, err = ed25519.GenerateKey(rand.Reader)
+	case contribCrypto.KeyTypeSymmetric128:
+		raw, err = randomBytes(16)
+	case contribCrypto.KeyTypeSymmetric192:
+		raw, err = randomBytes(24)
+	case contribCrypto.KeyTypeSymmetric256:
+		raw, err = randomBytes(32)
+	default:
+		return nil, fmt.Errorf("unsupported key type '%s'", keyType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+	return key, nil
+}
+
+// keyTypeOf returns the type of an existing key, as one of the KeyType constants.
+func keyTypeOf(key jwk.Key) (string, error) {
+	switch k := key.(type) {
+	case jwk.RSAPrivateKey:
+		return "rsa-" + strconv.Itoa(len(k.N())*8), nil
+	case jwk.ECDSAPrivateKey:
+		switch k.Crv() {
+		case jwa.P256:
+			return contribCrypto.KeyTypeECP256, nil
+		case jwa.P384:
+			return contribCrypto.KeyTypeECP384, nil
+		case jwa.P521:
+			return contribCrypto.KeyTypeECP521, nil
+		}
+	case jwk.OKPPrivateKey:
+		if k.Crv() == jwa.Ed25519 {
+			return contribCrypto.KeyTypeEd25519, nil
+		}
+	case jwk.SymmetricKey:
+		return "oct-" + strconv.Itoa(len(k.Octets())*8), nil
+	}
+	return "", fmt.Errorf("unsupported type of key '%s'", key.KeyID())
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
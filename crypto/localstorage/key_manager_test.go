@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstorage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func newTestComponent(t *testing.T) (*localStorageCrypto, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	l := NewLocalStorageCrypto(logger.NewLogger("test")).(*localStorageCrypto)
+	err := l.Init(context.Background(), contribCrypto.Metadata{Base: contribMetadata.Base{
+		Properties: map[string]string{"path": dir},
+	}})
+	require.NoError(t, err)
+	return l, dir
+}
+
+func TestKeyManager(t *testing.T) {
+	ctx := context.Background()
+	nonce := []byte("123456789012")
+
+	t.Run("generate and rotate", func(t *testing.T) {
+		l, dir := newTestComponent(t)
+		assert.Contains(t, l.Features(), contribCrypto.FeatureKeyManagement)
+
+		version, err := l.GenerateKey(ctx, "mykey", contribCrypto.KeyTypeSymmetric256)
+		require.NoError(t, err)
+		assert.Equal(t, "mykey/1", version)
+		assert.FileExists(t, filepath.Join(dir, "mykey", "1.json"))
+
+		_, err = l.GenerateKey(ctx, "mykey", contribCrypto.KeyTypeSymmetric256)
+		require.ErrorContains(t, err, "already exists")
+
+		ciphertext1, tag1, err := l.Encrypt(ctx, []byte("message 1"), "A256GCM", "mykey", nonce, nil)
+		require.NoError(t, err)
+
+		version, err = l.RotateKey(ctx, "mykey")
+		require.NoError(t, err)
+		assert.Equal(t, "mykey/2", version)
+
+		versions, err := l.ListKeyVersions(ctx, "mykey")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mykey/1", "mykey/2"}, versions)
+
+		// The current version is used to encrypt
+		ciphertext2, tag2, err := l.Encrypt(ctx, []byte("message 2"), "A256GCM", "mykey", nonce, nil)
+		require.NoError(t, err)
+		_, err = l.Decrypt(ctx, ciphertext2, "A256GCM", "mykey/1", nonce, tag2, nil)
+		require.Error(t, err)
+
+		// Decrypting with the name of the key tries the previous versions
+		plaintext, err := l.Decrypt(ctx, ciphertext1, "A256GCM", "mykey", nonce, tag1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "message 1", string(plaintext))
+		plaintext, err = l.Decrypt(ctx, ciphertext2, "A256GCM", "mykey", nonce, tag2, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "message 2", string(plaintext))
+
+		// Specific versions are not retried
+		_, err = l.Decrypt(ctx, ciphertext1, "A256GCM", "mykey/2", nonce, tag1, nil)
+		require.Error(t, err)
+		plaintext, err = l.Decrypt(ctx, ciphertext1, "A256GCM", "mykey/1", nonce, tag1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "message 1", string(plaintext))
+	})
+
+	t.Run("unwrap with previous versions", func(t *testing.T) {
+		l, _ := newTestComponent(t)
+
+		_, err := l.GenerateKey(ctx, "kek", contribCrypto.KeyTypeSymmetric256)
+		require.NoError(t, err)
+
+		dek, err := jwk.FromRaw([]byte("0123456789abcdef0123456789abcdef"))
+		require.NoError(t, err)
+		wrapped, tag, err := l.WrapKey(ctx, dek, "A256KW", "kek", nil, nil)
+		require.NoError(t, err)
+
+		_, err = l.RotateKey(ctx, "kek")
+		require.NoError(t, err)
+
+		unwrapped, err := l.UnwrapKey(ctx, wrapped, "A256KW", "kek", nil, tag, nil)
+		require.NoError(t, err)
+		var raw []byte
+		require.NoError(t, unwrapped.Raw(&raw))
+		assert.Equal(t, "0123456789abcdef0123456789abcdef", string(raw))
+	})
+
+	t.Run("rotated keys have the same type", func(t *testing.T) {
+		l, dir := newTestComponent(t)
+
+		_, err := l.GenerateKey(ctx, "signing", contribCrypto.KeyTypeECP384)
+		require.NoError(t, err)
+		_, err = l.RotateKey(ctx, "signing")
+		require.NoError(t, err)
+
+		key, err := l.readKeyFile(filepath.Join(dir, "signing", "2.json"))
+		require.NoError(t, err)
+		keyType, err := keyTypeOf(key)
+		require.NoError(t, err)
+		assert.Equal(t, contribCrypto.KeyTypeECP384, keyType)
+		assert.Equal(t, "signing/2", key.KeyID())
+
+		// The public key of the current version is returned
+		pub, err := l.GetKey(ctx, "signing")
+		require.NoError(t, err)
+		assert.Equal(t, "signing/2", pub.KeyID())
+	})
+
+	t.Run("unversioned keys", func(t *testing.T) {
+		l, dir := newTestComponent(t)
+
+		key, err := generateKey(contribCrypto.KeyTypeSymmetric128)
+		require.NoError(t, err)
+		data, err := json.Marshal(key)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "plain.json"), data, 0o600))
+
+		ciphertext, tag, err := l.Encrypt(ctx, []byte("message"), "A128GCM", "plain.json", nonce, nil)
+		require.NoError(t, err)
+		plaintext, err := l.Decrypt(ctx, ciphertext, "A128GCM", "plain.json", nonce, tag, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "message", string(plaintext))
+
+		_, err = l.RotateKey(ctx, "plain.json")
+		require.Error(t, err)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		l, dir := newTestComponent(t)
+
+		_, err := l.GenerateKey(ctx, "badtype", "rsa-1024")
+		require.ErrorContains(t, err, "unsupported key type")
+		assert.NoDirExists(t, filepath.Join(dir, "badtype"))
+
+		_, err = l.GenerateKey(ctx, "nested/key", contribCrypto.KeyTypeEd25519)
+		require.ErrorContains(t, err, "invalid key name")
+		_, err = l.GenerateKey(ctx, "..", contribCrypto.KeyTypeEd25519)
+		require.ErrorContains(t, err, "invalid key name")
+
+		_, err = l.RotateKey(ctx, "missing")
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+		_, err = l.ListKeyVersions(ctx, "missing")
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+	})
+}
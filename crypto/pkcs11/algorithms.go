@@ -0,0 +1,221 @@
+//go:build cgo
+// +build cgo
+
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"crypto"
+	"encoding/asn1"
+	"errors"
+	"maps"
+	"math/big"
+	"slices"
+
+	"github.com/miekg/pkcs11"
+
+	internals "github.com/dapr/kit/crypto"
+)
+
+// Constants from PKCS#11 v3.0 that are not defined in the pkcs11 package.
+const (
+	ckkECEdwards = 0x00000040
+	ckmEdDSA     = 0x00001057
+)
+
+// Size of the authentication tag for AES-GCM, in bytes.
+const gcmTagSize = 16
+
+// encryptionAlgorithm maps an encryption (or key wrapping) algorithm to a PKCS#11 mechanism.
+type encryptionAlgorithm struct {
+	mechanism uint
+	// Size of the key in bytes, for symmetric algorithms
+	keySize int
+	// Hash used by RSA-OAEP
+	hash crypto.Hash
+}
+
+var encryptionAlgorithms = map[string]encryptionAlgorithm{
+	internals.Algorithm_A128GCM:       {mechanism: pkcs11.CKM_AES_GCM, keySize: 16},
+	internals.Algorithm_A192GCM:       {mechanism: pkcs11.CKM_AES_GCM, keySize: 24},
+	internals.Algorithm_A256GCM:       {mechanism: pkcs11.CKM_AES_GCM, keySize: 32},
+	internals.Algorithm_A128GCMKW:     {mechanism: pkcs11.CKM_AES_GCM, keySize: 16},
+	internals.Algorithm_A192GCMKW:     {mechanism: pkcs11.CKM_AES_GCM, keySize: 24},
+	internals.Algorithm_A256GCMKW:     {mechanism: pkcs11.CKM_AES_GCM, keySize: 32},
+	internals.Algorithm_A128CBC:       {mechanism: pkcs11.CKM_AES_CBC_PAD, keySize: 16},
+	internals.Algorithm_A192CBC:       {mechanism: pkcs11.CKM_AES_CBC_PAD, keySize: 24},
+	internals.Algorithm_A256CBC:       {mechanism: pkcs11.CKM_AES_CBC_PAD, keySize: 32},
+	internals.Algorithm_A128CBC_NOPAD: {mechanism: pkcs11.CKM_AES_CBC, keySize: 16},
+	internals.Algorithm_A192CBC_NOPAD: {mechanism: pkcs11.CKM_AES_CBC, keySize: 24},
+	internals.Algorithm_A256CBC_NOPAD: {mechanism: pkcs11.CKM_AES_CBC, keySize: 32},
+	internals.Algorithm_A128KW:        {mechanism: pkcs11.CKM_AES_KEY_WRAP, keySize: 16},
+	internals.Algorithm_A192KW:        {mechanism: pkcs11.CKM_AES_KEY_WRAP, keySize: 24},
+	internals.Algorithm_A256KW:        {mechanism: pkcs11.CKM_AES_KEY_WRAP, keySize: 32},
+	internals.Algorithm_RSA1_5:        {mechanism: pkcs11.CKM_RSA_PKCS},
+	internals.Algorithm_RSA_OAEP:      {mechanism: pkcs11.CKM_RSA_PKCS_OAEP, hash: crypto.SHA1},
+	internals.Algorithm_RSA_OAEP_256:  {mechanism: pkcs11.CKM_RSA_PKCS_OAEP, hash: crypto.SHA256},
+	internals.Algorithm_RSA_OAEP_384:  {mechanism: pkcs11.CKM_RSA_PKCS_OAEP, hash: crypto.SHA384},
+	internals.Algorithm_RSA_OAEP_512:  {mechanism: pkcs11.CKM_RSA_PKCS_OAEP, hash: crypto.SHA512},
+}
+
+// IsAsymmetric returns true if the algorithm uses a public/private key pair.
+func (a encryptionAlgorithm) IsAsymmetric() bool {
+	return a.keySize == 0
+}
+
+// IsGCM returns true if the algorithm is AES-GCM, which appends the authentication tag to the ciphertext.
+func (a encryptionAlgorithm) IsGCM() bool {
+	return a.mechanism == pkcs11.CKM_AES_GCM
+}
+
+// Mechanism returns the PKCS#11 mechanism to use for the algorithm.
+// When using AES-GCM, the returned parameters must be freed once the operation is complete.
+func (a encryptionAlgorithm) Mechanism(nonce []byte, associatedData []byte) (*pkcs11.Mechanism, *pkcs11.GCMParams, error) {
+	switch a.mechanism {
+	case pkcs11.CKM_AES_GCM:
+		if len(nonce) != 12 {
+			return nil, nil, internals.ErrInvalidNonce
+		}
+		params := pkcs11.NewGCMParams(nonce, associatedData, gcmTagSize*8)
+		return pkcs11.NewMechanism(a.mechanism, params), params, nil
+	case pkcs11.CKM_AES_CBC_PAD, pkcs11.CKM_AES_CBC:
+		if len(nonce) != 16 {
+			return nil, nil, internals.ErrInvalidNonce
+		}
+		return pkcs11.NewMechanism(a.mechanism, nonce), nil, nil
+	case pkcs11.CKM_RSA_PKCS_OAEP:
+		params := pkcs11.NewOAEPParams(hashMechanisms[a.hash], mgfMechanisms[a.hash], pkcs11.CKZ_DATA_SPECIFIED, associatedData)
+		return pkcs11.NewMechanism(a.mechanism, params), nil, nil
+	default:
+		return pkcs11.NewMechanism(a.mechanism, nil), nil, nil
+	}
+}
+
+// signatureAlgorithm maps a signature algorithm to a PKCS#11 mechanism.
+type signatureAlgorithm struct {
+	mechanism uint
+	// Hash used to compute the digest; this is 0 for EdDSA, which signs the full message
+	hash crypto.Hash
+	// Size in bytes of each of the two integers in ECDSA signatures
+	ecdsaSize int
+}
+
+var signatureAlgorithms = map[string]signatureAlgorithm{
+	internals.Algorithm_RS256: {mechanism: pkcs11.CKM_RSA_PKCS, hash: crypto.SHA256},
+	internals.Algorithm_RS384: {mechanism: pkcs11.CKM_RSA_PKCS, hash: crypto.SHA384},
+	internals.Algorithm_RS512: {mechanism: pkcs11.CKM_RSA_PKCS, hash: crypto.SHA512},
+	internals.Algorithm_PS256: {mechanism: pkcs11.CKM_RSA_PKCS_PSS, hash: crypto.SHA256},
+	internals.Algorithm_PS384: {mechanism: pkcs11.CKM_RSA_PKCS_PSS, hash: crypto.SHA384},
+	internals.Algorithm_PS512: {mechanism: pkcs11.CKM_RSA_PKCS_PSS, hash: crypto.SHA512},
+	internals.Algorithm_ES256: {mechanism: pkcs11.CKM_ECDSA, hash: crypto.SHA256, ecdsaSize: 32},
+	internals.Algorithm_ES384: {mechanism: pkcs11.CKM_ECDSA, hash: crypto.SHA384, ecdsaSize: 48},
+	internals.Algorithm_ES512: {mechanism: pkcs11.CKM_ECDSA, hash: crypto.SHA512, ecdsaSize: 66},
+	internals.Algorithm_EdDSA: {mechanism: ckmEdDSA},
+}
+
+// Mechanism returns the PKCS#11 mechanism to use for the algorithm.
+func (a signatureAlgorithm) Mechanism() *pkcs11.Mechanism {
+	if a.mechanism == pkcs11.CKM_RSA_PKCS_PSS {
+		// The salt has the same length as the hash
+		return pkcs11.NewMechanism(a.mechanism, pkcs11.NewPSSParams(hashMechanisms[a.hash], mgfMechanisms[a.hash], uint(a.hash.Size())))
+	}
+	return pkcs11.NewMechanism(a.mechanism, nil)
+}
+
+// Input returns the data that is signed by the token for a digest.
+func (a signatureAlgorithm) Input(digest []byte) ([]byte, error) {
+	if a.hash == 0 {
+		return digest, nil
+	}
+	if len(digest) != a.hash.Size() {
+		return nil, errors.New("digest has an invalid length for the algorithm")
+	}
+
+	// CKM_RSA_PKCS only pads the data, so the DigestInfo structure needs to be added
+	if a.mechanism == pkcs11.CKM_RSA_PKCS {
+		prefix := digestInfoPrefixes[a.hash]
+		input := make([]byte, len(prefix)+len(digest))
+		copy(input, prefix)
+		copy(input[len(prefix):], digest)
+		return input, nil
+	}
+	return digest, nil
+}
+
+// EncodeSignature converts a signature returned by the token to the format used by Dapr.
+// PKCS#11 returns ECDSA signatures as the concatenation of r and s, while Dapr uses ASN.1.
+func (a signatureAlgorithm) EncodeSignature(signature []byte) ([]byte, error) {
+	if a.ecdsaSize == 0 {
+		return signature, nil
+	}
+	if len(signature) == 0 || len(signature)%2 != 0 {
+		return nil, errors.New("invalid ECDSA signature returned by the token")
+	}
+	n := len(signature) / 2
+	return asn1.Marshal(ecdsaSignature{
+		R: new(big.Int).SetBytes(signature[:n]),
+		S: new(big.Int).SetBytes(signature[n:]),
+	})
+}
+
+// DecodeSignature converts a signature in the format used by Dapr to the one used by PKCS#11.
+// It returns false if the signature is malformed.
+func (a signatureAlgorithm) DecodeSignature(signature []byte) ([]byte, bool) {
+	if a.ecdsaSize == 0 {
+		return signature, true
+	}
+	var sig ecdsaSignature
+	rest, err := asn1.Unmarshal(signature, &sig)
+	if err != nil || len(rest) > 0 || sig.R.Sign() <= 0 || sig.S.Sign() <= 0 {
+		return nil, false
+	}
+	if sig.R.BitLen() > a.ecdsaSize*8 || sig.S.BitLen() > a.ecdsaSize*8 {
+		return nil, false
+	}
+	res := make([]byte, a.ecdsaSize*2)
+	sig.R.FillBytes(res[:a.ecdsaSize])
+	sig.S.FillBytes(res[a.ecdsaSize:])
+	return res, true
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+var hashMechanisms = map[crypto.Hash]uint{
+	crypto.SHA1:   pkcs11.CKM_SHA_1,
+	crypto.SHA256: pkcs11.CKM_SHA256,
+	crypto.SHA384: pkcs11.CKM_SHA384,
+	crypto.SHA512: pkcs11.CKM_SHA512,
+}
+
+var mgfMechanisms = map[crypto.Hash]uint{
+	crypto.SHA1:   pkcs11.CKG_MGF1_SHA1,
+	crypto.SHA256: pkcs11.CKG_MGF1_SHA256,
+	crypto.SHA384: pkcs11.CKG_MGF1_SHA384,
+	crypto.SHA512: pkcs11.CKG_MGF1_SHA512,
+}
+
+// DER-encoded DigestInfo prefixes, from RFC 8017.
+var digestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+var (
+	encryptionAlgsList = slices.Sorted(maps.Keys(encryptionAlgorithms))
+	signatureAlgsList  = slices.Sorted(maps.Keys(signatureAlgorithms))
+)
@@ -0,0 +1,547 @@
+//go:build cgo
+// +build cgo
+
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/miekg/pkcs11"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+// pkcs11Crypto is a crypto provider that performs all operations inside a token (such as a HSM) accessed through PKCS#11.
+// Keys are referenced by their label (CKA_LABEL) and never leave the token.
+type pkcs11Crypto struct {
+	md     pkcs11Metadata
+	ctx    *pkcs11.Ctx
+	slot   uint
+	logger logger.Logger
+
+	// Session that keeps the user logged in while the component is initialized
+	// Operations are performed in their own sessions, which share the login state
+	loginSession pkcs11.SessionHandle
+}
+
+// NewPKCS11Crypto returns a new PKCS#11 crypto provider.
+func NewPKCS11Crypto(logger logger.Logger) contribCrypto.SubtleCrypto {
+	return &pkcs11Crypto{
+		logger: logger,
+	}
+}
+
+// Init loads the PKCS#11 module and logs into the token.
+func (p *pkcs11Crypto) Init(_ context.Context, metadata contribCrypto.Metadata) error {
+	// Init the metadata
+	err := p.md.InitWithMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	p.ctx, err = loadModule(p.md.Module)
+	if err != nil {
+		return err
+	}
+
+	err = p.login()
+	if err != nil {
+		releaseModule(p.md.Module)
+		p.ctx = nil
+		return err
+	}
+
+	return nil
+}
+
+func (p *pkcs11Crypto) login() (err error) {
+	p.slot, err = p.findSlot()
+	if err != nil {
+		return err
+	}
+
+	p.loginSession, err = p.ctx.OpenSession(p.slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+
+	err = p.ctx.Login(p.loginSession, pkcs11.CKU_USER, p.md.PIN)
+	// The user may already be logged in if another component uses the same token
+	if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		_ = p.ctx.CloseSession(p.loginSession)
+		return fmt.Errorf("failed to log into the token: %w", err)
+	}
+
+	return nil
+}
+
+// findSlot returns the slot configured in the metadata, or the one containing the token with the configured label.
+func (p *pkcs11Crypto) findSlot() (uint, error) {
+	if p.md.SlotID != nil {
+		return *p.md.SlotID, nil
+	}
+
+	slots, err := p.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := p.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get information on the token in slot %d: %w", slot, err)
+		}
+		if info.Label == p.md.TokenLabel {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("could not find a token with label '%s'", p.md.TokenLabel)
+}
+
+// Features returns the features available in this crypto provider.
+func (p *pkcs11Crypto) Features() []contribCrypto.Feature {
+	return []contribCrypto.Feature{} // No Feature supported.
+}
+
+// GetKey returns the public part of a key stored in the token.
+// This method returns an error if the key is symmetric.
+func (p *pkcs11Crypto) GetKey(_ context.Context, keyName string) (pubKey jwk.Key, err error) {
+	err = p.withSession(func(sh pkcs11.SessionHandle) error {
+		obj, err := p.findKey(sh, keyName, pkcs11.CKO_PUBLIC_KEY)
+		if err != nil {
+			return err
+		}
+
+		attrs, err := p.ctx.GetAttributeValue(sh, obj, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get type of key: %w", err)
+		}
+		keyType, err := attributeUint(attrs[0].Value)
+		if err != nil {
+			return fmt.Errorf("invalid key type: %w", err)
+		}
+
+		template, err := publicKeyAttributes(keyType)
+		if err != nil {
+			return err
+		}
+		attrs, err = p.ctx.GetAttributeValue(sh, obj, template)
+		if err != nil {
+			return fmt.Errorf("failed to get attributes of key: %w", err)
+		}
+		pk, err := PublicKey(keyType, attrs)
+		if err != nil {
+			return fmt.Errorf("failed to extract public key: %w", err)
+		}
+
+		pubKey, err = jwk.FromRaw(pk)
+		if err != nil {
+			return fmt.Errorf("failed to create JWK from public key: %w", err)
+		}
+		return pubKey.Set(jwk.KeyIDKey, keyName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pubKey, nil
+}
+
+// Encrypt a small message and returns the ciphertext.
+func (p *pkcs11Crypto) Encrypt(_ context.Context, plaintext []byte, algorithm string, keyName string, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	alg, ok := encryptionAlgorithms[algorithm]
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	err = p.withSession(func(sh pkcs11.SessionHandle) error {
+		key, err := p.findEncryptionKey(sh, keyName, alg, pkcs11.CKO_PUBLIC_KEY)
+		if err != nil {
+			return err
+		}
+
+		mech, gcmParams, err := alg.Mechanism(nonce, associatedData)
+		if err != nil {
+			return err
+		}
+		defer gcmParams.Free()
+
+		err = p.ctx.EncryptInit(sh, []*pkcs11.Mechanism{mech}, key)
+		if err != nil {
+			return fmt.Errorf("failed to initialize encryption: %w", err)
+		}
+		ciphertext, err = p.ctx.Encrypt(sh, plaintext)
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+
+	if alg.IsGCM() {
+		return splitTag(ciphertext)
+	}
+	return ciphertext, nil, nil
+}
+
+// Decrypt a small message and returns the plaintext.
+func (p *pkcs11Crypto) Decrypt(_ context.Context, ciphertext []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
+	alg, ok := encryptionAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	if alg.IsGCM() {
+		ciphertext, err = appendTag(ciphertext, tag)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = p.withSession(func(sh pkcs11.SessionHandle) error {
+		key, err := p.findEncryptionKey(sh, keyName, alg, pkcs11.CKO_PRIVATE_KEY)
+		if err != nil {
+			return err
+		}
+
+		mech, gcmParams, err := alg.Mechanism(nonce, associatedData)
+		if err != nil {
+			return err
+		}
+		defer gcmParams.Free()
+
+		err = p.ctx.DecryptInit(sh, []*pkcs11.Mechanism{mech}, key)
+		if err != nil {
+			return fmt.Errorf("failed to initialize decryption: %w", err)
+		}
+		plaintext, err = p.ctx.Decrypt(sh, ciphertext)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+// WrapKey wraps a symmetric key.
+// The key is imported in the token as a session object, and then wrapped by the token.
+func (p *pkcs11Crypto) WrapKey(_ context.Context, plaintextKey jwk.Key, algorithm string, keyName string, nonce []byte, associatedData []byte) (wrappedKey []byte, tag []byte, err error) {
+	// Only symmetric keys can be imported as secret keys
+	if plaintextKey.KeyType() != jwa.OctetSeq {
+		return nil, nil, errors.New("cannot wrap asymmetric keys")
+	}
+	plaintext, err := internals.SerializeKey(plaintextKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot serialize key: %w", err)
+	}
+
+	alg, ok := encryptionAlgorithms[algorithm]
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	err = p.withSession(func(sh pkcs11.SessionHandle) error {
+		kek, err := p.findEncryptionKey(sh, keyName, alg, pkcs11.CKO_PUBLIC_KEY)
+		if err != nil {
+			return err
+		}
+
+		mech, gcmParams, err := alg.Mechanism(nonce, associatedData)
+		if err != nil {
+			return err
+		}
+		defer gcmParams.Free()
+
+		obj, err := p.ctx.CreateObject(sh, secretKeyTemplate(pkcs11.NewAttribute(pkcs11.CKA_VALUE, plaintext)))
+		if err != nil {
+			return fmt.Errorf("failed to import key: %w", err)
+		}
+		defer p.destroyObject(sh, obj)
+
+		wrappedKey, err = p.ctx.WrapKey(sh, []*pkcs11.Mechanism{mech}, kek, obj)
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap key: %w", err)
+	}
+
+	if alg.IsGCM() {
+		return splitTag(wrappedKey)
+	}
+	return wrappedKey, nil, nil
+}
+
+// UnwrapKey unwraps a key.
+// The key is unwrapped by the token as a session object, whose value is then exported.
+func (p *pkcs11Crypto) UnwrapKey(_ context.Context, wrappedKey []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
+	alg, ok := encryptionAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	if alg.IsGCM() {
+		wrappedKey, err = appendTag(wrappedKey, tag)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var plaintext []byte
+	err = p.withSession(func(sh pkcs11.SessionHandle) error {
+		kek, err := p.findEncryptionKey(sh, keyName, alg, pkcs11.CKO_PRIVATE_KEY)
+		if err != nil {
+			return err
+		}
+
+		mech, gcmParams, err := alg.Mechanism(nonce, associatedData)
+		if err != nil {
+			return err
+		}
+		defer gcmParams.Free()
+
+		obj, err := p.ctx.UnwrapKey(sh, []*pkcs11.Mechanism{mech}, kek, wrappedKey, secretKeyTemplate())
+		if err != nil {
+			return err
+		}
+		defer p.destroyObject(sh, obj)
+
+		attrs, err := p.ctx.GetAttributeValue(sh, obj, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to export unwrapped key: %w", err)
+		}
+		plaintext = attrs[0].Value
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key: %w", err)
+	}
+
+	// We allow wrapping/unwrapping only symmetric keys, so no need to try and decode an ASN.1 DER-encoded sequence
+	plaintextKey, err = jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+
+	return plaintextKey, nil
+}
+
+// Sign a digest.
+// When using EdDSA, the full message must be passed as digest.
+func (p *pkcs11Crypto) Sign(_ context.Context, digest []byte, algorithm string, keyName string) (signature []byte, err error) {
+	alg, ok := signatureAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+	input, err := alg.Input(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.withSession(func(sh pkcs11.SessionHandle) error {
+		key, err := p.findKey(sh, keyName, pkcs11.CKO_PRIVATE_KEY)
+		if err != nil {
+			return err
+		}
+
+		err = p.ctx.SignInit(sh, []*pkcs11.Mechanism{alg.Mechanism()}, key)
+		if err != nil {
+			return fmt.Errorf("failed to initialize signing: %w", err)
+		}
+		signature, err = p.ctx.Sign(sh, input)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+
+	return alg.EncodeSignature(signature)
+}
+
+// Verify a signature.
+// When using EdDSA, the full message must be passed as digest.
+func (p *pkcs11Crypto) Verify(_ context.Context, digest []byte, signature []byte, algorithm string, keyName string) (valid bool, err error) {
+	alg, ok := signatureAlgorithms[algorithm]
+	if !ok {
+		return false, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+	input, err := alg.Input(digest)
+	if err != nil {
+		return false, err
+	}
+	signature, ok = alg.DecodeSignature(signature)
+	if !ok {
+		return false, nil
+	}
+
+	err = p.withSession(func(sh pkcs11.SessionHandle) error {
+		key, err := p.findKey(sh, keyName, pkcs11.CKO_PUBLIC_KEY)
+		if err != nil {
+			return err
+		}
+
+		err = p.ctx.VerifyInit(sh, []*pkcs11.Mechanism{alg.Mechanism()}, key)
+		if err != nil {
+			return fmt.Errorf("failed to initialize verification: %w", err)
+		}
+		return p.ctx.Verify(sh, input, signature)
+	})
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, pkcs11.Error(pkcs11.CKR_SIGNATURE_INVALID)),
+		errors.Is(err, pkcs11.Error(pkcs11.CKR_SIGNATURE_LEN_RANGE)):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to validate the signature: %w", err)
+	}
+}
+
+// withSession invokes fn with a new session, which is closed when fn returns.
+func (p *pkcs11Crypto) withSession(fn func(sh pkcs11.SessionHandle) error) error {
+	sh, err := p.ctx.OpenSession(p.slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+	defer func() {
+		cErr := p.ctx.CloseSession(sh)
+		if cErr != nil {
+			p.logger.Warnf("Failed to close PKCS#11 session: %v", cErr)
+		}
+	}()
+
+	return fn(sh)
+}
+
+// findKey returns the object of the given class with keyName as label.
+func (p *pkcs11Crypto) findKey(sh pkcs11.SessionHandle, keyName string, class uint) (pkcs11.ObjectHandle, error) {
+	err := p.ctx.FindObjectsInit(sh, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, keyName),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to search for key '%s': %w", keyName, err)
+	}
+	objs, _, err := p.ctx.FindObjects(sh, 1)
+	fErr := p.ctx.FindObjectsFinal(sh)
+	if err == nil {
+		err = fErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to search for key '%s': %w", keyName, err)
+	}
+	if len(objs) == 0 {
+		return 0, fmt.Errorf("key '%s': %w", keyName, contribCrypto.ErrKeyNotFound)
+	}
+	return objs[0], nil
+}
+
+// findEncryptionKey returns the key to use with an encryption algorithm.
+// Asymmetric algorithms use the key of class asymmetricClass, while symmetric ones use a secret key of the size required by the algorithm.
+func (p *pkcs11Crypto) findEncryptionKey(sh pkcs11.SessionHandle, keyName string, alg encryptionAlgorithm, asymmetricClass uint) (pkcs11.ObjectHandle, error) {
+	if alg.IsAsymmetric() {
+		return p.findKey(sh, keyName, asymmetricClass)
+	}
+
+	key, err := p.findKey(sh, keyName, pkcs11.CKO_SECRET_KEY)
+	if err != nil {
+		return 0, err
+	}
+	attrs, err := p.ctx.GetAttributeValue(sh, key, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, nil),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get size of key '%s': %w", keyName, err)
+	}
+	size, err := attributeUint(attrs[0].Value)
+	if err != nil || size != uint(alg.keySize) {
+		return 0, errors.New("key cannot be used with this algorithm")
+	}
+	return key, nil
+}
+
+func (p *pkcs11Crypto) destroyObject(sh pkcs11.SessionHandle, obj pkcs11.ObjectHandle) {
+	err := p.ctx.DestroyObject(sh, obj)
+	if err != nil {
+		p.logger.Warnf("Failed to destroy PKCS#11 session object: %v", err)
+	}
+}
+
+// secretKeyTemplate returns the template of the session objects used for keys that are wrapped or unwrapped.
+func secretKeyTemplate(attrs ...*pkcs11.Attribute) []*pkcs11.Attribute {
+	return append([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_GENERIC_SECRET),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, true),
+	}, attrs...)
+}
+
+// splitTag splits the authentication tag that PKCS#11 appends to AES-GCM ciphertexts.
+func splitTag(ciphertext []byte) ([]byte, []byte, error) {
+	if len(ciphertext) < gcmTagSize {
+		return nil, nil, internals.ErrInvalidCiphertextLength
+	}
+	n := len(ciphertext) - gcmTagSize
+	return ciphertext[:n], ciphertext[n:], nil
+}
+
+// appendTag appends the authentication tag to an AES-GCM ciphertext, as expected by PKCS#11.
+func appendTag(ciphertext []byte, tag []byte) ([]byte, error) {
+	if len(tag) != gcmTagSize {
+		return nil, internals.ErrInvalidTag
+	}
+	res := make([]byte, len(ciphertext)+len(tag))
+	copy(res, ciphertext)
+	copy(res[len(ciphertext):], tag)
+	return res, nil
+}
+
+func (p *pkcs11Crypto) Close() error {
+	if p.ctx == nil {
+		return nil
+	}
+
+	// Logging out is not needed as it happens when the last session is closed
+	err := p.ctx.CloseSession(p.loginSession)
+	releaseModule(p.md.Module)
+	p.ctx = nil
+	return err
+}
+
+func (pkcs11Crypto) SupportedEncryptionAlgorithms() []string {
+	return encryptionAlgsList
+}
+
+func (pkcs11Crypto) SupportedSignatureAlgorithms() []string {
+	return signatureAlgsList
+}
+
+func (pkcs11Crypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := pkcs11Metadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
+	return
+}
@@ -0,0 +1,96 @@
+//go:build !cgo
+// +build !cgo
+
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// errNoCgo is returned by all operations when the component is built without cgo, which is required to load PKCS#11 modules.
+var errNoCgo = errors.New("PKCS#11 requires cgo: this binary was built with CGO_ENABLED=0")
+
+// pkcs11Crypto is a placeholder for the PKCS#11 crypto provider in builds without cgo.
+type pkcs11Crypto struct{}
+
+// NewPKCS11Crypto returns a new PKCS#11 crypto provider.
+// Without cgo, the provider fails to initialize.
+func NewPKCS11Crypto(logger logger.Logger) contribCrypto.SubtleCrypto {
+	return &pkcs11Crypto{}
+}
+
+// Init returns an error, as PKCS#11 requires cgo.
+func (p *pkcs11Crypto) Init(_ context.Context, metadata contribCrypto.Metadata) error {
+	return errNoCgo
+}
+
+func (p *pkcs11Crypto) Features() []contribCrypto.Feature {
+	return []contribCrypto.Feature{} // No Feature supported.
+}
+
+func (p *pkcs11Crypto) GetKey(_ context.Context, keyName string) (pubKey jwk.Key, err error) {
+	return nil, errNoCgo
+}
+
+func (p *pkcs11Crypto) Encrypt(_ context.Context, plaintext []byte, algorithm string, keyName string, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	return nil, nil, errNoCgo
+}
+
+func (p *pkcs11Crypto) Decrypt(_ context.Context, ciphertext []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
+	return nil, errNoCgo
+}
+
+func (p *pkcs11Crypto) WrapKey(_ context.Context, plaintextKey jwk.Key, algorithm string, keyName string, nonce []byte, associatedData []byte) (wrappedKey []byte, tag []byte, err error) {
+	return nil, nil, errNoCgo
+}
+
+func (p *pkcs11Crypto) UnwrapKey(_ context.Context, wrappedKey []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
+	return nil, errNoCgo
+}
+
+func (p *pkcs11Crypto) Sign(_ context.Context, digest []byte, algorithm string, keyName string) (signature []byte, err error) {
+	return nil, errNoCgo
+}
+
+func (p *pkcs11Crypto) Verify(_ context.Context, digest []byte, signature []byte, algorithm string, keyName string) (valid bool, err error) {
+	return false, errNoCgo
+}
+
+func (p *pkcs11Crypto) Close() error {
+	return nil
+}
+
+func (pkcs11Crypto) SupportedEncryptionAlgorithms() []string {
+	return []string{}
+}
+
+func (pkcs11Crypto) SupportedSignatureAlgorithms() []string {
+	return []string{}
+}
+
+func (pkcs11Crypto) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := pkcs11Metadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
+	return
+}
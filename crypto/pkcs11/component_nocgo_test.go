@@ -0,0 +1,40 @@
+//go:build !cgo
+// +build !cgo
+
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func TestInitWithoutCgo(t *testing.T) {
+	c := NewPKCS11Crypto(logger.NewLogger("test"))
+	err := c.Init(context.Background(), contribCrypto.Metadata{Base: contribMetadata.Base{
+		Properties: map[string]string{
+			"module": "/usr/lib/softhsm/libsofthsm2.so",
+			"pin":    "1234",
+		},
+	}})
+	require.ErrorContains(t, err, "PKCS#11 requires cgo")
+	require.NoError(t, c.Close())
+}
@@ -0,0 +1,421 @@
+//go:build cgo
+// +build cgo
+
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	testTokenLabel = "dapr-test"
+	testPIN        = "1234"
+)
+
+// DER-encoded OID of the P-256 curve.
+var oidP256 = []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}
+
+// softHSMModule returns the path to the SoftHSM2 module, skipping the test if it's not installed.
+// The path can be set with the SOFTHSM2_MODULE environmental variable.
+func softHSMModule(t *testing.T) string {
+	t.Helper()
+
+	candidates := []string{
+		os.Getenv("SOFTHSM2_MODULE"),
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/lib/aarch64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+		"/usr/lib64/pkcs11/libsofthsm2.so",
+	}
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+
+	t.Skip("SoftHSM2 is not installed; set SOFTHSM2_MODULE to the path of libsofthsm2.so")
+	return ""
+}
+
+// setupToken creates a SoftHSM2 token in a temporary folder and generates the keys used by the tests:
+// "aes" (AES-256), "rsa" (RSA-2048) and "ec" (ECDSA P-256).
+func setupToken(t *testing.T, module string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "softhsm2.conf")
+	err := os.WriteFile(conf, []byte("directories.tokendir = "+dir+"\nobjectstore.backend = file\nlog.level = ERROR\n"), 0o600)
+	require.NoError(t, err)
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	ctx := pkcs11.New(module)
+	require.NotNil(t, ctx)
+	require.NoError(t, ctx.Initialize())
+	defer func() {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+	}()
+
+	// SoftHSM2 always has a slot with an uninitialized token
+	slots, err := ctx.GetSlotList(true)
+	require.NoError(t, err)
+	require.NotEmpty(t, slots)
+	require.NoError(t, ctx.InitToken(slots[0], "5678", testTokenLabel))
+
+	// The token is moved to a new slot once initialized
+	slots, err = ctx.GetSlotList(true)
+	require.NoError(t, err)
+	var slot uint
+	for _, s := range slots {
+		info, err := ctx.GetTokenInfo(s)
+		require.NoError(t, err)
+		if info.Label == testTokenLabel {
+			slot = s
+		}
+	}
+
+	sh, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	require.NoError(t, err)
+	defer func() {
+		_ = ctx.CloseSession(sh)
+	}()
+	require.NoError(t, ctx.Login(sh, pkcs11.CKU_SO, "5678"))
+	require.NoError(t, ctx.InitPIN(sh, testPIN))
+	require.NoError(t, ctx.Logout(sh))
+	require.NoError(t, ctx.Login(sh, pkcs11.CKU_USER, testPIN))
+
+	_, err = ctx.GenerateKey(sh,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_KEY_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "aes"),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, 32),
+			pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_WRAP, true),
+			pkcs11.NewAttribute(pkcs11.CKA_UNWRAP, true),
+		},
+	)
+	require.NoError(t, err)
+
+	_, _, err = ctx.GenerateKeyPair(sh,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "rsa"),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+			pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_WRAP, true),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "rsa"),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_UNWRAP, true),
+		},
+	)
+	require.NoError(t, err)
+
+	_, _, err = ctx.GenerateKeyPair(sh,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "ec"),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, oidP256),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "ec"),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		},
+	)
+	require.NoError(t, err)
+}
+
+func newTestComponent(t *testing.T, props map[string]string) (contribCrypto.SubtleCrypto, error) {
+	t.Helper()
+
+	p := NewPKCS11Crypto(logger.NewLogger("test"))
+	err := p.Init(context.Background(), contribCrypto.Metadata{Base: contribMetadata.Base{
+		Properties: props,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close())
+	})
+	return p, nil
+}
+
+func TestPKCS11Crypto(t *testing.T) {
+	module := softHSMModule(t)
+	setupToken(t, module)
+
+	ctx := context.Background()
+	p, err := newTestComponent(t, map[string]string{
+		"module":     module,
+		"tokenLabel": testTokenLabel,
+		"pin":        testPIN,
+	})
+	require.NoError(t, err)
+
+	message := []byte("hello world")
+	digest := sha256.Sum256(message)
+	gcmNonce := []byte("123456789012")
+	cbcNonce := []byte("1234567890123456")
+
+	t.Run("get key", func(t *testing.T) {
+		key, err := p.GetKey(ctx, "rsa")
+		require.NoError(t, err)
+		assert.Equal(t, "rsa", key.KeyID())
+		var rsaKey rsa.PublicKey
+		require.NoError(t, key.Raw(&rsaKey))
+		assert.Equal(t, 2048, rsaKey.N.BitLen())
+
+		key, err = p.GetKey(ctx, "ec")
+		require.NoError(t, err)
+		var ecKey ecdsa.PublicKey
+		require.NoError(t, key.Raw(&ecKey))
+		assert.Equal(t, "P-256", ecKey.Curve.Params().Name)
+
+		// Symmetric and missing keys are not found
+		_, err = p.GetKey(ctx, "aes")
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+		_, err = p.GetKey(ctx, "missing")
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+	})
+
+	t.Run("encrypt and decrypt with AES-GCM", func(t *testing.T) {
+		ciphertext, tag, err := p.Encrypt(ctx, message, internals.Algorithm_A256GCM, "aes", gcmNonce, []byte("aad"))
+		require.NoError(t, err)
+		assert.Len(t, tag, 16)
+
+		plaintext, err := p.Decrypt(ctx, ciphertext, internals.Algorithm_A256GCM, "aes", gcmNonce, tag, []byte("aad"))
+		require.NoError(t, err)
+		assert.Equal(t, message, plaintext)
+
+		_, err = p.Decrypt(ctx, ciphertext, internals.Algorithm_A256GCM, "aes", gcmNonce, tag, []byte("other"))
+		require.Error(t, err)
+	})
+
+	t.Run("encrypt and decrypt with AES-CBC", func(t *testing.T) {
+		ciphertext, tag, err := p.Encrypt(ctx, message, internals.Algorithm_A256CBC, "aes", cbcNonce, nil)
+		require.NoError(t, err)
+		assert.Nil(t, tag)
+
+		plaintext, err := p.Decrypt(ctx, ciphertext, internals.Algorithm_A256CBC, "aes", cbcNonce, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, message, plaintext)
+	})
+
+	t.Run("algorithm does not match key size", func(t *testing.T) {
+		_, _, err := p.Encrypt(ctx, message, internals.Algorithm_A128GCM, "aes", gcmNonce, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("encrypt with public key and decrypt in the token", func(t *testing.T) {
+		pk, err := p.GetKey(ctx, "rsa")
+		require.NoError(t, err)
+		ciphertext, err := internals.EncryptPublicKey(message, internals.Algorithm_RSA_OAEP, pk, nil)
+		require.NoError(t, err)
+
+		plaintext, err := p.Decrypt(ctx, ciphertext, internals.Algorithm_RSA_OAEP, "rsa", nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, message, plaintext)
+
+		ciphertext, _, err = p.Encrypt(ctx, message, internals.Algorithm_RSA_OAEP, "rsa", nil, nil)
+		require.NoError(t, err)
+		plaintext, err = p.Decrypt(ctx, ciphertext, internals.Algorithm_RSA_OAEP, "rsa", nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, message, plaintext)
+	})
+
+	t.Run("wrap and unwrap keys", func(t *testing.T) {
+		dek, err := jwk.FromRaw([]byte("0123456789abcdef0123456789abcdef"))
+		require.NoError(t, err)
+
+		for _, tc := range []struct{ alg, key string }{
+			{internals.Algorithm_A256KW, "aes"},
+			{internals.Algorithm_RSA_OAEP, "rsa"},
+		} {
+			wrapped, tag, err := p.WrapKey(ctx, dek, tc.alg, tc.key, nil, nil)
+			require.NoError(t, err, tc.alg)
+
+			unwrapped, err := p.UnwrapKey(ctx, wrapped, tc.alg, tc.key, nil, tag, nil)
+			require.NoError(t, err, tc.alg)
+			var raw []byte
+			require.NoError(t, unwrapped.Raw(&raw))
+			assert.Equal(t, "0123456789abcdef0123456789abcdef", string(raw), tc.alg)
+		}
+
+		_, _, err = p.WrapKey(ctx, dek, internals.Algorithm_A256KW, "missing", nil, nil)
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+	})
+
+	t.Run("sign and verify", func(t *testing.T) {
+		for _, tc := range []struct{ alg, key string }{
+			{internals.Algorithm_RS256, "rsa"},
+			{internals.Algorithm_PS256, "rsa"},
+			{internals.Algorithm_ES256, "ec"},
+		} {
+			signature, err := p.Sign(ctx, digest[:], tc.alg, tc.key)
+			require.NoError(t, err, tc.alg)
+
+			valid, err := p.Verify(ctx, digest[:], signature, tc.alg, tc.key)
+			require.NoError(t, err, tc.alg)
+			assert.True(t, valid, tc.alg)
+
+			// Signatures can be verified with the public key outside of the token
+			pk, err := p.GetKey(ctx, tc.key)
+			require.NoError(t, err)
+			valid, err = internals.VerifyPublicKey(digest[:], signature, tc.alg, pk)
+			require.NoError(t, err, tc.alg)
+			assert.True(t, valid, tc.alg)
+
+			other := sha256.Sum256([]byte("other message"))
+			valid, err = p.Verify(ctx, other[:], signature, tc.alg, tc.key)
+			require.NoError(t, err, tc.alg)
+			assert.False(t, valid, tc.alg)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, _, err := p.Encrypt(ctx, message, "XYZ", "aes", nil, nil)
+		require.ErrorContains(t, err, "invalid algorithm")
+		_, err = p.Sign(ctx, message, internals.Algorithm_ES256, "ec")
+		require.ErrorContains(t, err, "invalid length")
+		_, _, err = p.Encrypt(ctx, message, internals.Algorithm_A256GCM, "aes", []byte("short"), nil)
+		require.ErrorIs(t, err, internals.ErrInvalidNonce)
+	})
+}
+
+func TestInit(t *testing.T) {
+	t.Run("missing properties", func(t *testing.T) {
+		_, err := newTestComponent(t, map[string]string{
+			"tokenLabel": testTokenLabel,
+			"pin":        testPIN,
+		})
+		require.ErrorContains(t, err, "'module' is required")
+
+		_, err = newTestComponent(t, map[string]string{
+			"module": os.Args[0],
+			"pin":    testPIN,
+		})
+		require.ErrorContains(t, err, "'tokenLabel' and 'slotID'")
+
+		_, err = newTestComponent(t, map[string]string{
+			"module":     os.Args[0],
+			"tokenLabel": testTokenLabel,
+		})
+		require.ErrorContains(t, err, "'pin' is required")
+	})
+
+	t.Run("token errors", func(t *testing.T) {
+		module := softHSMModule(t)
+		setupToken(t, module)
+
+		_, err := newTestComponent(t, map[string]string{
+			"module":     module,
+			"tokenLabel": "missing",
+			"pin":        testPIN,
+		})
+		require.ErrorContains(t, err, "could not find a token")
+
+		_, err = newTestComponent(t, map[string]string{
+			"module":     module,
+			"tokenLabel": testTokenLabel,
+			"pin":        "0000",
+		})
+		require.ErrorContains(t, err, "failed to log into the token")
+
+		// The module is released when initialization fails
+		assert.Empty(t, modules)
+	})
+}
+
+func TestSignatureEncoding(t *testing.T) {
+	alg := signatureAlgorithms[internals.Algorithm_ES256]
+
+	raw := make([]byte, 64)
+	raw[31] = 1
+	raw[63] = 2
+	encoded, err := alg.EncodeSignature(raw)
+	require.NoError(t, err)
+
+	decoded, ok := alg.DecodeSignature(encoded)
+	require.True(t, ok)
+	assert.Equal(t, raw, decoded)
+
+	_, ok = alg.DecodeSignature([]byte("not asn.1"))
+	assert.False(t, ok)
+
+	// RSA signatures are not converted
+	rs := signatureAlgorithms[internals.Algorithm_RS256]
+	decoded, ok = rs.DecodeSignature([]byte("signature"))
+	require.True(t, ok)
+	assert.Equal(t, "signature", string(decoded))
+
+	input, err := rs.Input(make([]byte, 32))
+	require.NoError(t, err)
+	assert.Len(t, input, 19+32)
+	_, err = rs.Input(make([]byte, 20))
+	require.Error(t, err)
+}
+
+func TestGCMTag(t *testing.T) {
+	ciphertext, tag, err := splitTag([]byte("message" + "0123456789abcdef"))
+	require.NoError(t, err)
+	assert.Equal(t, "message", string(ciphertext))
+	assert.Equal(t, "0123456789abcdef", string(tag))
+
+	joined, err := appendTag(ciphertext, tag)
+	require.NoError(t, err)
+	assert.Equal(t, "message0123456789abcdef", string(joined))
+
+	_, _, err = splitTag([]byte("short"))
+	require.ErrorIs(t, err, internals.ErrInvalidCiphertextLength)
+	_, err = appendTag(ciphertext, []byte("short"))
+	require.ErrorIs(t, err, internals.ErrInvalidTag)
+}
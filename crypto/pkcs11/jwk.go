@@ -0,0 +1,158 @@
+//go:build cgo
+// +build cgo
+
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/miekg/pkcs11"
+)
+
+// OID of EC public keys in SubjectPublicKeyInfo structures, from RFC 5480.
+var oidPublicKeyEC = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+// publicKeyAttributes returns the attributes of public key objects of the given type that are needed to export the key.
+func publicKeyAttributes(keyType uint) ([]*pkcs11.Attribute, error) {
+	switch keyType {
+	case pkcs11.CKK_RSA:
+		return []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		}, nil
+	case pkcs11.CKK_EC:
+		return []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		}, nil
+	case ckkECEdwards:
+		return []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type: %d", keyType)
+}
+
+// PublicKey returns a crypto.PublicKey from the attributes of a PKCS#11 public key object of the given type.
+func PublicKey(keyType uint, attrs []*pkcs11.Attribute) (crypto.PublicKey, error) {
+	values := make(map[uint][]byte, len(attrs))
+	for _, a := range attrs {
+		values[a.Type] = a.Value
+	}
+
+	switch keyType {
+	case pkcs11.CKK_RSA:
+		return publicRSA(values[pkcs11.CKA_MODULUS], values[pkcs11.CKA_PUBLIC_EXPONENT])
+	case pkcs11.CKK_EC:
+		return publicEC(values[pkcs11.CKA_EC_PARAMS], values[pkcs11.CKA_EC_POINT])
+	case ckkECEdwards:
+		return publicEd25519(values[pkcs11.CKA_EC_POINT])
+	}
+
+	return nil, fmt.Errorf("unsupported key type: %d", keyType)
+}
+
+func publicRSA(modulus []byte, exponent []byte) (*rsa.PublicKey, error) {
+	if len(modulus) == 0 {
+		return nil, errors.New("attribute CKA_MODULUS is empty")
+	}
+	if len(exponent) == 0 || len(exponent) > 4 {
+		return nil, errors.New("attribute CKA_PUBLIC_EXPONENT is empty or invalid")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
+
+// publicEC builds a SubjectPublicKeyInfo structure from the curve's OID and the point, so they are validated by the x509 package.
+func publicEC(params []byte, point []byte) (crypto.PublicKey, error) {
+	if len(params) == 0 {
+		return nil, errors.New("attribute CKA_EC_PARAMS is empty")
+	}
+	point, err := ecPoint(point)
+	if err != nil {
+		return nil, err
+	}
+
+	spki, err := asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyEC,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: len(point) * 8},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode EC public key: %w", err)
+	}
+
+	pk, err := x509.ParsePKIXPublicKey(spki)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse EC public key: %w", err)
+	}
+	return pk, nil
+}
+
+func publicEd25519(point []byte) (ed25519.PublicKey, error) {
+	point, err := ecPoint(point)
+	if err != nil {
+		return nil, err
+	}
+	if len(point) != ed25519.PublicKeySize {
+		return nil, errors.New("attribute CKA_EC_POINT has an invalid length")
+	}
+	return ed25519.PublicKey(point), nil
+}
+
+// ecPoint returns the value of the CKA_EC_POINT attribute.
+// The attribute should be a DER-encoded OCTET STRING, but some tokens return the raw point.
+func ecPoint(value []byte) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, errors.New("attribute CKA_EC_POINT is empty")
+	}
+	var point []byte
+	rest, err := asn1.Unmarshal(value, &point)
+	if err != nil || len(rest) > 0 {
+		return value, nil
+	}
+	return point, nil
+}
+
+// attributeUint decodes a CK_ULONG attribute, which uses the native byte order and size.
+func attributeUint(value []byte) (uint, error) {
+	switch len(value) {
+	case 4:
+		return uint(binary.NativeEndian.Uint32(value)), nil
+	case 8:
+		return uint(binary.NativeEndian.Uint64(value)), nil
+	default:
+		return 0, fmt.Errorf("attribute has an invalid length: %d", len(value))
+	}
+}
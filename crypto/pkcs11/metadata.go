@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/kit/metadata"
+)
+
+type pkcs11Metadata struct {
+	// Path to the PKCS#11 module (shared library) of the HSM, such as "/usr/lib/softhsm/libsofthsm2.so" (required).
+	Module string `json:"module" mapstructure:"module"`
+
+	// Label of the token that contains the keys.
+	// Either this or slotID is required.
+	TokenLabel string `json:"tokenLabel" mapstructure:"tokenLabel"`
+
+	// ID of the slot that contains the keys.
+	// Either this or tokenLabel is required.
+	SlotID *uint `json:"slotID" mapstructure:"slotID"`
+
+	// PIN of the user of the token (required).
+	PIN string `json:"pin" mapstructure:"pin"`
+}
+
+func (m *pkcs11Metadata) InitWithMetadata(meta contribCrypto.Metadata) error {
+	m.reset()
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+
+	// Module
+	if m.Module == "" {
+		return errors.New("metadata property 'module' is required")
+	}
+	_, err = os.Stat(m.Module)
+	if err != nil {
+		return fmt.Errorf("could not stat module '%s': %w", m.Module, err)
+	}
+
+	// Token
+	if m.TokenLabel == "" && m.SlotID == nil {
+		return errors.New("one of the metadata properties 'tokenLabel' and 'slotID' is required")
+	}
+
+	// PIN
+	if m.PIN == "" {
+		return errors.New("metadata property 'pin' is required")
+	}
+
+	return nil
+}
+
+// Reset the object
+func (m *pkcs11Metadata) reset() {
+	m.Module = ""
+	m.TokenLabel = ""
+	m.SlotID = nil
+	m.PIN = ""
+}
@@ -0,0 +1,80 @@
+//go:build cgo
+// +build cgo
+
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// A PKCS#11 module can be initialized only once per process, so it's shared by all components that use it.
+var (
+	modules     = map[string]*module{}
+	modulesLock sync.Mutex
+)
+
+type module struct {
+	ctx  *pkcs11.Ctx
+	refs int
+}
+
+// loadModule returns the context of an initialized PKCS#11 module, loading it if needed.
+// Each call must be matched by a call to releaseModule.
+func loadModule(path string) (*pkcs11.Ctx, error) {
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
+	m, ok := modules[path]
+	if ok {
+		m.refs++
+		return m.ctx, nil
+	}
+
+	ctx := pkcs11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module '%s'", path)
+	}
+	err := ctx.Initialize()
+	if err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module '%s': %w", path, err)
+	}
+
+	modules[path] = &module{ctx: ctx, refs: 1}
+	return ctx, nil
+}
+
+// releaseModule finalizes and unloads a PKCS#11 module when it's not used anymore.
+func releaseModule(path string) {
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
+	m, ok := modules[path]
+	if !ok {
+		return
+	}
+	m.refs--
+	if m.refs > 0 {
+		return
+	}
+
+	delete(modules, path)
+	_ = m.ctx.Finalize()
+	m.ctx.Destroy()
+}
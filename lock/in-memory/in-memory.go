@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// In-memory lock store.
+// Locks are shared only within the current process.
+type inMemoryLock struct {
+	locks   map[string]*lockItem
+	lock    sync.Mutex
+	logger  logger.Logger
+	clock   clock.Clock
+	closeCh chan struct{}
+	closed  atomic.Bool
+	wg      sync.WaitGroup
+}
+
+type lockItem struct {
+	owner  string
+	expire *time.Time
+}
+
+func (item *lockItem) isExpired(now time.Time) bool {
+	if item == nil || item.expire == nil {
+		return false
+	}
+	return !now.Before(*item.expire)
+}
+
+// NewInMemoryLock returns a new in-memory lock store.
+func NewInMemoryLock(logger logger.Logger) lock.Store {
+	return newInMemoryLock(logger)
+}
+
+func newInMemoryLock(logger logger.Logger) *inMemoryLock {
+	return &inMemoryLock{
+		locks:   map[string]*lockItem{},
+		logger:  logger,
+		clock:   clock.RealClock{},
+		closeCh: make(chan struct{}),
+	}
+}
+
+// InitLockStore initializes the lock store.
+func (l *inMemoryLock) InitLockStore(ctx context.Context, metadata lock.Metadata) error {
+	if l.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	// Start a background goroutine that removes expired locks
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		l.startCleanThread()
+	}()
+	return nil
+}
+
+// TryLock tries to acquire a lock.
+// If the lock cannot be acquired, it returns immediately.
+func (l *inMemoryLock) TryLock(ctx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.clock.Now()
+	item := l.locks[req.ResourceID]
+	if item != nil && !item.isExpired(now) {
+		return &lock.TryLockResponse{
+			Success: false,
+		}, nil
+	}
+
+	item = &lockItem{
+		owner: req.LockOwner,
+	}
+	if req.ExpiryInSeconds > 0 {
+		expire := now.Add(time.Duration(req.ExpiryInSeconds) * time.Second)
+		item.expire = &expire
+	}
+	l.locks[req.ResourceID] = item
+
+	return &lock.TryLockResponse{
+		Success: true,
+	}, nil
+}
+
+// Unlock tries to release a lock if the lock is still valid.
+func (l *inMemoryLock) Unlock(ctx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	item := l.locks[req.ResourceID]
+	var status lock.Status
+	switch {
+	case item == nil:
+		status = lock.LockDoesNotExist
+	case item.isExpired(l.clock.Now()):
+		delete(l.locks, req.ResourceID)
+		status = lock.LockDoesNotExist
+	case item.owner != req.LockOwner:
+		status = lock.LockBelongsToOthers
+	default:
+		delete(l.locks, req.ResourceID)
+		status = lock.Success
+	}
+
+	return &lock.UnlockResponse{
+		Status: status,
+	}, nil
+}
+
+// Close stops the background goroutine and releases all locks.
+func (l *inMemoryLock) Close() error {
+	if l.closed.CompareAndSwap(false, true) {
+		close(l.closeCh)
+	}
+	l.wg.Wait()
+
+	l.lock.Lock()
+	clear(l.locks)
+	l.lock.Unlock()
+
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (l *inMemoryLock) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	// no metadata, hence no metadata struct to convert here
+	return
+}
+
+func (l *inMemoryLock) startCleanThread() {
+	for {
+		select {
+		case <-l.clock.After(time.Second):
+			l.doCleanExpiredLocks()
+		case <-l.closeCh:
+			return
+		}
+	}
+}
+
+func (l *inMemoryLock) doCleanExpiredLocks() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.clock.Now()
+	for resourceID, item := range l.locks {
+		if item.isExpired(now) {
+			delete(l.locks, resourceID)
+		}
+	}
+}
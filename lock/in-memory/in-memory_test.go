@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/kit/logger"
+)
+
+const resourceID = "resource_xxx"
+
+func TestInMemoryLock(t *testing.T) {
+	// 0. prepare
+	comp := newInMemoryLock(logger.NewLogger("test"))
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	comp.clock = fakeClock
+	defer comp.Close()
+
+	err := comp.InitLockStore(context.Background(), lock.Metadata{})
+	require.NoError(t, err)
+
+	tryLock := func(owner string, expiry int32) bool {
+		t.Helper()
+		resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+			ResourceID:      resourceID,
+			LockOwner:       owner,
+			ExpiryInSeconds: expiry,
+		})
+		require.NoError(t, err)
+		return resp.Success
+	}
+	unlock := func(owner string) lock.Status {
+		t.Helper()
+		resp, err := comp.Unlock(context.Background(), &lock.UnlockRequest{
+			ResourceID: resourceID,
+			LockOwner:  owner,
+		})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	// 1. unlock a lock that does not exist
+	assert.Equal(t, lock.LockDoesNotExist, unlock("owner1"))
+
+	// 2. owner1 acquires the lock, owner2 fails
+	assert.True(t, tryLock("owner1", 10))
+	assert.False(t, tryLock("owner2", 10))
+
+	// 3. owner2 can't release the lock of owner1
+	assert.Equal(t, lock.LockBelongsToOthers, unlock("owner2"))
+
+	// 4. owner1 releases the lock, then owner2 acquires it
+	assert.Equal(t, lock.Success, unlock("owner1"))
+	assert.True(t, tryLock("owner2", 10))
+
+	// 5. the lock expires, then owner1 acquires it
+	fakeClock.Step(10 * time.Second)
+	assert.True(t, tryLock("owner1", 10))
+	assert.Equal(t, lock.LockBelongsToOthers, unlock("owner2"))
+
+	// 6. an expired lock does not exist anymore
+	fakeClock.Step(10 * time.Second)
+	assert.Equal(t, lock.LockDoesNotExist, unlock("owner1"))
+}
+
+func TestInMemoryLockCleanExpired(t *testing.T) {
+	comp := newInMemoryLock(logger.NewLogger("test"))
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	comp.clock = fakeClock
+	defer comp.Close()
+
+	for _, r := range []string{"r1", "r2"} {
+		resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+			ResourceID:      r,
+			LockOwner:       "owner",
+			ExpiryInSeconds: 5,
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success)
+	}
+	resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID:      "r3",
+		LockOwner:       "owner",
+		ExpiryInSeconds: 60,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+
+	fakeClock.Step(5 * time.Second)
+	comp.doCleanExpiredLocks()
+
+	comp.lock.Lock()
+	defer comp.lock.Unlock()
+	assert.Len(t, comp.locks, 1)
+	assert.Contains(t, comp.locks, "r3")
+}
@@ -26,7 +26,14 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
-const unlockScript = `local v = redis.call("get",KEYS[1]); if v==false then return -1 end; if v~=ARGV[1] then return -2 else return redis.call("del",KEYS[1]) end`
+const (
+	unlockScript = `local v = redis.call("get",KEYS[1]); if v==false then return -1 end; if v~=ARGV[1] then return -2 else return redis.call("del",KEYS[1]) end`
+	renewScript  = `local v = redis.call("get",KEYS[1]); if v==false then return -1 end; if v~=ARGV[1] then return -2 else return redis.call("expire",KEYS[1],ARGV[2]) end`
+	infoScript   = `local v = redis.call("get",KEYS[1]); if v==false then return {} end; return {v, redis.call("pttl",KEYS[1])}`
+)
+
+// Interval between attempts to acquire a lock in blocking mode.
+const lockPollInterval = 100 * time.Millisecond
 
 // Standalone Redis lock store.
 // Any fail-over related features are not supported, such as Sentinel and Redis Cluster.
@@ -131,6 +138,121 @@ func (r *StandaloneRedisLock) Unlock(ctx context.Context, req *lock.UnlockReques
 	}, nil
 }
 
+// RenewLock extends the expiration of a lock if it's still owned by the caller.
+func (r *StandaloneRedisLock) RenewLock(ctx context.Context, req *lock.RenewLockRequest) (*lock.RenewLockResponse, error) {
+	if req.ExpiryInSeconds <= 0 {
+		return &lock.RenewLockResponse{
+			Status: lock.InternalError,
+		}, errors.New("expiryInSeconds must be greater than zero")
+	}
+
+	// Delegate to client.eval lua script
+	evalInt, parseErr, err := r.client.EvalInt(ctx, renewScript, []string{req.ResourceID}, req.LockOwner, req.ExpiryInSeconds)
+	if evalInt == nil {
+		res := &lock.RenewLockResponse{
+			Status: lock.InternalError,
+		}
+		return res, errors.New("eval renew script returned a nil response")
+	}
+
+	// Parse result
+	if parseErr != nil {
+		return &lock.RenewLockResponse{
+			Status: lock.InternalError,
+		}, err
+	}
+	var status lock.Status
+	switch {
+	case *evalInt == 1:
+		status = lock.Success
+	case *evalInt == -1:
+		status = lock.LockDoesNotExist
+	case *evalInt == -2:
+		status = lock.LockBelongsToOthers
+	default:
+		status = lock.InternalError
+	}
+
+	return &lock.RenewLockResponse{
+		Status: status,
+	}, nil
+}
+
+// Lock acquires a lock, waiting until it's released by its current owner or until the wait timeout expires.
+func (r *StandaloneRedisLock) Lock(ctx context.Context, req *lock.LockRequest) (*lock.LockResponse, error) {
+	if req.WaitTimeoutInSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.WaitTimeoutInSeconds)*time.Second)
+		defer cancel()
+	}
+
+	tryReq := &lock.TryLockRequest{
+		ResourceID:      req.ResourceID,
+		LockOwner:       req.LockOwner,
+		ExpiryInSeconds: req.ExpiryInSeconds,
+	}
+	t := time.NewTicker(lockPollInterval)
+	defer t.Stop()
+	for {
+		res, err := r.TryLock(ctx, tryReq)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// The wait timeout expired while the request was in progress
+			return &lock.LockResponse{}, nil
+		case err != nil:
+			return &lock.LockResponse{}, err
+		case res.Success:
+			return &lock.LockResponse{
+				Success: true,
+			}, nil
+		}
+
+		select {
+		case <-t.C:
+			// Try again
+		case <-ctx.Done():
+			return &lock.LockResponse{}, nil
+		}
+	}
+}
+
+// GetLockInfo returns the owner of a lock and its remaining time to live.
+func (r *StandaloneRedisLock) GetLockInfo(ctx context.Context, req *lock.GetLockInfoRequest) (*lock.GetLockInfoResponse, error) {
+	res, err := r.client.DoRead(ctx, "EVAL", infoScript, 1, req.ResourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock info: %w", err)
+	}
+
+	vals, ok := res.([]any)
+	if !ok {
+		return nil, fmt.Errorf("eval info script returned an unexpected response of type %T", res)
+	}
+	if len(vals) == 0 {
+		return &lock.GetLockInfoResponse{}, nil
+	}
+	if len(vals) != 2 {
+		return nil, fmt.Errorf("eval info script returned %d values", len(vals))
+	}
+	owner, ok := vals[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("eval info script returned an owner of type %T", vals[0])
+	}
+	pttl, ok := vals[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("eval info script returned a TTL of type %T", vals[1])
+	}
+
+	info := &lock.GetLockInfoResponse{
+		Exists:    true,
+		LockOwner: owner,
+	}
+	if pttl > 0 {
+		// Round up, so locks that are about to expire don't appear to have no expiration
+		info.RemainingTTLInSeconds = int32((time.Duration(pttl)*time.Millisecond + time.Second - 1) / time.Second)
+	}
+	return info, nil
+}
+
 // Close shuts down the client's redis connections.
 func (r *StandaloneRedisLock) Close() error {
 	if r.client != nil {
@@ -16,6 +16,7 @@ package redis
 import (
 	"context"
 	"testing"
+	"time"
 
 	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
@@ -147,3 +148,234 @@ func TestStandaloneRedisLock_TryLock(t *testing.T) {
 	require.NoError(t, err)
 	assert.EqualValues(t, 0, unlockResp.Status, "client2 failed to unlock!")
 }
+
+func TestStandaloneRedisLock_RenewLock(t *testing.T) {
+	// 0. prepare
+	// start redis
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Construct component
+	comp := NewStandaloneRedisLock(logger.NewLogger("test")).(*StandaloneRedisLock)
+	defer comp.Close()
+
+	cfg := lock.Metadata{Base: metadata.Base{
+		Properties: make(map[string]string),
+	}}
+	cfg.Properties["redisHost"] = s.Addr()
+	cfg.Properties["redisPassword"] = ""
+
+	// Init
+	err = comp.InitLockStore(context.Background(), cfg)
+	require.NoError(t, err)
+
+	// 1. renewing a lock that does not exist fails
+	ownerID1 := uuid.New().String()
+	renewResp, err := comp.RenewLock(context.Background(), &lock.RenewLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, lock.LockDoesNotExist, renewResp.Status)
+
+	// 2. client1 trylock
+	resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+
+	// 3. client2 can't renew the lock of client1
+	owner2 := uuid.New().String()
+	renewResp, err = comp.RenewLock(context.Background(), &lock.RenewLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       owner2,
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, lock.LockBelongsToOthers, renewResp.Status)
+
+	// 4. client1 renews the lock, which doesn't expire at the original time
+	s.FastForward(8 * time.Second)
+	renewResp, err = comp.RenewLock(context.Background(), &lock.RenewLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, lock.Success, renewResp.Status)
+
+	s.FastForward(8 * time.Second)
+	info, err := comp.GetLockInfo(context.Background(), &lock.GetLockInfoRequest{
+		ResourceID: resourceID,
+	})
+	require.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Equal(t, ownerID1, info.LockOwner)
+	assert.EqualValues(t, 2, info.RemainingTTLInSeconds)
+
+	// 5. once expired, the lock can't be renewed
+	s.FastForward(2 * time.Second)
+	renewResp, err = comp.RenewLock(context.Background(), &lock.RenewLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, lock.LockDoesNotExist, renewResp.Status)
+
+	// 6. the expiry is required
+	renewResp, err = comp.RenewLock(context.Background(), &lock.RenewLockRequest{
+		ResourceID: resourceID,
+		LockOwner:  ownerID1,
+	})
+	require.Error(t, err)
+	assert.Equal(t, lock.InternalError, renewResp.Status)
+}
+
+func TestStandaloneRedisLock_GetLockInfo(t *testing.T) {
+	// 0. prepare
+	// start redis
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Construct component
+	comp := NewStandaloneRedisLock(logger.NewLogger("test")).(*StandaloneRedisLock)
+	defer comp.Close()
+
+	cfg := lock.Metadata{Base: metadata.Base{
+		Properties: make(map[string]string),
+	}}
+	cfg.Properties["redisHost"] = s.Addr()
+	cfg.Properties["redisPassword"] = ""
+
+	// Init
+	err = comp.InitLockStore(context.Background(), cfg)
+	require.NoError(t, err)
+
+	// 1. the lock does not exist
+	info, err := comp.GetLockInfo(context.Background(), &lock.GetLockInfoRequest{
+		ResourceID: resourceID,
+	})
+	require.NoError(t, err)
+	assert.False(t, info.Exists)
+	assert.Empty(t, info.LockOwner)
+
+	// 2. client1 trylock
+	ownerID1 := uuid.New().String()
+	resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+
+	info, err = comp.GetLockInfo(context.Background(), &lock.GetLockInfoRequest{
+		ResourceID: resourceID,
+	})
+	require.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Equal(t, ownerID1, info.LockOwner)
+	assert.EqualValues(t, 10, info.RemainingTTLInSeconds)
+
+	// 3. a lock without expiration has no TTL
+	resp, err = comp.TryLock(context.Background(), &lock.TryLockRequest{
+		ResourceID: resourceID + "-noexpiry",
+		LockOwner:  ownerID1,
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+
+	info, err = comp.GetLockInfo(context.Background(), &lock.GetLockInfoRequest{
+		ResourceID: resourceID + "-noexpiry",
+	})
+	require.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Zero(t, info.RemainingTTLInSeconds)
+}
+
+func TestStandaloneRedisLock_Lock(t *testing.T) {
+	// 0. prepare
+	// start redis
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Construct component
+	comp := NewStandaloneRedisLock(logger.NewLogger("test")).(*StandaloneRedisLock)
+	defer comp.Close()
+
+	cfg := lock.Metadata{Base: metadata.Base{
+		Properties: make(map[string]string),
+	}}
+	cfg.Properties["redisHost"] = s.Addr()
+	cfg.Properties["redisPassword"] = ""
+
+	// Init
+	err = comp.InitLockStore(context.Background(), cfg)
+	require.NoError(t, err)
+
+	// 1. client1 acquires the lock without waiting
+	ownerID1 := uuid.New().String()
+	resp, err := comp.Lock(context.Background(), &lock.LockRequest{
+		ResourceID:           resourceID,
+		LockOwner:            ownerID1,
+		ExpiryInSeconds:      10,
+		WaitTimeoutInSeconds: 1,
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+
+	// 2. client2 times out waiting for the lock
+	owner2 := uuid.New().String()
+	start := time.Now()
+	resp, err = comp.Lock(context.Background(), &lock.LockRequest{
+		ResourceID:           resourceID,
+		LockOwner:            owner2,
+		ExpiryInSeconds:      10,
+		WaitTimeoutInSeconds: 1,
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Success)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+
+	// 3. client2 acquires the lock once client1 releases it
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		comp.Unlock(context.Background(), &lock.UnlockRequest{
+			ResourceID: resourceID,
+			LockOwner:  ownerID1,
+		})
+	}()
+	resp, err = comp.Lock(context.Background(), &lock.LockRequest{
+		ResourceID:           resourceID,
+		LockOwner:            owner2,
+		ExpiryInSeconds:      10,
+		WaitTimeoutInSeconds: 5,
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+
+	info, err := comp.GetLockInfo(context.Background(), &lock.GetLockInfoRequest{
+		ResourceID: resourceID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, owner2, info.LockOwner)
+
+	// 4. waiting stops when the context is canceled
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	resp, err = comp.Lock(ctx, &lock.LockRequest{
+		ResourceID:      resourceID,
+		LockOwner:       ownerID1,
+		ExpiryInSeconds: 10,
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Success)
+}
@@ -25,3 +25,24 @@ type UnlockRequest struct {
 	ResourceID string `json:"resourceId"`
 	LockOwner  string `json:"lockOwner"`
 }
+
+// RenewLockRequest is a request to extend the expiration of a lock.
+type RenewLockRequest struct {
+	ResourceID      string `json:"resourceId"`
+	LockOwner       string `json:"lockOwner"`
+	ExpiryInSeconds int32  `json:"expiryInSeconds"`
+}
+
+// LockRequest is a blocking lock acquire request.
+// If WaitTimeoutInSeconds is zero, it waits until the context is canceled.
+type LockRequest struct {
+	ResourceID           string `json:"resourceId"`
+	LockOwner            string `json:"lockOwner"`
+	ExpiryInSeconds      int32  `json:"expiryInSeconds"`
+	WaitTimeoutInSeconds int32  `json:"waitTimeoutInSeconds"`
+}
+
+// GetLockInfoRequest is a request for the current state of a lock.
+type GetLockInfoRequest struct {
+	ResourceID string `json:"resourceId"`
+}
@@ -23,6 +23,24 @@ type UnlockResponse struct {
 	Status Status `json:"status"`
 }
 
+// Status when renewing the lock.
+type RenewLockResponse struct {
+	Status Status `json:"status"`
+}
+
+// Lock acquire request was successful or the wait timeout expired.
+type LockResponse struct {
+	Success bool `json:"success"`
+}
+
+// Current state of a lock.
+// If the lock exists and doesn't expire, RemainingTTLInSeconds is zero.
+type GetLockInfoResponse struct {
+	Exists                bool   `json:"exists"`
+	LockOwner             string `json:"lockOwner,omitempty"`
+	RemainingTTLInSeconds int32  `json:"remainingTTLInSeconds,omitempty"`
+}
+
 type Status int32
 
 // lock status.
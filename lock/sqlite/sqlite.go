@@ -0,0 +1,243 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dapr/components-contrib/common/authentication/sqlite"
+	commonsql "github.com/dapr/components-contrib/common/component/sql"
+	"github.com/dapr/components-contrib/lock"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// SQLite lock store.
+type sqliteLock struct {
+	logger   logger.Logger
+	metadata sqliteMetadata
+	db       *sql.DB
+	gc       commonsql.GarbageCollector
+	closed   atomic.Bool
+}
+
+// NewSqliteLock returns a lock store that is based on a SQLite DB.
+func NewSqliteLock(logger logger.Logger) lock.Store {
+	return &sqliteLock{
+		logger: logger,
+	}
+}
+
+// InitLockStore initializes the lock store.
+func (s *sqliteLock) InitLockStore(ctx context.Context, md lock.Metadata) error {
+	if s.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	err := s.metadata.InitWithMetadata(md)
+	if err != nil {
+		return err
+	}
+
+	connString, err := s.metadata.GetConnectionString(s.logger, sqlite.GetConnectionStringOpts{})
+	if err != nil {
+		// Already logged
+		return err
+	}
+
+	// Show a warning if SQLite is configured with an in-memory DB
+	if s.metadata.SqliteAuthMetadata.IsInMemoryDB() {
+		s.logger.Warn("Configuring the lock store with an in-memory SQLite database. Locks will not be shared with other processes.")
+	} else {
+		s.logger.Infof("Configuring SQLite lock store with path %s", connString[len("file:"):strings.Index(connString, "?")])
+	}
+
+	s.db, err = sql.Open("sqlite", connString)
+	if err != nil {
+		return fmt.Errorf("failed to create connection: %w", err)
+	}
+
+	// Performs migrations
+	err = performMigrations(ctx, s.db, s.logger, migrationOptions{
+		LocksTableName:    s.metadata.TableName,
+		MetadataTableName: s.metadata.MetadataTableName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to perform migrations: %w", err)
+	}
+
+	// Init the background GC
+	return s.initGC()
+}
+
+func (s *sqliteLock) initGC() (err error) {
+	s.gc, err = commonsql.ScheduleGarbageCollector(commonsql.GCOptions{
+		Logger: s.logger,
+		UpdateLastCleanupQuery: func(arg any) (string, any) {
+			return fmt.Sprintf(`INSERT INTO %s (key, value)
+				VALUES ('locks-last-cleanup', CURRENT_TIMESTAMP)
+				ON CONFLICT (key)
+				DO UPDATE SET value = CURRENT_TIMESTAMP
+					WHERE (unixepoch(CURRENT_TIMESTAMP) - unixepoch(value)) * 1000 > ?;`,
+				s.metadata.MetadataTableName,
+			), arg
+		},
+		DeleteExpiredValuesQuery: fmt.Sprintf(`DELETE FROM %s
+			WHERE
+				expiration_time IS NOT NULL
+				AND expiration_time <= unixepoch(CURRENT_TIMESTAMP)`,
+			s.metadata.TableName,
+		),
+		CleanupInterval: s.metadata.CleanupInterval,
+		DB:              commonsql.AdaptDatabaseSQLConn(s.db),
+	})
+	return err
+}
+
+// TryLock tries to acquire a lock.
+// If the lock cannot be acquired, it returns immediately.
+func (s *sqliteLock) TryLock(ctx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	queryCtx, queryCancel := context.WithTimeout(ctx, s.metadata.Timeout)
+	defer queryCancel()
+
+	// A NULL expiration means that the lock never expires
+	var expiry *int32
+	if req.ExpiryInSeconds > 0 {
+		expiry = &req.ExpiryInSeconds
+	}
+
+	// Insert the lock, or take over an existing one if it has expired
+	//nolint:gosec
+	query := fmt.Sprintf(`INSERT INTO %[1]s (resource_id, lock_owner, expiration_time)
+		VALUES (?, ?, unixepoch(CURRENT_TIMESTAMP) + ?)
+		ON CONFLICT (resource_id)
+		DO UPDATE SET
+			lock_owner = excluded.lock_owner,
+			expiration_time = excluded.expiration_time
+		WHERE
+			%[1]s.expiration_time IS NOT NULL
+			AND %[1]s.expiration_time <= unixepoch(CURRENT_TIMESTAMP)`,
+		s.metadata.TableName,
+	)
+	res, err := s.db.ExecContext(queryCtx, query, req.ResourceID, req.LockOwner, expiry)
+	if err != nil {
+		return &lock.TryLockResponse{}, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return &lock.TryLockResponse{}, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	return &lock.TryLockResponse{
+		Success: n > 0,
+	}, nil
+}
+
+// Unlock tries to release a lock if the lock is still valid.
+func (s *sqliteLock) Unlock(ctx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	queryCtx, queryCancel := context.WithTimeout(ctx, s.metadata.Timeout)
+	defer queryCancel()
+
+	status, err := s.doUnlock(queryCtx, req)
+	if err != nil {
+		return &lock.UnlockResponse{
+			Status: lock.InternalError,
+		}, fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	return &lock.UnlockResponse{
+		Status: status,
+	}, nil
+}
+
+func (s *sqliteLock) doUnlock(ctx context.Context, req *lock.UnlockRequest) (lock.Status, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return lock.InternalError, err
+	}
+	defer tx.Rollback()
+
+	// Expired locks are treated as if they did not exist
+	var owner string
+	//nolint:gosec
+	err = tx.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT lock_owner FROM %s
+			WHERE
+				resource_id = ?
+				AND (expiration_time IS NULL OR expiration_time > unixepoch(CURRENT_TIMESTAMP))`,
+			s.metadata.TableName,
+		),
+		req.ResourceID,
+	).Scan(&owner)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return lock.LockDoesNotExist, nil
+	case err != nil:
+		return lock.InternalError, err
+	case owner != req.LockOwner:
+		return lock.LockBelongsToOthers, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE resource_id = ?", s.metadata.TableName),
+		req.ResourceID,
+	)
+	if err != nil {
+		return lock.InternalError, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return lock.InternalError, err
+	}
+	return lock.Success, nil
+}
+
+// Close implements io.Closer.
+func (s *sqliteLock) Close() (err error) {
+	if !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	errs := make([]error, 0)
+
+	if s.gc != nil {
+		err = s.gc.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.db != nil {
+		err = s.db.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (s *sqliteLock) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := sqliteMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.LockStoreType)
+	return
+}
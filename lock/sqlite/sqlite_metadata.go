@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	authSqlite "github.com/dapr/components-contrib/common/authentication/sqlite"
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/kit/metadata"
+)
+
+const (
+	defaultTableName         = "locks"
+	defaultMetadataTableName = "metadata"
+	defaultCleanupInternal   = time.Hour
+)
+
+type sqliteMetadata struct {
+	// Config options - passed by the user via the Configuration resource
+	authSqlite.SqliteAuthMetadata `mapstructure:",squash"`
+
+	TableName         string        `mapstructure:"tableName"`
+	MetadataTableName string        `mapstructure:"metadataTableName"`
+	CleanupInterval   time.Duration `mapstructure:"cleanupInterval" mapstructurealiases:"cleanupIntervalInSeconds"`
+}
+
+func (m *sqliteMetadata) InitWithMetadata(meta lock.Metadata) error {
+	// Reset the object
+	m.reset()
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+
+	// Validate and sanitize configuration
+	err = m.SqliteAuthMetadata.Validate()
+	if err != nil {
+		return err
+	}
+	if !authSqlite.ValidIdentifier(m.TableName) {
+		return fmt.Errorf("invalid identifier for table name: %s", m.TableName)
+	}
+	if !authSqlite.ValidIdentifier(m.MetadataTableName) {
+		return fmt.Errorf("invalid identifier for metadata table name: %s", m.MetadataTableName)
+	}
+
+	return nil
+}
+
+// Reset the object
+func (m *sqliteMetadata) reset() {
+	m.SqliteAuthMetadata.Reset()
+
+	m.TableName = defaultTableName
+	m.MetadataTableName = defaultMetadataTableName
+	m.CleanupInterval = defaultCleanupInternal
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	commonsql "github.com/dapr/components-contrib/common/component/sql"
+	sqlitemigrations "github.com/dapr/components-contrib/common/component/sql/migrations/sqlite"
+	"github.com/dapr/kit/logger"
+)
+
+type migrationOptions struct {
+	LocksTableName    string
+	MetadataTableName string
+}
+
+// Perform the required migrations
+func performMigrations(ctx context.Context, db *sql.DB, logger logger.Logger, opts migrationOptions) error {
+	m := sqlitemigrations.Migrations{
+		Pool:              db,
+		Logger:            logger,
+		MetadataTableName: opts.MetadataTableName,
+		MetadataKey:       "locks-migrations",
+	}
+
+	return m.Perform(ctx, []commonsql.MigrationFn{
+		// Migration 0: create the locks table
+		func(ctx context.Context) error {
+			logger.Infof("Creating locks table '%s'", opts.LocksTableName)
+			_, err := m.GetConn().ExecContext(
+				ctx,
+				fmt.Sprintf(
+					`CREATE TABLE %[1]s (
+						resource_id TEXT NOT NULL PRIMARY KEY,
+						lock_owner TEXT NOT NULL,
+						expiration_time INTEGER
+					);
+					CREATE INDEX %[1]s_expiration_time_idx ON %[1]s (expiration_time);`,
+					opts.LocksTableName,
+				),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create locks table: %w", err)
+			}
+			return nil
+		},
+	})
+}
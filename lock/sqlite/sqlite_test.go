@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const resourceID = "resource_xxx"
+
+func TestSqliteMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		md := sqliteMetadata{}
+		err := md.InitWithMetadata(lock.Metadata{Base: metadata.Base{
+			Properties: map[string]string{
+				"connectionString": ":memory:",
+			},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultTableName, md.TableName)
+		assert.Equal(t, defaultMetadataTableName, md.MetadataTableName)
+		assert.Equal(t, defaultCleanupInternal, md.CleanupInterval)
+	})
+
+	t.Run("missing connection string", func(t *testing.T) {
+		md := sqliteMetadata{}
+		err := md.InitWithMetadata(lock.Metadata{Base: metadata.Base{
+			Properties: map[string]string{},
+		}})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid table name", func(t *testing.T) {
+		md := sqliteMetadata{}
+		err := md.InitWithMetadata(lock.Metadata{Base: metadata.Base{
+			Properties: map[string]string{
+				"connectionString": ":memory:",
+				"tableName":        "locks;",
+			},
+		}})
+		require.ErrorContains(t, err, "invalid identifier for table name")
+	})
+}
+
+func TestSqliteLock(t *testing.T) {
+	comp := NewSqliteLock(logger.NewLogger("test")).(*sqliteLock)
+	defer comp.Close()
+
+	err := comp.InitLockStore(context.Background(), lock.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"connectionString": ":memory:",
+			"cleanupInterval":  "0",
+		},
+	}})
+	require.NoError(t, err)
+
+	tryLock := func(owner string, expiry int32) bool {
+		t.Helper()
+		resp, err := comp.TryLock(context.Background(), &lock.TryLockRequest{
+			ResourceID:      resourceID,
+			LockOwner:       owner,
+			ExpiryInSeconds: expiry,
+		})
+		require.NoError(t, err)
+		return resp.Success
+	}
+	unlock := func(owner string) lock.Status {
+		t.Helper()
+		resp, err := comp.Unlock(context.Background(), &lock.UnlockRequest{
+			ResourceID: resourceID,
+			LockOwner:  owner,
+		})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	// 1. unlock a lock that does not exist
+	assert.Equal(t, lock.LockDoesNotExist, unlock("owner1"))
+
+	// 2. owner1 acquires the lock, owner2 fails
+	assert.True(t, tryLock("owner1", 10))
+	assert.False(t, tryLock("owner2", 10))
+
+	// 3. owner2 can't release the lock of owner1
+	assert.Equal(t, lock.LockBelongsToOthers, unlock("owner2"))
+
+	// 4. owner1 releases the lock, then owner2 acquires it
+	assert.Equal(t, lock.Success, unlock("owner1"))
+	assert.True(t, tryLock("owner2", 10))
+
+	// 5. the lock expires, then owner1 acquires it
+	_, err = comp.db.Exec("UPDATE locks SET expiration_time = unixepoch(CURRENT_TIMESTAMP) - 1 WHERE resource_id = ?", resourceID)
+	require.NoError(t, err)
+	assert.True(t, tryLock("owner1", 10))
+	assert.Equal(t, lock.LockBelongsToOthers, unlock("owner2"))
+
+	// 6. an expired lock does not exist anymore
+	_, err = comp.db.Exec("UPDATE locks SET expiration_time = unixepoch(CURRENT_TIMESTAMP) - 1 WHERE resource_id = ?", resourceID)
+	require.NoError(t, err)
+	assert.Equal(t, lock.LockDoesNotExist, unlock("owner1"))
+}
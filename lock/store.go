@@ -34,3 +34,21 @@ type Store interface {
 
 	io.Closer
 }
+
+// RenewableStore is an optional interface for lock stores that can extend the expiration of a lock.
+type RenewableStore interface {
+	// RenewLock extends the expiration of a lock, if it's still owned by the caller.
+	RenewLock(ctx context.Context, req *RenewLockRequest) (*RenewLockResponse, error)
+}
+
+// BlockingStore is an optional interface for lock stores that can wait for a lock to be released.
+type BlockingStore interface {
+	// Lock acquires a lock, waiting until it's available or until the wait timeout expires.
+	Lock(ctx context.Context, req *LockRequest) (*LockResponse, error)
+}
+
+// InfoStore is an optional interface for lock stores that can return the current state of a lock.
+type InfoStore interface {
+	// GetLockInfo returns the owner of a lock and its remaining time to live.
+	GetLockInfo(ctx context.Context, req *GetLockInfoRequest) (*GetLockInfoResponse, error)
+}
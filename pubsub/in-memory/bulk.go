@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/google/uuid"
+
+	commonutils "github.com/dapr/components-contrib/common/utils"
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+const (
+	defaultMaxBulkSubCount           = 100
+	defaultMaxBulkSubAwaitDurationMs = 1000
+)
+
+// bulkEntry is a message received by a bulk subscription.
+type bulkEntry struct {
+	topic    string
+	metadata map[string]string
+	entry    pubsub.BulkMessageEntry
+}
+
+func (a *bus) BulkPublish(_ context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	if a.closed.Load() {
+		err := errors.New("component is closed")
+		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	}
+
+	// Publishing to memory can't fail, so there are never failed entries
+	for _, entry := range req.Entries {
+		a.bus.Publish(req.Topic, req.Topic, entry.Event, mergeMetadata(req.Metadata, entry.Metadata))
+	}
+
+	return pubsub.BulkPublishResponse{}, nil
+}
+
+func (a *bus) BulkSubscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.BulkHandler) error {
+	if a.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	maxCount := commonutils.GetIntValOrDefault(req.BulkSubscribeConfig.MaxMessagesCount, defaultMaxBulkSubCount)
+	maxAwait := time.Duration(commonutils.GetIntValOrDefault(req.BulkSubscribeConfig.MaxAwaitDurationMs, defaultMaxBulkSubAwaitDurationMs)) * time.Millisecond
+
+	// Messages are queued without blocking the publisher, then delivered in batches by a background goroutine
+	var (
+		lock   sync.Mutex
+		queue  []bulkEntry
+		notify = make(chan struct{}, 1)
+	)
+	enqueueHandler := func(topic string, data []byte, md map[string]string) {
+		lock.Lock()
+		queue = append(queue, bulkEntry{
+			topic:    topic,
+			metadata: md,
+			entry: pubsub.BulkMessageEntry{
+				EntryId:  uuid.NewString(),
+				Event:    data,
+				Metadata: mergeMetadata(req.Metadata, md),
+			},
+		})
+		lock.Unlock()
+
+		select {
+		case notify <- struct{}{}:
+		default:
+			// There's a pending notification already
+		}
+	}
+
+	// Returns the next batch of messages; if full is true, the batch is returned only if it has the maximum number of messages
+	nextBatch := func(full bool) []bulkEntry {
+		lock.Lock()
+		defer lock.Unlock()
+		n := min(len(queue), maxCount)
+		if n == 0 || (full && n < maxCount) {
+			return nil
+		}
+		batch := queue[:n:n]
+		queue = queue[n:]
+		return batch
+	}
+
+	err := a.bus.SubscribeAsync(req.Topic, enqueueHandler, true)
+	if err != nil {
+		return err
+	}
+
+	a.unsubscribeOnDone(ctx, req.Topic, enqueueHandler)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		// The timer is started when the first message of a batch is received
+		timer := time.NewTimer(maxAwait)
+		timer.Stop()
+		defer timer.Stop()
+		var timerC <-chan time.Time
+
+		for {
+			select {
+			case <-notify:
+			case <-timerC:
+				timerC = nil
+				if batch := nextBatch(false); batch != nil {
+					a.deliverBulk(ctx, req, handler, batch)
+				}
+			case <-ctx.Done():
+				return
+			case <-a.closeCh:
+				return
+			}
+
+			// Deliver all full batches right away
+			for batch := nextBatch(true); batch != nil; batch = nextBatch(true) {
+				a.deliverBulk(ctx, req, handler, batch)
+			}
+
+			lock.Lock()
+			pending := len(queue)
+			lock.Unlock()
+			if pending > 0 && timerC == nil {
+				timer.Reset(maxAwait)
+				timerC = timer.C
+			}
+		}
+	}()
+
+	return nil
+}
+
+// deliverBulk invokes the handler with a batch of messages.
+// Messages that fail are retried, then republished to the dead-letter topic like in Subscribe.
+func (a *bus) deliverBulk(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.BulkHandler, batch []bulkEntry) {
+	pending := batch
+	errs := make(map[string]error, len(batch))
+	err := backoff.Retry(func() error {
+		entries := make([]pubsub.BulkMessageEntry, len(pending))
+		for i, e := range pending {
+			entries[i] = e.entry
+		}
+		res, handleErr := handler(ctx, &pubsub.BulkMessage{
+			Entries:  entries,
+			Topic:    req.Topic,
+			Metadata: req.Metadata,
+		})
+		if handleErr == nil {
+			return nil
+		}
+		a.log.Error(handleErr)
+
+		// If the handler returned the status of each message, only the ones that failed are retried
+		if res != nil {
+			failed := make(map[string]error, len(res))
+			for _, r := range res {
+				if r.Error != nil {
+					failed[r.EntryId] = r.Error
+				}
+			}
+			failedEntries := pending[:0:0]
+			for _, e := range pending {
+				if entryErr, ok := failed[e.entry.EntryId]; ok {
+					errs[e.entry.EntryId] = entryErr
+					failedEntries = append(failedEntries, e)
+				}
+			}
+			pending = failedEntries
+			if len(pending) == 0 {
+				return nil
+			}
+		} else {
+			for _, e := range pending {
+				errs[e.entry.EntryId] = handleErr
+			}
+		}
+		return fmt.Errorf("failed to process %d messages: %w", len(pending), handleErr)
+	}, a.backOffConfig.NewBackOffWithContext(ctx))
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	for _, e := range pending {
+		a.deadLetter(e.topic, e.entry.Event, e.metadata, errs[e.entry.EntryId])
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+func bulkEntries(n int) []pubsub.BulkMessageEntry {
+	entries := make([]pubsub.BulkMessageEntry, n)
+	for i := range entries {
+		entries[i] = pubsub.BulkMessageEntry{
+			EntryId:  strconv.Itoa(i),
+			Event:    []byte(strconv.Itoa(i)),
+			Metadata: map[string]string{"n": strconv.Itoa(i)},
+		}
+	}
+	return entries
+}
+
+func eventsOf(msg *pubsub.BulkMessage) []string {
+	events := make([]string, len(msg.Entries))
+	for i, e := range msg.Entries {
+		events[i] = string(e.Event)
+	}
+	return events
+}
+
+func TestBulkPublishSubscribe(t *testing.T) {
+	ps := New(logger.NewLogger("test"))
+	require.NoError(t, ps.Init(context.Background(), pubsub.Metadata{}))
+	defer ps.Close()
+	assert.Contains(t, ps.Features(), pubsub.FeatureBulkPublish)
+
+	ch := make(chan *pubsub.BulkMessage, 10)
+	err := ps.(pubsub.BulkSubscriber).BulkSubscribe(context.Background(), pubsub.SubscribeRequest{
+		Topic:               "demo",
+		Metadata:            map[string]string{"sub": "1"},
+		BulkSubscribeConfig: pubsub.BulkSubscribeConfig{MaxMessagesCount: 3, MaxAwaitDurationMs: 100},
+	}, func(ctx context.Context, msg *pubsub.BulkMessage) ([]pubsub.BulkSubscribeResponseEntry, error) {
+		ch <- msg
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	// Messages published individually are received too
+	single := make(chan *pubsub.NewMessage, 10)
+	err = ps.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		single <- msg
+		return nil
+	})
+	require.NoError(t, err)
+
+	res, err := ps.(pubsub.BulkPublisher).BulkPublish(context.Background(), &pubsub.BulkPublishRequest{
+		Topic:    "demo",
+		Entries:  bulkEntries(5),
+		Metadata: map[string]string{"pub": "1"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, res.FailedEntries)
+
+	// The first batch is full, and the second one is delivered after the max await duration
+	var events []string
+	for range 2 {
+		select {
+		case msg := <-ch:
+			assert.Equal(t, "demo", msg.Topic)
+			assert.LessOrEqual(t, len(msg.Entries), 3)
+			for _, e := range msg.Entries {
+				assert.Equal(t, map[string]string{"sub": "1", "pub": "1", "n": string(e.Event)}, e.Metadata)
+				assert.NotEmpty(t, e.EntryId)
+			}
+			events = append(events, eventsOf(msg)...)
+		case <-time.After(5 * time.Second):
+			t.Fatal("batch was not delivered")
+		}
+	}
+	assert.ElementsMatch(t, []string{"0", "1", "2", "3", "4"}, events)
+
+	for range 5 {
+		select {
+		case msg := <-single:
+			assert.Equal(t, "1", msg.Metadata["pub"])
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not delivered")
+		}
+	}
+}
+
+func TestBulkSubscribeFailedEntries(t *testing.T) {
+	ps := New(logger.NewLogger("test"))
+	err := ps.Init(context.Background(), pubsub.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"deadLetterTopic":   "dead",
+			"backOffDuration":   "1ms",
+			"backOffMaxRetries": "2",
+		},
+	}})
+	require.NoError(t, err)
+	defer ps.Close()
+
+	// Messages "1" and "2" fail the first time, and "2" always fails
+	ch := make(chan []string, 10)
+	failures := map[string]int{}
+	err = ps.(pubsub.BulkSubscriber).BulkSubscribe(context.Background(), pubsub.SubscribeRequest{
+		Topic:               "demo",
+		BulkSubscribeConfig: pubsub.BulkSubscribeConfig{MaxMessagesCount: 3},
+	}, func(ctx context.Context, msg *pubsub.BulkMessage) ([]pubsub.BulkSubscribeResponseEntry, error) {
+		ch <- eventsOf(msg)
+		res := make([]pubsub.BulkSubscribeResponseEntry, len(msg.Entries))
+		var failed bool
+		for i, e := range msg.Entries {
+			res[i].EntryId = e.EntryId
+			event := string(e.Event)
+			if event == "2" || (event == "1" && failures[event] == 0) {
+				failures[event]++
+				res[i].Error = errors.New("failed " + event)
+				failed = true
+			}
+		}
+		if failed {
+			return res, errors.New("some messages failed")
+		}
+		return res, nil
+	})
+	require.NoError(t, err)
+
+	dead := make(chan *pubsub.NewMessage, 10)
+	err = ps.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "dead"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		dead <- msg
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = ps.(pubsub.BulkPublisher).BulkPublish(context.Background(), &pubsub.BulkPublishRequest{
+		Topic:   "demo",
+		Entries: bulkEntries(3),
+	})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-dead:
+		assert.Equal(t, "2", string(msg.Data))
+		assert.Equal(t, "failed 2", msg.Metadata[MetadataKeyDeadLetterReason])
+		assert.Equal(t, "demo", msg.Metadata[MetadataKeyDeadLetterOriginalTopic])
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not republished to the dead-letter topic")
+	}
+
+	// Only the failed messages are retried
+	assert.Equal(t, []string{"0", "1", "2"}, <-ch)
+	assert.Equal(t, []string{"1", "2"}, <-ch)
+	assert.Equal(t, []string{"2"}, <-ch)
+	assert.Empty(t, ch)
+}
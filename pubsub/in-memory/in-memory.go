@@ -16,22 +16,51 @@ package inmemory
 import (
 	"context"
 	"errors"
+	"maps"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
+
 	"github.com/dapr/components-contrib/common/eventbus"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
+	kitmd "github.com/dapr/kit/metadata"
+	"github.com/dapr/kit/retry"
+)
+
+// Keys of the metadata added to messages republished to the dead-letter topic.
+const (
+	MetadataKeyDeadLetterReason        = "deadLetterReason"
+	MetadataKeyDeadLetterOriginalTopic = "deadLetterOriginalTopic"
 )
 
+type inMemoryMetadata struct {
+	// Topic where messages are republished after the handler failed and retries are exhausted.
+	// If empty, those messages are dropped.
+	DeadLetterTopic string `mapstructure:"deadLetterTopic"`
+
+	// Policy used to retry messages when the handler fails.
+	// The "backOff" properties are decoded into a retry.Config by retry.DecodeConfigWithPrefix.
+	BackOffPolicy          string        `mapstructure:"backOffPolicy"`
+	BackOffDuration        time.Duration `mapstructure:"backOffDuration"`
+	BackOffInitialInterval time.Duration `mapstructure:"backOffInitialInterval"`
+	BackOffMultiplier      float32       `mapstructure:"backOffMultiplier"`
+	BackOffMaxInterval     time.Duration `mapstructure:"backOffMaxInterval"`
+	BackOffMaxRetries      int64         `mapstructure:"backOffMaxRetries"`
+}
+
 type bus struct {
-	bus     eventbus.Bus
-	log     logger.Logger
-	closed  atomic.Bool
-	closeCh chan struct{}
-	wg      sync.WaitGroup
+	bus           eventbus.Bus
+	log           logger.Logger
+	metadata      inMemoryMetadata
+	backOffConfig retry.Config
+	closed        atomic.Bool
+	closeCh       chan struct{}
+	wg            sync.WaitGroup
 }
 
 func New(logger logger.Logger) pubsub.PubSub {
@@ -50,10 +79,25 @@ func (a *bus) Close() error {
 }
 
 func (a *bus) Features() []pubsub.Feature {
-	return []pubsub.Feature{pubsub.FeatureSubscribeWildcards}
+	return []pubsub.Feature{pubsub.FeatureSubscribeWildcards, pubsub.FeatureBulkPublish}
 }
 
 func (a *bus) Init(_ context.Context, metadata pubsub.Metadata) error {
+	err := kitmd.DecodeMetadata(metadata.Properties, &a.metadata)
+	if err != nil {
+		return err
+	}
+
+	// By default, messages are delivered up to 10 times, retrying every 100ms.
+	// The policy can be changed with the "backOff" properties, such as "backOffPolicy" and "backOffMaxRetries".
+	a.backOffConfig = retry.DefaultConfig()
+	a.backOffConfig.Duration = 100 * time.Millisecond
+	a.backOffConfig.MaxRetries = 9
+	err = retry.DecodeConfigWithPrefix(&a.backOffConfig, metadata.Properties, "backOff")
+	if err != nil {
+		return err
+	}
+
 	a.bus = eventbus.New(true)
 
 	return nil
@@ -64,7 +108,7 @@ func (a *bus) Publish(_ context.Context, req *pubsub.PublishRequest) error {
 		return errors.New("component is closed")
 	}
 
-	a.bus.Publish(req.Topic, req.Data)
+	a.bus.Publish(req.Topic, req.Topic, req.Data, req.Metadata)
 
 	return nil
 }
@@ -75,19 +119,18 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 	}
 
 	// For this component we allow built-in retries because it is backed by memory
-	retryHandler := func(data []byte) {
-		for range 10 {
-			handleErr := handler(ctx, &pubsub.NewMessage{Data: data, Topic: req.Topic, Metadata: req.Metadata})
-			if handleErr == nil {
-				break
-			}
-			a.log.Error(handleErr)
-			select {
-			case <-time.After(100 * time.Millisecond):
-				// Nop
-			case <-ctx.Done():
-				return
+	retryHandler := func(topic string, data []byte, md map[string]string) {
+		msg := &pubsub.NewMessage{Data: data, Topic: req.Topic, Metadata: mergeMetadata(req.Metadata, md)}
+
+		err := backoff.Retry(func() error {
+			handleErr := handler(ctx, msg)
+			if handleErr != nil {
+				a.log.Error(handleErr)
 			}
+			return handleErr
+		}, a.backOffConfig.NewBackOffWithContext(ctx))
+		if err != nil && ctx.Err() == nil {
+			a.deadLetter(topic, data, md, err)
 		}
 	}
 	err := a.bus.SubscribeAsync(req.Topic, retryHandler, true)
@@ -95,7 +138,13 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 		return err
 	}
 
-	// Unsubscribe when context is done
+	a.unsubscribeOnDone(ctx, req.Topic, retryHandler)
+
+	return nil
+}
+
+// unsubscribeOnDone unsubscribes the handler when the context is done, or the component is closed.
+func (a *bus) unsubscribeOnDone(ctx context.Context, topic string, fn any) {
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
@@ -103,16 +152,49 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 		case <-ctx.Done():
 		case <-a.closeCh:
 		}
-		err := a.bus.Unsubscribe(req.Topic, retryHandler)
+		err := a.bus.Unsubscribe(topic, fn)
 		if err != nil {
-			a.log.Errorf("error while unsubscribing from topic %s: %v", req.Topic, err)
+			a.log.Errorf("error while unsubscribing from topic %s: %v", topic, err)
 		}
 	}()
+}
 
-	return nil
+// deadLetter republishes a message that ran out of retries to the dead-letter topic, with the reason of the failure in its metadata.
+func (a *bus) deadLetter(topic string, data []byte, md map[string]string, handleErr error) {
+	// Messages that fail on the dead-letter topic itself are dropped, to avoid loops
+	if a.metadata.DeadLetterTopic == "" || topic == a.metadata.DeadLetterTopic {
+		a.log.Errorf("Dropping message on topic %s after all retries failed: %v", topic, handleErr)
+		return
+	}
+
+	dlMetadata := maps.Clone(md)
+	if dlMetadata == nil {
+		dlMetadata = make(map[string]string, 2)
+	}
+	dlMetadata[MetadataKeyDeadLetterReason] = handleErr.Error()
+	dlMetadata[MetadataKeyDeadLetterOriginalTopic] = topic
+
+	// Publish in background, because the handler that failed could be subscribed to the dead-letter topic too, and handlers are not re-entrant
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.bus.Publish(a.metadata.DeadLetterTopic, a.metadata.DeadLetterTopic, data, dlMetadata)
+	}()
+}
+
+// mergeMetadata returns the metadata of a message, which is added to the metadata of the subscription.
+func mergeMetadata(subscription map[string]string, message map[string]string) map[string]string {
+	md := maps.Clone(subscription)
+	if md == nil {
+		md = make(map[string]string, len(message))
+	}
+	maps.Copy(md, message)
+	return md
 }
 
 // GetComponentMetadata returns the metadata of the component.
 func (a *bus) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataStruct := inMemoryMetadata{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.PubSubType)
 	return
 }
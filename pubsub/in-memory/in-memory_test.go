@@ -16,10 +16,14 @@ package inmemory
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -102,6 +106,78 @@ func TestRetry(t *testing.T) {
 	assert.Equal(t, 5, i)
 }
 
+func TestDeadLetter(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	err := bus.Init(context.Background(), pubsub.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"deadLetterTopic":   "dead",
+			"backOffDuration":   "1ms",
+			"backOffMaxRetries": "2",
+		},
+	}})
+	require.NoError(t, err)
+
+	var attempts atomic.Int32
+	bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		attempts.Add(1)
+		return errors.New("always fails")
+	})
+
+	ch := make(chan *pubsub.NewMessage, 1)
+	bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "dead"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg
+		return nil
+	})
+
+	bus.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("ABCD"), Topic: "demo", Metadata: map[string]string{"foo": "bar"}})
+
+	select {
+	case msg := <-ch:
+		assert.Equal(t, "ABCD", string(msg.Data))
+		assert.Equal(t, "dead", msg.Topic)
+		assert.Equal(t, map[string]string{
+			"foo":                              "bar",
+			MetadataKeyDeadLetterReason:        "always fails",
+			MetadataKeyDeadLetterOriginalTopic: "demo",
+		}, msg.Metadata)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not republished to the dead-letter topic")
+	}
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestExponentialBackOff(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	err := bus.Init(context.Background(), pubsub.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"backOffPolicy":          "exponential",
+			"backOffInitialInterval": "1ms",
+			"backOffMaxRetries":      "3",
+		},
+	}})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	var attempts atomic.Int32
+	bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		if attempts.Add(1) == 4 {
+			close(done)
+		}
+		return errors.New("always fails")
+	})
+
+	bus.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("ABCD"), Topic: "demo"})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler was not retried")
+	}
+
+	// Without a dead-letter topic, the message is dropped after the retries
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(4), attempts.Load())
+}
+
 func publish(ch chan []byte, msg *pubsub.NewMessage) error {
 	go func() { ch <- msg.Data }()
 
@@ -16,21 +16,17 @@ package vault
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"reflect"
 	"strings"
 
 	jsoniter "github.com/json-iterator/go"
-	"golang.org/x/net/http2"
 
+	vaultauth "github.com/dapr/components-contrib/common/authentication/hashicorp/vault"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
@@ -38,7 +34,7 @@ import (
 )
 
 const (
-	defaultVaultAddress          string = "https://127.0.0.1:8200"
+	defaultVaultAddress          string = vaultauth.DefaultAddress
 	defaultVaultEnginePath       string = "secret"
 	componentVaultAddress        string = "vaultAddr"
 	componentCaCert              string = "caCert"
@@ -51,8 +47,8 @@ const (
 	componentVaultKVPrefix       string = "vaultKVPrefix"
 	componentVaultKVUsePrefix    string = "vaultKVUsePrefix"
 	defaultVaultKVPrefix         string = "dapr"
-	vaultHTTPHeader              string = "X-Vault-Token"
-	vaultHTTPRequestHeader       string = "X-Vault-Request"
+	vaultHTTPHeader              string = vaultauth.TokenHeader
+	vaultHTTPRequestHeader       string = vaultauth.RequestHeader
 	vaultEnginePath              string = "enginePath"
 	vaultValueType               string = "vaultValueType"
 	versionID                    string = "version_id"
@@ -398,133 +394,22 @@ func (v *vaultSecretStore) isSecretPath(key string) bool {
 
 // initVaultToken reads the vault token from the file if token is defined by mount path.
 func (v *vaultSecretStore) initVaultToken() error {
-	// Test that at least one of them are set if not return error
-	if v.vaultToken == "" && v.vaultTokenMountPath == "" {
-		return errors.New("token mount path and token not set")
-	}
-
-	// Test that both are not set. If so return error
-	if v.vaultToken != "" && v.vaultTokenMountPath != "" {
-		return errors.New("token mount path and token both set")
-	}
-
-	if v.vaultToken != "" {
-		return nil
-	}
-
-	data, err := os.ReadFile(v.vaultTokenMountPath)
+	token, err := vaultauth.ReadToken(v.vaultToken, v.vaultTokenMountPath)
 	if err != nil {
-		return fmt.Errorf("couldn't read vault token from mount path %s err: %s", v.vaultTokenMountPath, err)
+		return err
 	}
-	v.vaultToken = string(bytes.TrimSpace(data))
-
+	v.vaultToken = token
 	return nil
 }
 
 func (v *vaultSecretStore) createHTTPClient(config *tlsConfig) (*http.Client, error) {
-	tlsClientConfig := &tls.Config{MinVersion: tls.VersionTLS12}
-
-	if config != nil && config.vaultSkipVerify {
-		v.logger.Infof("hashicorp vault: you are using 'skipVerify' to skip server config verify which is unsafe!")
-	}
-
-	tlsClientConfig.InsecureSkipVerify = config.vaultSkipVerify
-	if !config.vaultSkipVerify {
-		rootCAPools, err := v.getRootCAsPools(config.vaultCAPem, config.vaultCAPath, config.vaultCACert)
-		if err != nil {
-			return nil, err
-		}
-
-		tlsClientConfig.RootCAs = rootCAPools
-
-		if config.vaultServerName != "" {
-			tlsClientConfig.ServerName = config.vaultServerName
-		}
-	}
-
-	// Setup http transport
-	transport := &http.Transport{
-		TLSClientConfig: tlsClientConfig,
-	}
-
-	// Configure http2 client
-	err := http2.ConfigureTransport(transport)
-	if err != nil {
-		return nil, errors.New("failed to configure http2")
-	}
-
-	return &http.Client{
-		Transport: transport,
-	}, nil
-}
-
-// getRootCAsPools returns root CAs when you give it CA Pem file, CA path, and CA Certificate. Default is system certificates.
-func (v *vaultSecretStore) getRootCAsPools(vaultCAPem string, vaultCAPath string, vaultCACert string) (*x509.CertPool, error) {
-	if vaultCAPem != "" {
-		certPool := x509.NewCertPool()
-		cert := []byte(vaultCAPem)
-		if ok := certPool.AppendCertsFromPEM(cert); !ok {
-			return nil, errors.New("couldn't read PEM")
-		}
-
-		return certPool, nil
-	}
-
-	if vaultCAPath != "" {
-		certPool := x509.NewCertPool()
-		if err := readCertificateFolder(certPool, vaultCAPath); err != nil {
-			return nil, err
-		}
-
-		return certPool, nil
-	}
-
-	if vaultCACert != "" {
-		certPool := x509.NewCertPool()
-		if err := readCertificateFile(certPool, vaultCACert); err != nil {
-			return nil, err
-		}
-
-		return certPool, nil
-	}
-
-	certPool, err := x509.SystemCertPool()
-	if err != nil {
-		return nil, fmt.Errorf("couldn't read system certs: %s", err)
-	}
-
-	return certPool, nil
-}
-
-// readCertificateFile reads the certificate at given path.
-func readCertificateFile(certPool *x509.CertPool, path string) error {
-	// Read certificate file
-	pemFile, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("couldn't read CA file from disk: %s", err)
-	}
-
-	if ok := certPool.AppendCertsFromPEM(pemFile); !ok {
-		return errors.New("couldn't read PEM")
-	}
-
-	return nil
-}
-
-// readCertificateFolder scans a folder for certificates.
-func readCertificateFolder(certPool *x509.CertPool, path string) error {
-	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-		if info.IsDir() {
-			return nil
-		}
-
-		return readCertificateFile(certPool, p)
-	})
-	if err != nil {
-		return fmt.Errorf("couldn't read certificates at %s: %s", path, err)
-	}
-
-	return nil
+	return vaultauth.NewHTTPClient(vaultauth.TLSConfig{
+		CAPem:      config.vaultCAPem,
+		CAPath:     config.vaultCAPath,
+		CACert:     config.vaultCACert,
+		SkipVerify: config.vaultSkipVerify,
+		ServerName: config.vaultServerName,
+	}, v.logger)
 }
 
 // Features returns the features available in this secret store.
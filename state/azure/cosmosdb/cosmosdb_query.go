@@ -191,6 +191,26 @@ func (q *Query) visitFilters(op string, filters []query.Filter) (string, error)
 				return "", err
 			}
 			arr = append(arr, "("+str+")")
+		case *query.NOT:
+			if str, err = q.VisitNOT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LIKE:
+			if str, err = q.VisitLIKE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.EXISTS:
+			if str, err = q.VisitEXISTS(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.CONTAINS:
+			if str, err = q.VisitCONTAINS(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
 		default:
 			return "", fmt.Errorf("unsupported filter type %#v", f)
 		}
@@ -209,6 +229,49 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 	return q.visitFilters("OR", f.Filters)
 }
 
+func (q *Query) VisitNOT(f *query.NOT) (string, error) {
+	// IIF(<expression>, false, true)
+	// NOT can't be used because it returns undefined when the expression is undefined (e.g. the field is missing), and those items must be matched too
+	str, err := q.visitFilters("AND", []query.Filter{f.Filter})
+	if err != nil {
+		return "", err
+	}
+
+	return "IIF(" + str + ", false, true)", nil
+}
+
+func (q *Query) VisitLIKE(f *query.LIKE) (string, error) {
+	// <key> LIKE <val>
+	// Cosmos DB also supports ranges in brackets, so literal characters with special meaning are enclosed in brackets
+	name := q.setNextParameter(f.Translate(escapeLike, func(c rune) string {
+		return string(c)
+	}))
+
+	return replaceKeywords("c.value."+f.Key) + " LIKE " + name, nil
+}
+
+func (q *Query) VisitEXISTS(f *query.EXISTS) (string, error) {
+	// IS_DEFINED(<key>)
+	return "IS_DEFINED(" + replaceKeywords("c.value."+f.Key) + ")", nil
+}
+
+func (q *Query) VisitCONTAINS(f *query.CONTAINS) (string, error) {
+	// ARRAY_CONTAINS(<key>, <val>)
+	var name string
+	switch value := f.Val.(type) {
+	case string:
+		name = q.setNextParameter(value)
+	case int:
+		name = q.setNextParameterInt(value)
+	case float64:
+		name = q.setNextParameterFloat(value)
+	default:
+		return "", fmt.Errorf("unsupported type of value %#v; expected string or number", f.Val)
+	}
+
+	return "ARRAY_CONTAINS(" + replaceKeywords("c.value."+f.Key) + ", " + name + ")", nil
+}
+
 func (q *Query) Finalize(filters string, qq *query.Query) error {
 	var filter, orderBy string
 	if len(filters) != 0 {
@@ -327,6 +390,20 @@ func (q *Query) execute(ctx context.Context, client *azcosmos.ContainerClient) (
 	return ret, token, nil
 }
 
+// escapeLike escapes the characters with special meaning in LIKE patterns, enclosing them in brackets.
+func escapeLike(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '%', '_', '[':
+			b.WriteString("[" + string(c) + "]")
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
 func replaceKeywords(key string) string {
 	reserved := []string{"value"}
 
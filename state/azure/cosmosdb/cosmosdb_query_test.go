@@ -174,6 +174,26 @@ func TestCosmosDbQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: "../../../tests/state/query/q9.json",
+			query: InternalQuery{
+				query: "SELECT * FROM c WHERE c['value']['person']['name'] LIKE @__param__0__ AND IS_DEFINED(c['value']['person']['org']) AND IIF(c['value']['state'] = @__param__1__, false, true) AND ARRAY_CONTAINS(c['value']['tags'], @__param__2__) ORDER BY c['value']['person']['name'] ASC",
+				parameters: []azcosmos.QueryParameter{
+					{
+						Name:  "@__param__0__",
+						Value: "J%",
+					},
+					{
+						Name:  "@__param__1__",
+						Value: "CA",
+					},
+					{
+						Name:  "@__param__2__",
+						Value: "blue",
+					},
+				},
+			},
+		},
 	}
 	for _, test := range tests {
 		data, err := os.ReadFile(test.input)
@@ -189,3 +209,22 @@ func TestCosmosDbQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestCosmosDbQueryLIKE(t *testing.T) {
+	var qq query.Query
+	err := json.Unmarshal([]byte(`{"filter":{"LIKE":{"name":"[a]\\_%_"}}}`), &qq)
+	require.NoError(t, err)
+
+	q := &Query{}
+	err = query.NewQueryBuilder(q).BuildQuery(&qq)
+	require.NoError(t, err)
+	assert.Equal(t, InternalQuery{
+		query: "SELECT * FROM c WHERE c['value']['name'] LIKE @__param__0__",
+		parameters: []azcosmos.QueryParameter{
+			{
+				Name:  "@__param__0__",
+				Value: "[[]a][_]%_",
+			},
+		},
+	}, q.query)
+}
@@ -0,0 +1,473 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"k8s.io/utils/clock"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	// DefaultCacheMaxEntries is the default maximum number of values kept in the cache of a CachedStore.
+	DefaultCacheMaxEntries = 1000
+	// DefaultCacheTTL is the default time values are kept in the cache of a CachedStore.
+	DefaultCacheTTL = time.Minute
+)
+
+// CacheOptions contains the options for NewCachedStore.
+type CacheOptions struct {
+	// Maximum number of values kept in the cache, evicting the least recently used ones.
+	// Defaults to DefaultCacheMaxEntries.
+	MaxEntries int
+	// Maximum time values are kept in the cache.
+	// Values with a TTL in the wrapped state store are removed from the cache when they expire, if earlier.
+	// Defaults to DefaultCacheTTL.
+	TTL time.Duration
+}
+
+// CacheStats contains the statistics of the cache of a CachedStore.
+type CacheStats struct {
+	// Number of values read from the cache.
+	Hits uint64
+	// Number of values read from the wrapped state store because they were not in the cache.
+	// Reads with strong consistency are not counted, as they always bypass the cache.
+	Misses uint64
+	// Number of values in the cache, including expired ones that were not removed yet.
+	Entries int
+}
+
+// CachedStore is a state store that keeps the values read from another state store in a LRU cache.
+// Writes through the CachedStore remove the written keys from the cache, while changes made by other clients of the wrapped state store are visible after the values expire from the cache.
+// Reads with strong consistency always bypass the cache, and refresh it with the value they read.
+type CachedStore struct {
+	store Store
+	ttl   time.Duration
+	clock clock.Clock
+
+	cache *lru.Cache[string, *cacheEntry]
+	// Reads from the wrapped state store in progress, by key.
+	// Values read concurrently with a write to their key are not added to the cache, as they may be stale.
+	reads map[string]*keyReads
+	lock  sync.Mutex
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// cacheEntry is a value in the cache of a CachedStore.
+type cacheEntry struct {
+	res *GetResponse
+	// Metadata of the request that read the value, which can affect the response
+	metadata map[string]string
+	expires  time.Time
+}
+
+// keyReads tracks the reads of a key from the wrapped state store that are in progress.
+type keyReads struct {
+	// Number of reads in progress
+	count int
+	// Incremented by every write to the key while it's being read
+	generation uint64
+}
+
+// NewCachedStore returns a state store that caches the values read from store.
+// The returned store also implements the optional interfaces that store implements.
+func NewCachedStore(store Store, opts CacheOptions) (*CachedStore, error) {
+	if store == nil {
+		return nil, errors.New("state store is required")
+	}
+	if opts.MaxEntries < 0 {
+		return nil, errors.New("max entries must not be negative")
+	}
+	if opts.MaxEntries == 0 {
+		opts.MaxEntries = DefaultCacheMaxEntries
+	}
+	if opts.TTL < 0 {
+		return nil, errors.New("TTL must not be negative")
+	}
+	if opts.TTL == 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+
+	cache, err := lru.New[string, *cacheEntry](opts.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CachedStore{
+		store: store,
+		ttl:   opts.TTL,
+		clock: clock.RealClock{},
+		cache: cache,
+		reads: map[string]*keyReads{},
+	}, nil
+}
+
+// Stats returns the statistics of the cache.
+func (c *CachedStore) Stats() CacheStats {
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: c.cache.Len(),
+	}
+}
+
+// Init initializes the wrapped state store.
+func (c *CachedStore) Init(ctx context.Context, metadata Metadata) error {
+	return c.store.Init(ctx, metadata)
+}
+
+// Features returns the features of the wrapped state store.
+func (c *CachedStore) Features() []Feature {
+	return c.store.Features()
+}
+
+// GetComponentMetadata returns the metadata of the wrapped state store.
+func (c *CachedStore) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	// The method is part of the Store interface only when the "metadata" build tag is present
+	if s, ok := c.store.(interface {
+		GetComponentMetadata() contribMetadata.MetadataMap
+	}); ok {
+		return s.GetComponentMetadata()
+	}
+	return
+}
+
+// Close purges the cache and closes the wrapped state store.
+func (c *CachedStore) Close() error {
+	c.invalidateAll()
+	return c.store.Close()
+}
+
+// Ping pings the wrapped state store.
+func (c *CachedStore) Ping(ctx context.Context) error {
+	return Ping(ctx, c.store)
+}
+
+// Get returns a value from the cache, or reads it from the wrapped state store.
+func (c *CachedStore) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	res, ok := c.lookup(req)
+	if ok {
+		return res, nil
+	}
+
+	generation := c.startRead(req.Key)
+	res, err := c.store.Get(ctx, req)
+	if err != nil {
+		c.endRead(req, nil, generation)
+		return nil, err
+	}
+	c.endRead(req, res, generation)
+	return res, nil
+}
+
+// Set stores a value and removes it from the cache.
+func (c *CachedStore) Set(ctx context.Context, req *SetRequest) error {
+	// The key is removed even if the write fails, as it may have failed because the cached value is stale
+	defer c.invalidate(req.Key)
+	return c.store.Set(ctx, req)
+}
+
+// Delete deletes a value and removes it from the cache.
+func (c *CachedStore) Delete(ctx context.Context, req *DeleteRequest) error {
+	defer c.invalidate(req.Key)
+	return c.store.Delete(ctx, req)
+}
+
+// BulkGet returns values from the cache, and reads the others from the wrapped state store in bulk.
+func (c *CachedStore) BulkGet(ctx context.Context, req []GetRequest, opts BulkGetOpts) ([]BulkGetResponse, error) {
+	res := make([]BulkGetResponse, len(req))
+	found := make([]bool, len(req))
+	missing := make([]GetRequest, 0, len(req))
+	for i := range req {
+		cached, ok := c.lookup(&req[i])
+		if !ok {
+			missing = append(missing, req[i])
+			continue
+		}
+		found[i] = true
+		res[i] = BulkGetResponse{
+			Key:         req[i].Key,
+			Data:        cached.Data,
+			ETag:        cached.ETag,
+			Metadata:    cached.Metadata,
+			ContentType: cached.ContentType,
+		}
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	// Reads end when the responses are matched to the requests, adding the values that were read to the cache
+	generations := make(map[int]uint64, len(missing))
+	read := make(map[int]*GetResponse, len(missing))
+	for i := range req {
+		if !found[i] {
+			generations[i] = c.startRead(req[i].Key)
+		}
+	}
+	defer func() {
+		for i, generation := range generations {
+			c.endRead(&req[i], read[i], generation)
+		}
+	}()
+
+	missingRes, err := c.store.BulkGet(ctx, missing, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Responses of the wrapped state store are matched to the requests by key, as they may not be in the same order
+	pending := make(map[string][]int, len(missing))
+	for i := range req {
+		if !found[i] {
+			pending[req[i].Key] = append(pending[req[i].Key], i)
+		}
+	}
+	var unmatched []BulkGetResponse
+	for _, item := range missingRes {
+		idx := pending[item.Key]
+		if len(idx) == 0 {
+			unmatched = append(unmatched, item)
+			continue
+		}
+		i := idx[0]
+		pending[item.Key] = idx[1:]
+
+		found[i] = true
+		res[i] = item
+		if item.Error == "" {
+			read[i] = &GetResponse{
+				Data:        item.Data,
+				ETag:        item.ETag,
+				Metadata:    item.Metadata,
+				ContentType: item.ContentType,
+			}
+		}
+	}
+
+	// Requests that the wrapped state store didn't respond to are omitted, like in its own response
+	n := 0
+	for i := range res {
+		if found[i] {
+			res[n] = res[i]
+			n++
+		}
+	}
+	return append(res[:n], unmatched...), nil
+}
+
+// BulkSet stores values in bulk and removes them from the cache.
+func (c *CachedStore) BulkSet(ctx context.Context, req []SetRequest, opts BulkStoreOpts) error {
+	defer c.invalidate(requestKeys(req)...)
+	return c.store.BulkSet(ctx, req, opts)
+}
+
+// BulkDelete deletes values in bulk and removes them from the cache.
+func (c *CachedStore) BulkDelete(ctx context.Context, req []DeleteRequest, opts BulkStoreOpts) error {
+	defer c.invalidate(requestKeys(req)...)
+	return c.store.BulkDelete(ctx, req, opts)
+}
+
+// Multi executes a transaction on the wrapped state store and removes the keys of its operations from the cache.
+func (c *CachedStore) Multi(ctx context.Context, request *TransactionalStateRequest) error {
+	transactional, ok := c.store.(TransactionalStore)
+	if !ok {
+		return errors.New("state store does not support transactions")
+	}
+
+	defer c.invalidate(requestKeys(request.Operations)...)
+	return transactional.Multi(ctx, request)
+}
+
+// MultiMaxSize returns the maximum size of a transaction of the wrapped state store, or -1 if it has no limit.
+func (c *CachedStore) MultiMaxSize() int {
+	if s, ok := c.store.(TransactionalStoreMultiMaxSize); ok {
+		return s.MultiMaxSize()
+	}
+	return -1
+}
+
+// Query executes a query on the wrapped state store.
+// Query results are not cached.
+func (c *CachedStore) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	querier, ok := c.store.(Querier)
+	if !ok {
+		return nil, errors.New("state store does not support queries")
+	}
+	return querier.Query(ctx, req)
+}
+
+// DeleteWithPrefix deletes the values with a prefix from the wrapped state store and removes them from the cache.
+func (c *CachedStore) DeleteWithPrefix(ctx context.Context, req DeleteWithPrefixRequest) (DeleteWithPrefixResponse, error) {
+	s, ok := c.store.(DeleteWithPrefix)
+	if !ok {
+		return DeleteWithPrefixResponse{}, errors.New("state store does not support deleting with prefix")
+	}
+
+	defer c.invalidatePrefix(req.Prefix)
+	return s.DeleteWithPrefix(ctx, req)
+}
+
+// ListKeys lists the keys in the wrapped state store.
+func (c *CachedStore) ListKeys(ctx context.Context, req *ListKeysRequest) (*ListKeysResponse, error) {
+	s, ok := c.store.(ListKeys)
+	if !ok {
+		return nil, errors.New("state store does not support listing keys")
+	}
+	return s.ListKeys(ctx, req)
+}
+
+// Watch watches for changes in the wrapped state store.
+func (c *CachedStore) Watch(ctx context.Context, req *WatchRequest, handler WatchHandler) error {
+	s, ok := c.store.(Watcher)
+	if !ok {
+		return errors.New("state store does not support watching keys")
+	}
+	return s.Watch(ctx, req, handler)
+}
+
+// lookup returns a copy of the cached response for req, if any.
+func (c *CachedStore) lookup(req *GetRequest) (*GetResponse, bool) {
+	if req.Options.Consistency == Strong {
+		return nil, false
+	}
+
+	entry, ok := c.cache.Get(req.Key)
+	if !ok || !maps.Equal(entry.metadata, req.Metadata) {
+		c.misses.Add(1)
+		return nil, false
+	}
+	if !c.clock.Now().Before(entry.expires) {
+		c.cache.Remove(req.Key)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	return cloneGetResponse(entry.res), true
+}
+
+// startRead registers a read of key from the wrapped state store, and returns the generation to pass to endRead.
+func (c *CachedStore) startRead(key string) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	r, ok := c.reads[key]
+	if !ok {
+		r = &keyReads{}
+		c.reads[key] = r
+	}
+	r.count++
+	return r.generation
+}
+
+// endRead ends a read started with startRead, and adds its response to the cache unless the key was written since the read started.
+// The response is nil if the read failed; responses for keys that don't exist are not cached.
+func (c *CachedStore) endRead(req *GetRequest, res *GetResponse, generation uint64) {
+	var entry *cacheEntry
+	if res != nil && len(res.Data) > 0 {
+		expires := c.clock.Now().Add(c.ttl)
+		if expireTime, err := time.Parse(time.RFC3339, res.Metadata[GetRespMetaKeyTTLExpireTime]); err == nil && expireTime.Before(expires) {
+			expires = expireTime
+		}
+		entry = &cacheEntry{
+			res:      cloneGetResponse(res),
+			metadata: maps.Clone(req.Metadata),
+			expires:  expires,
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	r := c.reads[req.Key]
+	r.count--
+	if r.count == 0 {
+		delete(c.reads, req.Key)
+	}
+	if entry != nil && r.generation == generation {
+		c.cache.Add(req.Key, entry)
+	}
+}
+
+// invalidate removes keys from the cache, and prevents the reads of those keys in progress from adding their values.
+func (c *CachedStore) invalidate(keys ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, key := range keys {
+		c.cache.Remove(key)
+		if r, ok := c.reads[key]; ok {
+			r.generation++
+		}
+	}
+}
+
+func requestKeys[T StateRequest](reqs []T) []string {
+	keys := make([]string, len(reqs))
+	for i := range reqs {
+		keys[i] = reqs[i].GetKey()
+	}
+	return keys
+}
+
+func (c *CachedStore) invalidatePrefix(prefix string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, key := range c.cache.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			c.cache.Remove(key)
+		}
+	}
+	for key, r := range c.reads {
+		if strings.HasPrefix(key, prefix) {
+			r.generation++
+		}
+	}
+}
+
+func (c *CachedStore) invalidateAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cache.Purge()
+	for _, r := range c.reads {
+		r.generation++
+	}
+}
+
+func cloneGetResponse(res *GetResponse) *GetResponse {
+	return &GetResponse{
+		Data:        slices.Clone(res.Data),
+		ETag:        clonePtr(res.ETag),
+		Metadata:    maps.Clone(res.Metadata),
+		ContentType: clonePtr(res.ContentType),
+	}
+}
+
+func clonePtr[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+
+	newStore := func(t *testing.T, opts CacheOptions) (*CachedStore, *storeMap, *clocktesting.FakeClock) {
+		inner := newStoreMap()
+		s, err := NewCachedStore(inner, opts)
+		require.NoError(t, err)
+		fakeClock := clocktesting.NewFakeClock(time.Now())
+		s.clock = fakeClock
+		return s, inner, fakeClock
+	}
+	get := func(t *testing.T, s *CachedStore, req *GetRequest) string {
+		res, err := s.Get(ctx, req)
+		require.NoError(t, err)
+		return string(res.Data)
+	}
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewCachedStore(newStoreMap(), CacheOptions{MaxEntries: -1})
+		require.Error(t, err)
+		_, err = NewCachedStore(newStoreMap(), CacheOptions{TTL: -time.Second})
+		require.Error(t, err)
+	})
+
+	t.Run("get and set", func(t *testing.T) {
+		s, inner, _ := newStore(t, CacheOptions{})
+		require.NoError(t, s.Set(ctx, &SetRequest{Key: "key", Value: []byte("one")}))
+
+		assert.Equal(t, "one", get(t, s, &GetRequest{Key: "key"}))
+		res, err := s.Get(ctx, &GetRequest{Key: "key"})
+		require.NoError(t, err)
+		assert.Equal(t, "one", string(res.Data))
+		require.NotNil(t, res.ETag)
+		assert.Equal(t, "1", *res.ETag)
+		assert.Equal(t, int32(1), inner.gets.Load())
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Entries: 1}, s.Stats())
+
+		// Responses are copies of the cached values
+		res.Data[0] = 'X'
+		assert.Equal(t, "one", get(t, s, &GetRequest{Key: "key"}))
+
+		// Writes remove the value from the cache
+		require.NoError(t, s.Set(ctx, &SetRequest{Key: "key", Value: []byte("two")}))
+		assert.Equal(t, "two", get(t, s, &GetRequest{Key: "key"}))
+		assert.Equal(t, int32(2), inner.gets.Load())
+		require.NoError(t, s.Delete(ctx, &DeleteRequest{Key: "key"}))
+		assert.Empty(t, get(t, s, &GetRequest{Key: "key"}))
+
+		// Missing keys are not cached
+		assert.Empty(t, get(t, s, &GetRequest{Key: "key"}))
+		assert.Equal(t, int32(4), inner.gets.Load())
+	})
+
+	t.Run("strong consistency", func(t *testing.T) {
+		s, inner, _ := newStore(t, CacheOptions{})
+		require.NoError(t, s.Set(ctx, &SetRequest{Key: "key", Value: []byte("one")}))
+		assert.Equal(t, "one", get(t, s, &GetRequest{Key: "key"}))
+
+		// Changes made directly in the wrapped store are visible with strong consistency only
+		require.NoError(t, inner.Set(ctx, &SetRequest{Key: "key", Value: []byte("two")}))
+		assert.Equal(t, "one", get(t, s, &GetRequest{Key: "key"}))
+		assert.Equal(t, "two", get(t, s, &GetRequest{Key: "key", Options: GetStateOption{Consistency: Strong}}))
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Entries: 1}, s.Stats())
+
+		// Strong reads refresh the cache
+		assert.Equal(t, "two", get(t, s, &GetRequest{Key: "key"}))
+	})
+
+	t.Run("request metadata", func(t *testing.T) {
+		s, inner, _ := newStore(t, CacheOptions{})
+		require.NoError(t, s.Set(ctx, &SetRequest{Key: "key", Value: []byte("one")}))
+		get(t, s, &GetRequest{Key: "key", Metadata: map[string]string{"partitionKey": "a"}})
+		get(t, s, &GetRequest{Key: "key", Metadata: map[string]string{"partitionKey": "a"}})
+		get(t, s, &GetRequest{Key: "key", Metadata: map[string]string{"partitionKey": "b"}})
+		assert.Equal(t, int32(2), inner.gets.Load())
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		s, inner, fakeClock := newStore(t, CacheOptions{TTL: 10 * time.Second})
+		require.NoError(t, s.Set(ctx, &SetRequest{Key: "key", Value: []byte("one")}))
+		get(t, s, &GetRequest{Key: "key"})
+		fakeClock.Step(5 * time.Second)
+		get(t, s, &GetRequest{Key: "key"})
+		assert.Equal(t, int32(1), inner.gets.Load())
+		fakeClock.Step(5 * time.Second)
+		get(t, s, &GetRequest{Key: "key"})
+		assert.Equal(t, int32(2), inner.gets.Load())
+
+		// Values expire from the cache when they expire in the wrapped store
+		res := &GetResponse{
+			Data:     []byte("ttl"),
+			Metadata: map[string]string{GetRespMetaKeyTTLExpireTime: fakeClock.Now().Add(2 * time.Second).Format(time.RFC3339)},
+		}
+		s.endRead(&GetRequest{Key: "ttl"}, res, s.startRead("ttl"))
+		_, ok := s.lookup(&GetRequest{Key: "ttl"})
+		assert.True(t, ok)
+		fakeClock.Step(2 * time.Second)
+		_, ok = s.lookup(&GetRequest{Key: "ttl"})
+		assert.False(t, ok)
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		s, _, _ := newStore(t, CacheOptions{MaxEntries: 2})
+		for _, key := range []string{"a", "b", "c"} {
+			require.NoError(t, s.Set(ctx, &SetRequest{Key: key, Value: []byte(key)}))
+			get(t, s, &GetRequest{Key: key})
+		}
+		assert.Equal(t, 2, s.Stats().Entries)
+		_, ok := s.lookup(&GetRequest{Key: "a"})
+		assert.False(t, ok)
+	})
+
+	t.Run("concurrent writes", func(t *testing.T) {
+		s, _, _ := newStore(t, CacheOptions{})
+
+		// Values read concurrently with a write to their key are not cached
+		generation := s.startRead("key")
+		require.NoError(t, s.Set(ctx, &SetRequest{Key: "key", Value: []byte("one")}))
+		s.endRead(&GetRequest{Key: "key"}, &GetResponse{Data: []byte("stale")}, generation)
+		assert.Equal(t, 0, s.Stats().Entries)
+
+		// Writes to other keys don't prevent caching
+		generation = s.startRead("key")
+		require.NoError(t, s.Set(ctx, &SetRequest{Key: "other", Value: []byte("two")}))
+		s.endRead(&GetRequest{Key: "key"}, &GetResponse{Data: []byte("one")}, generation)
+		assert.Equal(t, 1, s.Stats().Entries)
+		assert.Empty(t, s.reads)
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		s, inner, _ := newStore(t, CacheOptions{})
+		require.NoError(t, s.BulkSet(ctx, []SetRequest{
+			{Key: "a", Value: []byte("a")},
+			{Key: "b", Value: []byte("b")},
+		}, BulkStoreOpts{}))
+		get(t, s, &GetRequest{Key: "b"})
+
+		res, err := s.BulkGet(ctx, []GetRequest{{Key: "a"}, {Key: "b"}, {Key: "c"}}, BulkGetOpts{})
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+		assert.Equal(t, "a", res[0].Key)
+		assert.Equal(t, "a", string(res[0].Data))
+		assert.Equal(t, "b", res[1].Key)
+		assert.Equal(t, "b", string(res[1].Data))
+		assert.Equal(t, "c", res[2].Key)
+		assert.Empty(t, res[2].Data)
+		assert.Equal(t, int32(3), inner.gets.Load())
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 3, Entries: 2}, s.Stats())
+
+		require.NoError(t, s.BulkDelete(ctx, []DeleteRequest{{Key: "a"}}, BulkStoreOpts{}))
+		assert.Equal(t, 1, s.Stats().Entries)
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		s, _, _ := newStore(t, CacheOptions{})
+		require.NoError(t, s.Set(ctx, &SetRequest{Key: "a", Value: []byte("a")}))
+		require.NoError(t, s.Set(ctx, &SetRequest{Key: "b", Value: []byte("b")}))
+		get(t, s, &GetRequest{Key: "a"})
+		get(t, s, &GetRequest{Key: "b"})
+
+		err := s.Multi(ctx, &TransactionalStateRequest{
+			Operations: []TransactionalStateOperation{
+				SetRequest{Key: "a", Value: []byte("a2")},
+				DeleteRequest{Key: "b"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, s.Stats().Entries)
+		assert.Equal(t, "a2", get(t, s, &GetRequest{Key: "a"}))
+		assert.Empty(t, get(t, s, &GetRequest{Key: "b"}))
+	})
+}
@@ -0,0 +1,499 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state/utils"
+	internals "github.com/dapr/kit/crypto"
+)
+
+const (
+	// Version of the format of values stored by EncryptedStore.
+	encryptedValueVersion = 1
+	// Size of the data encryption keys, for AES-256-GCM.
+	encryptionDataKeySize = 32
+	// Maximum number of values encrypted with the same data encryption key.
+	// This is well below the limit for AES-GCM with random nonces.
+	encryptionDataKeyMaxUses = 1 << 24
+	// Maximum number of unwrapped data encryption keys kept in memory.
+	encryptionUnwrappedKeysCacheSize = 1000
+)
+
+// ErrValueNotEncrypted is returned when reading a value that was not stored by an EncryptedStore.
+var ErrValueNotEncrypted = errors.New("value is not encrypted")
+
+// EncryptionOptions contains the options for NewEncryptedStore.
+type EncryptionOptions struct {
+	// Crypto component used to wrap and unwrap the data encryption keys.
+	Crypto contribCrypto.SubtleCrypto
+	// Name of the key encryption key in the crypto component, optionally including its version as "name/version".
+	// The name is stored alongside each value, so the key can be rotated by changing this option: new values are encrypted with the new key, while existing values are still decrypted with the key they were encrypted with.
+	KeyName string
+	// Algorithm used to wrap the data encryption keys with the key encryption key, such as "A256KW" or "RSA-OAEP-256".
+	KeyWrapAlgorithm string
+}
+
+// EncryptedStore is a state store that encrypts values before they are stored in another state store.
+// Values are encrypted with AES-256-GCM using data encryption keys, which are wrapped with a key encryption key managed by a crypto component (envelope encryption).
+// The key of each item is used as associated data, so encrypted values can't be moved to a different key.
+// Queries are executed on the encrypted values, so they can only list items, without filters, sorting or projections.
+type EncryptedStore struct {
+	store Store
+	opts  EncryptionOptions
+
+	// Data encryption key used for new values
+	dataKey     *encryptionDataKey
+	dataKeyLock sync.Mutex
+
+	// Unwrapped data encryption keys used to decrypt values, indexed by key encryption key and wrapped key
+	unwrappedKeys     map[string]cipher.AEAD
+	unwrappedKeysLock sync.RWMutex
+}
+
+// encryptionDataKey is a data encryption key used to encrypt values.
+type encryptionDataKey struct {
+	aead    cipher.AEAD
+	wrapped encryptedValue
+	uses    int
+}
+
+// encryptedValue is the format of values stored by EncryptedStore.
+type encryptedValue struct {
+	Version int `json:"v"`
+	// Name (and version) of the key encryption key
+	KeyName string `json:"kid"`
+	// Algorithm used to wrap the data encryption key
+	KeyWrapAlgorithm string `json:"alg"`
+	// Wrapped data encryption key, with the nonce and tag used to wrap it if any
+	WrappedKey []byte `json:"wk"`
+	WrapNonce  []byte `json:"wn,omitempty"`
+	WrapTag    []byte `json:"wt,omitempty"`
+	// Nonce and ciphertext of the value, encrypted with the data encryption key
+	Nonce      []byte `json:"n"`
+	Ciphertext []byte `json:"ct"`
+}
+
+// NewEncryptedStore returns a state store that encrypts the values stored in store.
+// The returned store also implements the optional interfaces that store implements.
+func NewEncryptedStore(store Store, opts EncryptionOptions) (Store, error) {
+	if store == nil {
+		return nil, errors.New("state store is required")
+	}
+	if opts.Crypto == nil {
+		return nil, errors.New("crypto component is required")
+	}
+	if opts.KeyName == "" {
+		return nil, errors.New("key name is required")
+	}
+	if opts.KeyWrapAlgorithm == "" {
+		return nil, errors.New("key wrap algorithm is required")
+	}
+
+	return &EncryptedStore{
+		store:         store,
+		opts:          opts,
+		unwrappedKeys: make(map[string]cipher.AEAD),
+	}, nil
+}
+
+// Init initializes the wrapped state store.
+func (e *EncryptedStore) Init(ctx context.Context, metadata Metadata) error {
+	return e.store.Init(ctx, metadata)
+}
+
+// Features returns the features of the wrapped state store, except the query API, as encrypted values can't be filtered or sorted.
+func (e *EncryptedStore) Features() []Feature {
+	return slices.DeleteFunc(slices.Clone(e.store.Features()), func(f Feature) bool {
+		return f == FeatureQueryAPI
+	})
+}
+
+// GetComponentMetadata returns the metadata of the wrapped state store.
+func (e *EncryptedStore) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	// The method is part of the Store interface only when the "metadata" build tag is present
+	if s, ok := e.store.(interface {
+		GetComponentMetadata() contribMetadata.MetadataMap
+	}); ok {
+		return s.GetComponentMetadata()
+	}
+	return
+}
+
+// Close closes the wrapped state store.
+// The crypto component is not closed, as it's not owned by the store.
+func (e *EncryptedStore) Close() error {
+	return e.store.Close()
+}
+
+// Ping pings the wrapped state store.
+func (e *EncryptedStore) Ping(ctx context.Context) error {
+	return Ping(ctx, e.store)
+}
+
+// Get retrieves and decrypts a value.
+func (e *EncryptedStore) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	res, err := e.store.Get(ctx, req)
+	if err != nil || res == nil || len(res.Data) == 0 {
+		return res, err
+	}
+
+	res.Data, err = e.decrypt(ctx, req.Key, res.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value for key '%s': %w", req.Key, err)
+	}
+	return res, nil
+}
+
+// Set encrypts and stores a value.
+func (e *EncryptedStore) Set(ctx context.Context, req *SetRequest) error {
+	encReq, err := e.encryptSetRequest(ctx, *req)
+	if err != nil {
+		return err
+	}
+	return e.store.Set(ctx, &encReq)
+}
+
+// Delete deletes a value.
+func (e *EncryptedStore) Delete(ctx context.Context, req *DeleteRequest) error {
+	return e.store.Delete(ctx, req)
+}
+
+// BulkGet retrieves and decrypts values in bulk.
+// Values that can't be decrypted are returned with an error.
+func (e *EncryptedStore) BulkGet(ctx context.Context, req []GetRequest, opts BulkGetOpts) ([]BulkGetResponse, error) {
+	res, err := e.store.BulkGet(ctx, req, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range res {
+		if res[i].Error != "" || len(res[i].Data) == 0 {
+			continue
+		}
+		res[i].Data, err = e.decrypt(ctx, res[i].Key, res[i].Data)
+		if err != nil {
+			res[i].Data = nil
+			res[i].Error = "failed to decrypt value: " + err.Error()
+		}
+	}
+	return res, nil
+}
+
+// BulkSet encrypts and stores values in bulk.
+func (e *EncryptedStore) BulkSet(ctx context.Context, req []SetRequest, opts BulkStoreOpts) error {
+	encReq := make([]SetRequest, len(req))
+	for i := range req {
+		var err error
+		encReq[i], err = e.encryptSetRequest(ctx, req[i])
+		if err != nil {
+			return err
+		}
+	}
+	return e.store.BulkSet(ctx, encReq, opts)
+}
+
+// BulkDelete deletes values in bulk.
+func (e *EncryptedStore) BulkDelete(ctx context.Context, req []DeleteRequest, opts BulkStoreOpts) error {
+	return e.store.BulkDelete(ctx, req, opts)
+}
+
+// Multi encrypts the values of the set operations and executes the transaction on the wrapped state store.
+func (e *EncryptedStore) Multi(ctx context.Context, request *TransactionalStateRequest) error {
+	transactional, ok := e.store.(TransactionalStore)
+	if !ok {
+		return errors.New("state store does not support transactions")
+	}
+
+	encRequest := &TransactionalStateRequest{
+		Operations: make([]TransactionalStateOperation, len(request.Operations)),
+		Metadata:   request.Metadata,
+	}
+	for i, op := range request.Operations {
+		setReq, ok := op.(SetRequest)
+		if !ok {
+			encRequest.Operations[i] = op
+			continue
+		}
+		encReq, err := e.encryptSetRequest(ctx, setReq)
+		if err != nil {
+			return err
+		}
+		encRequest.Operations[i] = encReq
+	}
+	return transactional.Multi(ctx, encRequest)
+}
+
+// MultiMaxSize returns the maximum size of a transaction of the wrapped state store, or -1 if it has no limit.
+func (e *EncryptedStore) MultiMaxSize() int {
+	if s, ok := e.store.(TransactionalStoreMultiMaxSize); ok {
+		return s.MultiMaxSize()
+	}
+	return -1
+}
+
+// Query executes a query on the wrapped state store and decrypts the values in the results.
+// Values that can't be decrypted are returned with an error.
+// Queries can only list items, as the state store can't read the fields of encrypted values.
+func (e *EncryptedStore) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	querier, ok := e.store.(Querier)
+	if !ok {
+		return nil, errors.New("state store does not support queries")
+	}
+	q := req.Query.QueryFields
+	if len(q.Filters) > 0 || len(q.Sort) > 0 || len(q.Projection) > 0 || len(q.Aggregations) > 0 || len(q.GroupBy) > 0 {
+		return nil, errors.New("queries on encrypted values can't reference their fields")
+	}
+
+	res, err := querier.Query(ctx, req)
+	if err != nil || res == nil {
+		return res, err
+	}
+	for i := range res.Results {
+		item := &res.Results[i]
+		if item.Error != "" || len(item.Data) == 0 {
+			continue
+		}
+		item.Data, err = e.decrypt(ctx, item.Key, item.Data)
+		if err != nil {
+			item.Data = nil
+			item.Error = "failed to decrypt value: " + err.Error()
+		}
+	}
+	return res, nil
+}
+
+// DeleteWithPrefix deletes the values with a prefix from the wrapped state store.
+func (e *EncryptedStore) DeleteWithPrefix(ctx context.Context, req DeleteWithPrefixRequest) (DeleteWithPrefixResponse, error) {
+	s, ok := e.store.(DeleteWithPrefix)
+	if !ok {
+		return DeleteWithPrefixResponse{}, errors.New("state store does not support deleting with prefix")
+	}
+	return s.DeleteWithPrefix(ctx, req)
+}
+
+// ListKeys lists the keys in the wrapped state store.
+// Keys are not encrypted.
+func (e *EncryptedStore) ListKeys(ctx context.Context, req *ListKeysRequest) (*ListKeysResponse, error) {
+	s, ok := e.store.(ListKeys)
+	if !ok {
+		return nil, errors.New("state store does not support listing keys")
+	}
+	return s.ListKeys(ctx, req)
+}
+
+// Watch watches for changes in the wrapped state store.
+// Events don't contain values, so they don't need to be decrypted.
+func (e *EncryptedStore) Watch(ctx context.Context, req *WatchRequest, handler WatchHandler) error {
+	s, ok := e.store.(Watcher)
+	if !ok {
+		return errors.New("state store does not support watching keys")
+	}
+	return s.Watch(ctx, req, handler)
+}
+
+// encryptSetRequest returns a copy of req with the value encrypted.
+func (e *EncryptedStore) encryptSetRequest(ctx context.Context, req SetRequest) (SetRequest, error) {
+	plaintext, err := utils.Marshal(req.Value, json.Marshal)
+	if err != nil {
+		return req, fmt.Errorf("failed to serialize value for key '%s': %w", req.Key, err)
+	}
+	req.Value, err = e.encrypt(ctx, req.Key, plaintext)
+	if err != nil {
+		return req, fmt.Errorf("failed to encrypt value for key '%s': %w", req.Key, err)
+	}
+
+	// Encrypted values are opaque to the wrapped store, which must not try to parse them
+	if _, ok := req.Metadata[contribMetadata.ContentType]; ok {
+		req.Metadata = maps.Clone(req.Metadata)
+		delete(req.Metadata, contribMetadata.ContentType)
+	}
+	return req, nil
+}
+
+func (e *EncryptedStore) encrypt(ctx context.Context, key string, plaintext []byte) ([]byte, error) {
+	aead, value, err := e.getDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	value.Nonce = make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, value.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	value.Ciphertext = aead.Seal(nil, value.Nonce, plaintext, []byte(key))
+
+	return json.Marshal(value)
+}
+
+func (e *EncryptedStore) decrypt(ctx context.Context, key string, data []byte) ([]byte, error) {
+	var value encryptedValue
+	err := json.Unmarshal(data, &value)
+	if err != nil || value.Version == 0 {
+		return nil, ErrValueNotEncrypted
+	}
+	if value.Version != encryptedValueVersion {
+		return nil, fmt.Errorf("unsupported encrypted value version %d", value.Version)
+	}
+
+	aead, err := e.unwrapDataKey(ctx, &value)
+	if err != nil {
+		return nil, err
+	}
+	if len(value.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+	return aead.Open(nil, value.Nonce, value.Ciphertext, []byte(key))
+}
+
+// getDataKey returns the data encryption key for new values, and the value with its wrapped form.
+// Data encryption keys are reused for multiple values, so the key encryption key is used only when they are rotated.
+func (e *EncryptedStore) getDataKey(ctx context.Context) (cipher.AEAD, encryptedValue, error) {
+	e.dataKeyLock.Lock()
+	defer e.dataKeyLock.Unlock()
+
+	if e.dataKey == nil || e.dataKey.uses >= encryptionDataKeyMaxUses {
+		dataKey, err := e.newDataKey(ctx)
+		if err != nil {
+			return nil, encryptedValue{}, err
+		}
+		e.dataKey = dataKey
+	}
+	e.dataKey.uses++
+	return e.dataKey.aead, e.dataKey.wrapped, nil
+}
+
+func (e *EncryptedStore) newDataKey(ctx context.Context) (*encryptionDataKey, error) {
+	rawKey := make([]byte, encryptionDataKeySize)
+	_, err := io.ReadFull(rand.Reader, rawKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data encryption key: %w", err)
+	}
+	aead, err := newEncryptionAEAD(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintextKey, err := jwk.FromRaw(rawKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from data encryption key: %w", err)
+	}
+
+	var wrapNonce []byte
+	if n := keyWrapNonceSize(e.opts.KeyWrapAlgorithm); n > 0 {
+		wrapNonce = make([]byte, n)
+		_, err = io.ReadFull(rand.Reader, wrapNonce)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+	}
+	wrappedKey, tag, err := e.opts.Crypto.WrapKey(ctx, plaintextKey, e.opts.KeyWrapAlgorithm, e.opts.KeyName, wrapNonce, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data encryption key: %w", err)
+	}
+
+	dataKey := &encryptionDataKey{
+		aead: aead,
+		wrapped: encryptedValue{
+			Version:          encryptedValueVersion,
+			KeyName:          e.opts.KeyName,
+			KeyWrapAlgorithm: e.opts.KeyWrapAlgorithm,
+			WrappedKey:       wrappedKey,
+			WrapNonce:        wrapNonce,
+			WrapTag:          tag,
+		},
+	}
+	e.cacheUnwrappedKey(&dataKey.wrapped, aead)
+	return dataKey, nil
+}
+
+// unwrapDataKey returns the data encryption key used to encrypt value.
+func (e *EncryptedStore) unwrapDataKey(ctx context.Context, value *encryptedValue) (cipher.AEAD, error) {
+	e.unwrappedKeysLock.RLock()
+	aead, ok := e.unwrappedKeys[unwrappedKeysCacheKey(value)]
+	e.unwrappedKeysLock.RUnlock()
+	if ok {
+		return aead, nil
+	}
+
+	plaintextKey, err := e.opts.Crypto.UnwrapKey(ctx, value.WrappedKey, value.KeyWrapAlgorithm, value.KeyName, value.WrapNonce, value.WrapTag, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data encryption key with key '%s': %w", value.KeyName, err)
+	}
+	var rawKey []byte
+	err = plaintextKey.Raw(&rawKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unwrapped data encryption key: %w", err)
+	}
+	aead, err = newEncryptionAEAD(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	e.cacheUnwrappedKey(value, aead)
+	return aead, nil
+}
+
+func (e *EncryptedStore) cacheUnwrappedKey(value *encryptedValue, aead cipher.AEAD) {
+	e.unwrappedKeysLock.Lock()
+	defer e.unwrappedKeysLock.Unlock()
+
+	// The cache is reset when full, as keys are rotated rarely
+	if len(e.unwrappedKeys) >= encryptionUnwrappedKeysCacheSize {
+		clear(e.unwrappedKeys)
+	}
+	e.unwrappedKeys[unwrappedKeysCacheKey(value)] = aead
+}
+
+func unwrappedKeysCacheKey(value *encryptedValue) string {
+	return value.KeyName + "\x00" + string(value.WrappedKey)
+}
+
+func newEncryptionAEAD(rawKey []byte) (cipher.AEAD, error) {
+	if len(rawKey) != encryptionDataKeySize {
+		return nil, fmt.Errorf("invalid data encryption key size %d", len(rawKey))
+	}
+	block, err := aes.NewCipher(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyWrapNonceSize returns the size of the nonce required by the key wrap algorithm, or 0 if it doesn't use a nonce.
+func keyWrapNonceSize(algorithm string) int {
+	switch algorithm {
+	case internals.Algorithm_A128GCMKW, internals.Algorithm_A192GCMKW, internals.Algorithm_A256GCMKW, internals.Algorithm_C20PKW:
+		return 12
+	case internals.Algorithm_XC20PKW:
+		return 24
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/components-contrib/crypto/jwks"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state/query"
+	"github.com/dapr/kit/logger"
+)
+
+const testEncryptionJWKS = `{"keys":[
+{"kid":"key1","kty":"oct","k":"BXZccOp90mxiqNp84mx1lPUVpr5EUaqQU7KxFIdV3YA"},
+{"kid":"key2","kty":"oct","k":"XxDAA8dPUj67-H7QYiFTYEnd-2gICFONQh3fkg1nrD8"}
+]}`
+
+func TestEncryptedStore(t *testing.T) {
+	ctx := context.Background()
+
+	crypto := jwks.NewJWKSCrypto(logger.NewLogger("test"))
+	err := crypto.Init(ctx, contribCrypto.Metadata{Base: metadata.Base{
+		Properties: map[string]string{"jwks": testEncryptionJWKS},
+	}})
+	require.NoError(t, err)
+	defer crypto.Close()
+
+	inner := newStoreMap()
+	newStore := func(t *testing.T, keyName string, algorithm string) Store {
+		s, err := NewEncryptedStore(inner, EncryptionOptions{
+			Crypto:           crypto,
+			KeyName:          keyName,
+			KeyWrapAlgorithm: algorithm,
+		})
+		require.NoError(t, err)
+		return s
+	}
+	store := newStore(t, "key1", "A256KW")
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewEncryptedStore(inner, EncryptionOptions{Crypto: crypto, KeyWrapAlgorithm: "A256KW"})
+		require.Error(t, err)
+		_, err = NewEncryptedStore(inner, EncryptionOptions{KeyName: "key1", KeyWrapAlgorithm: "A256KW"})
+		require.Error(t, err)
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		err := store.Set(ctx, &SetRequest{
+			Key:      "key",
+			Value:    map[string]string{"hello": "world"},
+			Metadata: map[string]string{metadata.ContentType: "application/json"},
+		})
+		require.NoError(t, err)
+
+		stored := inner.data["key"]
+		assert.NotContains(t, string(stored), "world")
+		assert.NotContains(t, inner.metadata["key"], metadata.ContentType)
+		var value encryptedValue
+		require.NoError(t, json.Unmarshal(stored, &value))
+		assert.Equal(t, "key1", value.KeyName)
+		assert.Equal(t, "A256KW", value.KeyWrapAlgorithm)
+
+		res, err := store.Get(ctx, &GetRequest{Key: "key"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"hello":"world"}`, string(res.Data))
+
+		res, err = store.Get(ctx, &GetRequest{Key: "missing"})
+		require.NoError(t, err)
+		assert.Empty(t, res.Data)
+	})
+
+	t.Run("values are bound to their key", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, &SetRequest{Key: "original", Value: []byte("secret")}))
+		inner.data["moved"] = inner.data["original"]
+
+		_, err := store.Get(ctx, &GetRequest{Key: "moved"})
+		require.Error(t, err)
+	})
+
+	t.Run("unencrypted values", func(t *testing.T) {
+		require.NoError(t, inner.Set(ctx, &SetRequest{Key: "plain", Value: []byte("hello")}))
+
+		_, err := store.Get(ctx, &GetRequest{Key: "plain"})
+		require.ErrorIs(t, err, ErrValueNotEncrypted)
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		err := store.BulkSet(ctx, []SetRequest{
+			{Key: "bulk1", Value: "one"},
+			{Key: "bulk2", Value: "two"},
+		}, BulkStoreOpts{})
+		require.NoError(t, err)
+
+		res, err := store.BulkGet(ctx, []GetRequest{{Key: "bulk1"}, {Key: "bulk2"}, {Key: "plain"}}, BulkGetOpts{})
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+		assert.Equal(t, `"one"`, string(res[0].Data))
+		assert.Equal(t, `"two"`, string(res[1].Data))
+		assert.Empty(t, res[2].Data)
+		assert.NotEmpty(t, res[2].Error)
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		err := store.(TransactionalStore).Multi(ctx, &TransactionalStateRequest{
+			Operations: []TransactionalStateOperation{
+				SetRequest{Key: "multi1", Value: []byte("one")},
+				DeleteRequest{Key: "bulk1"},
+			},
+		})
+		require.NoError(t, err)
+		assert.NotContains(t, string(inner.data["multi1"]), "one")
+		assert.NotContains(t, inner.data, "bulk1")
+
+		res, err := store.Get(ctx, &GetRequest{Key: "multi1"})
+		require.NoError(t, err)
+		assert.Equal(t, "one", string(res.Data))
+	})
+
+	t.Run("query", func(t *testing.T) {
+		assert.False(t, FeatureQueryAPI.IsPresent(store.Features()))
+		assert.True(t, FeatureTransactional.IsPresent(store.Features()))
+
+		_, err := store.(Querier).Query(ctx, &QueryRequest{Query: query.Query{QueryFields: query.QueryFields{
+			Filters: map[string]any{"EQ": map[string]any{"hello": "world"}},
+		}}})
+		require.Error(t, err)
+
+		res, err := store.(Querier).Query(ctx, &QueryRequest{})
+		require.NoError(t, err)
+		for _, item := range res.Results {
+			switch item.Key {
+			case "multi1":
+				assert.Equal(t, "one", string(item.Data))
+				assert.Empty(t, item.Error)
+			case "plain", "moved":
+				assert.Empty(t, item.Data)
+				assert.NotEmpty(t, item.Error)
+			}
+		}
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, &SetRequest{Key: "rotated1", Value: []byte("one")}))
+
+		// Values encrypted with the previous key can still be decrypted
+		rotated := newStore(t, "key2", "C20PKW")
+		require.NoError(t, rotated.Set(ctx, &SetRequest{Key: "rotated2", Value: []byte("two")}))
+		var value encryptedValue
+		require.NoError(t, json.Unmarshal(inner.data["rotated2"], &value))
+		assert.Equal(t, "key2", value.KeyName)
+		assert.Len(t, value.WrapNonce, 12)
+		assert.NotEmpty(t, value.WrapTag)
+
+		res, err := rotated.Get(ctx, &GetRequest{Key: "rotated1"})
+		require.NoError(t, err)
+		assert.Equal(t, "one", string(res.Data))
+		res, err = rotated.Get(ctx, &GetRequest{Key: "rotated2"})
+		require.NoError(t, err)
+		assert.Equal(t, "two", string(res.Data))
+	})
+
+	t.Run("unsupported optional interfaces", func(t *testing.T) {
+		s, err := NewEncryptedStore(&storeBulk{}, EncryptionOptions{Crypto: crypto, KeyName: "key1", KeyWrapAlgorithm: "A256KW"})
+		require.NoError(t, err)
+		require.Error(t, s.(TransactionalStore).Multi(ctx, &TransactionalStateRequest{}))
+		_, err = s.(Querier).Query(ctx, &QueryRequest{})
+		require.Error(t, err)
+		_, err = s.(ListKeys).ListKeys(ctx, &ListKeysRequest{})
+		require.Error(t, err)
+	})
+}
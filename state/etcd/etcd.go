@@ -77,6 +77,8 @@ func newETCD(logger logger.Logger, schema schemaMarshaller) state.Store {
 			state.FeatureETag,
 			state.FeatureTransactional,
 			state.FeatureTTL,
+			state.FeatureWatch,
+			state.FeatureKeyListing,
 		},
 	}
 	s.BulkStore = state.NewDefaultBulkStore(s)
@@ -175,6 +177,45 @@ func (e *Etcd) Get(ctx context.Context, req *state.GetRequest) (*state.GetRespon
 	}, nil
 }
 
+// ListKeys retrieves the keys of Etcd KV items, sorted by key.
+// The continuation token is the last key of the previous page.
+func (e *Etcd) ListKeys(ctx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	prefixWithPath := e.keyPrefixPath + "/" + req.Prefix
+	start := prefixWithPath
+	if req.ContinuationToken != "" {
+		// Start right after the last key of the previous page
+		start = e.keyPrefixPath + "/" + req.ContinuationToken + "\x00"
+		if start < prefixWithPath {
+			start = prefixWithPath
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := e.client.Get(ctx, start,
+		clientv3.WithRange(clientv3.GetPrefixRangeEnd(prefixWithPath)),
+		clientv3.WithKeysOnly(),
+		clientv3.WithLimit(int64(req.PageSize)+1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list keys with prefix %s: %w", prefixWithPath, err)
+	}
+
+	keys := make([]string, len(resp.Kvs))
+	for i, kv := range resp.Kvs {
+		keys[i] = strings.TrimPrefix(string(kv.Key), e.keyPrefixPath+"/")
+	}
+	res := &state.ListKeysResponse{}
+	res.Keys, res.ContinuationToken = stateutils.KeysPage(keys, req.PageSize)
+	return res, nil
+}
+
 // Set saves a Etcd KV item.
 func (e *Etcd) Set(ctx context.Context, req *state.SetRequest) error {
 	ttlInSeconds, err := e.doSetValidateParameters(req)
@@ -306,6 +347,77 @@ func (e *Etcd) doValidateEtag(key string, etag *string, concurrency string) erro
 	return nil
 }
 
+// Watch invokes handler for each change to the keys that match the request, until ctx is canceled.
+// Changes are received with a native etcd watch, and the ETag of set events is the revision of the key.
+func (e *Etcd) Watch(ctx context.Context, req *state.WatchRequest, handler state.WatchHandler) error {
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	keyWithPath := e.keyPrefixPath + "/" + req.Key
+	opts := []clientv3.OpOption{clientv3.WithCreatedNotify()}
+	if req.Prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	// The watch is canceled if it can't be established, or when the context is canceled
+	// Requiring a leader makes the watch fail rather than hang if the member is partitioned from the cluster
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	watchCh := e.client.Watch(clientv3.WithRequireLeader(watchCtx), keyWithPath, opts...)
+
+	// Wait for the watch to be established
+	createdCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	select {
+	case res, ok := <-watchCh:
+		if !ok {
+			watchCancel()
+			return fmt.Errorf("couldn't watch key %s: %w", keyWithPath, ctx.Err())
+		}
+		if err = res.Err(); err != nil {
+			watchCancel()
+			return fmt.Errorf("couldn't watch key %s: %w", keyWithPath, err)
+		}
+	case <-createdCtx.Done():
+		watchCancel()
+		return fmt.Errorf("couldn't watch key %s: %w", keyWithPath, createdCtx.Err())
+	}
+
+	go func() {
+		defer watchCancel()
+
+		// The channel is closed when the context is canceled or the client is closed
+		for res := range watchCh {
+			if err := res.Err(); err != nil {
+				e.logger.Errorf("Error watching key %s: %v", keyWithPath, err)
+				continue
+			}
+			for _, ev := range res.Events {
+				err := handler(ctx, e.toWatchEvent(ev))
+				if err != nil {
+					e.logger.Errorf("Error from the handler of watch for key %s: %v", keyWithPath, err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (e *Etcd) toWatchEvent(ev *clientv3.Event) *state.WatchEvent {
+	event := &state.WatchEvent{
+		Key: strings.TrimPrefix(string(ev.Kv.Key), e.keyPrefixPath+"/"),
+	}
+	if ev.Type == clientv3.EventTypeDelete {
+		// This includes keys deleted when their lease expires
+		event.Type = state.WatchEventDelete
+	} else {
+		event.Type = state.WatchEventSet
+		event.ETag = ptr.Of(strconv.Itoa(int(ev.Kv.ModRevision)))
+	}
+	return event
+}
+
 func (e *Etcd) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
 	metadataStruct := etcdConfig{}
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.StateStoreType)
@@ -30,6 +30,10 @@ const (
 	FeatureDeleteWithPrefix Feature = "DELETE_WITH_PREFIX"
 	// FeaturePartitionKey is the feature that supports the partition
 	FeaturePartitionKey Feature = "PARTITION_KEY"
+	// FeatureWatch is the feature that supports watching for changes to keys.
+	FeatureWatch Feature = "WATCH"
+	// FeatureKeyListing is the feature that supports listing keys.
+	FeatureKeyListing Feature = "KEY_LISTING"
 )
 
 // Feature names a feature that can be implemented by state store components.
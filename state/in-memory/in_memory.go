@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -37,13 +38,14 @@ import (
 type inMemoryStore struct {
 	state.BulkStore
 
-	items   map[string]*inMemStateStoreItem
-	lock    sync.RWMutex
-	log     logger.Logger
-	clock   clock.Clock
-	closeCh chan struct{}
-	closed  atomic.Bool
-	wg      sync.WaitGroup
+	items    map[string]*inMemStateStoreItem
+	watchers map[*inMemWatcher]struct{}
+	lock     sync.RWMutex
+	log      logger.Logger
+	clock    clock.Clock
+	closeCh  chan struct{}
+	closed   atomic.Bool
+	wg       sync.WaitGroup
 }
 
 func NewInMemoryStateStore(log logger.Logger) state.Store {
@@ -52,10 +54,11 @@ func NewInMemoryStateStore(log logger.Logger) state.Store {
 
 func newStateStore(log logger.Logger) *inMemoryStore {
 	s := &inMemoryStore{
-		items:   map[string]*inMemStateStoreItem{},
-		log:     log,
-		closeCh: make(chan struct{}),
-		clock:   clock.RealClock{},
+		items:    map[string]*inMemStateStoreItem{},
+		watchers: map[*inMemWatcher]struct{}{},
+		log:      log,
+		closeCh:  make(chan struct{}),
+		clock:    clock.RealClock{},
 	}
 	s.BulkStore = state.NewDefaultBulkStore(s)
 	return s
@@ -78,11 +81,12 @@ func (store *inMemoryStore) Close() error {
 
 	// release memory reference
 	store.lock.Lock()
-	defer store.lock.Unlock()
 	for k := range store.items {
 		delete(store.items, k)
 	}
+	store.lock.Unlock()
 
+	// Wait for background goroutines, including watchers, after releasing the lock as they may need it to return
 	store.wg.Wait()
 
 	return nil
@@ -94,6 +98,9 @@ func (store *inMemoryStore) Features() []state.Feature {
 		state.FeatureTransactional,
 		state.FeatureTTL,
 		state.FeatureDeleteWithPrefix,
+		state.FeatureQueryAPI,
+		state.FeatureWatch,
+		state.FeatureKeyListing,
 	}
 }
 
@@ -138,7 +145,7 @@ func (store *inMemoryStore) DeleteWithPrefix(ctx context.Context, req state.Dele
 			// The string contains the prefix, now we check to make sure there aren't more || after
 			longerPrefix := strings.Contains(key[len(req.Prefix):], "||")
 			if !longerPrefix {
-				delete(store.items, key)
+				store.doDelete(ctx, key)
 				count++
 			}
 		}
@@ -146,6 +153,35 @@ func (store *inMemoryStore) DeleteWithPrefix(ctx context.Context, req state.Dele
 	return state.DeleteWithPrefixResponse{Count: count}, nil
 }
 
+// ListKeys returns the keys in lexicographical order.
+// The continuation token is the last key of the previous page.
+func (store *inMemoryStore) ListKeys(ctx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	store.lock.RLock()
+	now := store.clock.Now()
+	keys := make([]string, 0)
+	for key, item := range store.items {
+		if strings.HasPrefix(key, req.Prefix) && key > req.ContinuationToken && !item.isExpired(now) {
+			keys = append(keys, key)
+		}
+	}
+	store.lock.RUnlock()
+
+	slices.Sort(keys)
+	res := &state.ListKeysResponse{
+		Keys: keys,
+	}
+	if len(keys) > req.PageSize {
+		res.Keys = keys[:req.PageSize]
+		res.ContinuationToken = res.Keys[req.PageSize-1]
+	}
+	return res, nil
+}
+
 func (store *inMemoryStore) doValidateEtag(key string, etag *string, concurrency string) error {
 	hasEtag := etag != nil && *etag != ""
 
@@ -174,7 +210,14 @@ func (store *inMemoryStore) doValidateEtag(key string, etag *string, concurrency
 }
 
 func (store *inMemoryStore) doDelete(ctx context.Context, key string) {
+	if _, ok := store.items[key]; !ok {
+		return
+	}
 	delete(store.items, key)
+	store.notifyWatchers(&state.WatchEvent{
+		Type: state.WatchEventDelete,
+		Key:  key,
+	})
 }
 
 func (store *inMemoryStore) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
@@ -242,7 +285,7 @@ func (store *inMemoryStore) getAndExpire(key string) *inMemStateStoreItem {
 		return nil
 	}
 	if item.isExpired(store.clock.Now()) {
-		delete(store.items, key)
+		store.doDelete(context.Background(), key)
 		return nil
 	}
 	return item
@@ -332,6 +375,11 @@ func (store *inMemoryStore) doSet(ctx context.Context, key string, data []byte,
 	}
 
 	store.items[key] = el
+	store.notifyWatchers(&state.WatchEvent{
+		Type: state.WatchEventSet,
+		Key:  key,
+		ETag: &etag,
+	})
 }
 
 // innerSetRequest is only used to pass ttlInSeconds and data with SetRequest.
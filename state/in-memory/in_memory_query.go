@@ -0,0 +1,461 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+// Query executes a query against the store.
+// Filters are evaluated in-process against the JSON values, following the same semantics as the PostgreSQL state store:
+// fields are compared using their text representation (except that numbers are compared numerically in range filters and sorting, like jsonb),
+// and fields that are missing or null never match a filter (except EXISTS and NOT).
+func (store *inMemoryStore) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	q := &Query{}
+	qbuilder := query.NewQueryBuilder(q)
+	if err := qbuilder.BuildQuery(&req.Query); err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	data, token := q.execute(store)
+	return &state.QueryResponse{
+		Results: data,
+		Token:   token,
+	}, nil
+}
+
+// predicate is a compiled filter which is evaluated against a JSON document.
+type predicate func(doc any) bool
+
+// Query implements query.Visitor by compiling the filters into predicates.
+// Because the visitor interface returns strings, each compiled predicate is stored in a list and referenced by its index.
+type Query struct {
+	predicates []predicate
+	filter     predicate
+	sort       []query.Sorting
+	limit      int
+	skip       int64
+}
+
+func (q *Query) addPredicate(p predicate) string {
+	q.predicates = append(q.predicates, p)
+	return strconv.Itoa(len(q.predicates) - 1)
+}
+
+func (q *Query) getPredicate(ref string) (predicate, error) {
+	i, err := strconv.Atoi(ref)
+	if err != nil || i < 0 || i >= len(q.predicates) {
+		return nil, fmt.Errorf("invalid filter reference %q", ref)
+	}
+	return q.predicates[i], nil
+}
+
+func (q *Query) VisitEQ(f *query.EQ) (string, error) {
+	val := fmt.Sprintf("%v", f.Val)
+	return q.addPredicate(func(doc any) bool {
+		field, ok := fieldText(doc, f.Key)
+		return ok && field == val
+	}), nil
+}
+
+func (q *Query) VisitNEQ(f *query.NEQ) (string, error) {
+	val := fmt.Sprintf("%v", f.Val)
+	return q.addPredicate(func(doc any) bool {
+		field, ok := fieldText(doc, f.Key)
+		return ok && field != val
+	}), nil
+}
+
+func (q *Query) VisitGT(f *query.GT) (string, error) {
+	return q.visitCompare(f.Key, f.Val, func(c int) bool { return c > 0 })
+}
+
+func (q *Query) VisitGTE(f *query.GTE) (string, error) {
+	return q.visitCompare(f.Key, f.Val, func(c int) bool { return c >= 0 })
+}
+
+func (q *Query) VisitLT(f *query.LT) (string, error) {
+	return q.visitCompare(f.Key, f.Val, func(c int) bool { return c < 0 })
+}
+
+func (q *Query) VisitLTE(f *query.LTE) (string, error) {
+	return q.visitCompare(f.Key, f.Val, func(c int) bool { return c <= 0 })
+}
+
+func (q *Query) visitCompare(key string, value any, match func(c int) bool) (string, error) {
+	if v, ok := value.(string); ok {
+		return "", fmt.Errorf("unsupported type of value %s; string type not permitted", v)
+	}
+	return q.addPredicate(func(doc any) bool {
+		field, ok := fieldValue(doc, key)
+		return ok && field != nil && match(compareValues(field, value))
+	}), nil
+}
+
+func (q *Query) VisitIN(f *query.IN) (string, error) {
+	if len(f.Vals) == 0 {
+		return "", fmt.Errorf("empty IN operator for key %q", f.Key)
+	}
+
+	vals := make(map[string]struct{}, len(f.Vals))
+	for _, v := range f.Vals {
+		vals[fmt.Sprintf("%v", v)] = struct{}{}
+	}
+	return q.addPredicate(func(doc any) bool {
+		field, ok := fieldText(doc, f.Key)
+		if !ok {
+			return false
+		}
+		_, ok = vals[field]
+		return ok
+	}), nil
+}
+
+func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
+	var (
+		arr []predicate
+		str string
+		err error
+	)
+
+	for _, fil := range filters {
+		switch f := fil.(type) {
+		case *query.EQ:
+			str, err = q.VisitEQ(f)
+		case *query.NEQ:
+			str, err = q.VisitNEQ(f)
+		case *query.GT:
+			str, err = q.VisitGT(f)
+		case *query.GTE:
+			str, err = q.VisitGTE(f)
+		case *query.LT:
+			str, err = q.VisitLT(f)
+		case *query.LTE:
+			str, err = q.VisitLTE(f)
+		case *query.IN:
+			str, err = q.VisitIN(f)
+		case *query.OR:
+			str, err = q.VisitOR(f)
+		case *query.AND:
+			str, err = q.VisitAND(f)
+		case *query.NOT:
+			str, err = q.VisitNOT(f)
+		case *query.LIKE:
+			str, err = q.VisitLIKE(f)
+		case *query.EXISTS:
+			str, err = q.VisitEXISTS(f)
+		case *query.CONTAINS:
+			str, err = q.VisitCONTAINS(f)
+		default:
+			return "", fmt.Errorf("unsupported filter type %#v", f)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		var p predicate
+		p, err = q.getPredicate(str)
+		if err != nil {
+			return "", err
+		}
+		arr = append(arr, p)
+	}
+
+	if op == "AND" {
+		return q.addPredicate(func(doc any) bool {
+			for _, p := range arr {
+				if !p(doc) {
+					return false
+				}
+			}
+			return true
+		}), nil
+	}
+
+	return q.addPredicate(func(doc any) bool {
+		for _, p := range arr {
+			if p(doc) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (q *Query) VisitAND(f *query.AND) (string, error) {
+	return q.visitFilters("AND", f.Filters)
+}
+
+func (q *Query) VisitOR(f *query.OR) (string, error) {
+	return q.visitFilters("OR", f.Filters)
+}
+
+func (q *Query) VisitNOT(f *query.NOT) (string, error) {
+	ref, err := q.visitFilters("AND", []query.Filter{f.Filter})
+	if err != nil {
+		return "", err
+	}
+	p, err := q.getPredicate(ref)
+	if err != nil {
+		return "", err
+	}
+	return q.addPredicate(func(doc any) bool {
+		return !p(doc)
+	}), nil
+}
+
+func (q *Query) VisitLIKE(f *query.LIKE) (string, error) {
+	re, err := regexp.Compile("(?s)" + f.Regexp())
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern for key %q: %w", f.Key, err)
+	}
+	return q.addPredicate(func(doc any) bool {
+		field, ok := fieldText(doc, f.Key)
+		return ok && re.MatchString(field)
+	}), nil
+}
+
+func (q *Query) VisitEXISTS(f *query.EXISTS) (string, error) {
+	return q.addPredicate(func(doc any) bool {
+		_, ok := fieldValue(doc, f.Key)
+		return ok
+	}), nil
+}
+
+func (q *Query) VisitCONTAINS(f *query.CONTAINS) (string, error) {
+	return q.addPredicate(func(doc any) bool {
+		field, _ := fieldValue(doc, f.Key)
+		arr, ok := field.([]any)
+		if !ok {
+			return false
+		}
+		for _, el := range arr {
+			if el != nil && compareValues(el, f.Val) == 0 {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (q *Query) Finalize(filters string, qq *query.Query) error {
+	if filters != "" {
+		p, err := q.getPredicate(filters)
+		if err != nil {
+			return err
+		}
+		q.filter = p
+	}
+
+	for _, sortItem := range qq.Sort {
+		switch sortItem.Order {
+		case "", query.ASC, query.DESC:
+			// Nop
+		default:
+			return fmt.Errorf("invalid sort order %q", sortItem.Order)
+		}
+	}
+	q.sort = qq.Sort
+
+	if qq.Page.Limit > 0 {
+		q.limit = qq.Page.Limit
+	}
+
+	if len(qq.Page.Token) != 0 {
+		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
+		if err != nil {
+			return err
+		}
+		q.skip = skip
+	}
+
+	return nil
+}
+
+// execute runs the query against the store.
+// It must be invoked while holding a read lock on the store.
+func (q *Query) execute(store *inMemoryStore) ([]state.QueryItem, string) {
+	type match struct {
+		key  string
+		doc  any
+		item *inMemStateStoreItem
+	}
+
+	now := store.clock.Now()
+	matches := make([]match, 0, len(store.items))
+	for key, item := range store.items {
+		if item.isExpired(now) {
+			continue
+		}
+
+		// Values that are not valid JSON (such as binary data) are treated as documents without any field
+		doc := decodeDocument(item.data)
+		if q.filter != nil && !q.filter(doc) {
+			continue
+		}
+		matches = append(matches, match{key: key, doc: doc, item: item})
+	}
+
+	// Sort by the requested fields, then by key so the order (and pagination) is stable
+	sort.SliceStable(matches, func(i, j int) bool {
+		for _, sortItem := range q.sort {
+			a, _ := fieldValue(matches[i].doc, sortItem.Key)
+			b, _ := fieldValue(matches[j].doc, sortItem.Key)
+			aOk, bOk := a != nil, b != nil
+			desc := sortItem.Order == query.DESC
+			switch {
+			case aOk && bOk:
+				c := compareValues(a, b)
+				if c != 0 {
+					return (c < 0) != desc
+				}
+			case aOk != bOk:
+				// Like in PostgreSQL, nulls are sorted last in ascending order and first in descending order
+				return aOk != desc
+			}
+		}
+		return matches[i].key < matches[j].key
+	})
+
+	if q.skip > 0 {
+		if q.skip >= int64(len(matches)) {
+			matches = matches[:0]
+		} else {
+			matches = matches[q.skip:]
+		}
+	}
+	if q.limit > 0 && len(matches) > q.limit {
+		matches = matches[:q.limit]
+	}
+
+	ret := make([]state.QueryItem, len(matches))
+	for i, m := range matches {
+		ret[i] = state.QueryItem{
+			Key:  m.key,
+			Data: m.item.data,
+			ETag: m.item.etag,
+		}
+	}
+
+	var token string
+	if q.limit != 0 {
+		token = strconv.FormatInt(q.skip+int64(len(ret)), 10)
+	}
+
+	return ret, token
+}
+
+func decodeDocument(data []byte) any {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil
+	}
+	return doc
+}
+
+// fieldText returns the text representation of a (dot-separated) field in the document.
+// This is equivalent to the "->>" operator in PostgreSQL; the second return value is false if the field is missing or null.
+func fieldText(doc any, key string) (string, bool) {
+	field, ok := fieldValue(doc, key)
+	if !ok {
+		return "", false
+	}
+	return valueText(field)
+}
+
+// fieldValue returns the value of a (dot-separated) field in the document.
+// The second return value is false if the field is missing, but it's true if the field is null.
+func fieldValue(doc any, key string) (any, bool) {
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		doc, ok = obj[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// valueText returns the text representation of a value decoded from JSON; the second return value is false if the value is null.
+func valueText(val any) (string, bool) {
+	switch v := val.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+}
+
+// compareValues compares two non-null values like jsonb in PostgreSQL: numerically if both are numbers, or else by their text representation.
+// Strings that contain numbers are compared as text, so "10" is sorted before "9" like in the PostgreSQL state store.
+func compareValues(a, b any) int {
+	af, aOk := valueNumber(a)
+	bf, bOk := valueNumber(b)
+	if aOk && bOk {
+		return cmp.Compare(af, bf)
+	}
+	at, _ := valueText(a)
+	bt, _ := valueText(b)
+	return strings.Compare(at, bt)
+}
+
+// valueNumber returns the value as a float if it's a number, either decoded from a JSON document or from a query.
+func valueNumber(val any) (float64, bool) {
+	switch v := val.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestQuery(t *testing.T) {
+	store := newStateStore(logger.NewLogger("test"))
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	store.clock = fakeClock
+
+	items := map[string]any{
+		"1": map[string]any{"state": "CA", "person": map[string]any{"org": "A", "name": "John", "id": 1036}, "tags": []any{"blue"}},
+		"2": map[string]any{"state": "WA", "person": map[string]any{"org": "B", "name": "Mary", "id": 123}},
+		"3": map[string]any{"state": "CA", "person": map[string]any{"org": "B", "name": "Bob", "id": 567}},
+		"4": map[string]any{"state": "NY", "person": map[string]any{"org": "A", "name": "Alice", "id": 890}},
+		"5": map[string]any{"state": "WA", "person": map[string]any{"org": "A", "name": "Carl", "id": 9}},
+		"6": []byte("binary"),
+		"8": map[string]any{"state": "NY", "person": map[string]any{"org": nil, "name": "Jane"}, "tags": []any{"red", "blue"}},
+		"9": map[string]any{"person": map[string]any{"name": "Jack"}, "tags": []any{"blue"}},
+	}
+	for k, v := range items {
+		err := store.Set(context.Background(), &state.SetRequest{Key: k, Value: v})
+		require.NoError(t, err)
+	}
+
+	// Expired items are not returned
+	err := store.Set(context.Background(), &state.SetRequest{
+		Key:      "7",
+		Value:    map[string]any{"state": "CA", "person": map[string]any{"org": "A"}},
+		Metadata: map[string]string{"ttlInSeconds": "1"},
+	})
+	require.NoError(t, err)
+	fakeClock.Step(2 * time.Second)
+
+	doQuery := func(t *testing.T, q string) *state.QueryResponse {
+		t.Helper()
+		req := &state.QueryRequest{}
+		err := json.Unmarshal([]byte(q), &req.Query)
+		require.NoError(t, err)
+		res, err := store.Query(context.Background(), req)
+		require.NoError(t, err)
+		return res
+	}
+	keys := func(res *state.QueryResponse) []string {
+		ret := make([]string, len(res.Results))
+		for i, r := range res.Results {
+			ret[i] = r.Key
+		}
+		return ret
+	}
+
+	tests := []struct {
+		input string
+		keys  []string
+		token string
+	}{
+		{
+			input: "../../tests/state/query/q1.json",
+			keys:  []string{"1", "2"},
+			token: "2",
+		},
+		{
+			input: "../../tests/state/query/q2.json",
+			keys:  []string{"1", "3"},
+			token: "2",
+		},
+		{
+			input: "../../tests/state/query/q2-token.json",
+			keys:  []string{},
+			token: "2",
+		},
+		{
+			input: "../../tests/state/query/q3.json",
+			keys:  []string{"5", "1"},
+		},
+		{
+			input: "../../tests/state/query/q4-notequal.json",
+			keys:  []string{"5", "4"},
+			token: "2",
+		},
+		{
+			input: "../../tests/state/query/q6.json",
+			keys:  []string{"2", "3"},
+			token: "2",
+		},
+		{
+			input: "../../tests/state/query/q9.json",
+			keys:  []string{"8"},
+			token: "1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			data, err := os.ReadFile(test.input)
+			require.NoError(t, err)
+
+			res := doQuery(t, string(data))
+			assert.Equal(t, test.keys, keys(res))
+			assert.Equal(t, test.token, res.Token)
+		})
+	}
+
+	t.Run("numeric comparisons", func(t *testing.T) {
+		res := doQuery(t, `{"filter":{"AND":[{"GTE":{"person.id":123}},{"LT":{"person.id":1000}}]},"sort":[{"key":"person.id","order":"DESC"}]}`)
+		assert.Equal(t, []string{"4", "3", "2"}, keys(res))
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		res := doQuery(t, `{"filter":{"EQ":{"person.org":"A"}},"page":{"limit":2}}`)
+		assert.Equal(t, []string{"1", "4"}, keys(res))
+		assert.Equal(t, "2", res.Token)
+
+		res = doQuery(t, `{"filter":{"EQ":{"person.org":"A"}},"page":{"limit":2,"token":"`+res.Token+`"}}`)
+		assert.Equal(t, []string{"5"}, keys(res))
+		assert.Equal(t, "3", res.Token)
+	})
+
+	t.Run("results include data and etag", func(t *testing.T) {
+		res := doQuery(t, `{"filter":{"EQ":{"person.name":"Bob"}}}`)
+		require.Len(t, res.Results, 1)
+		get, err := store.Get(context.Background(), &state.GetRequest{Key: "3"})
+		require.NoError(t, err)
+		assert.Equal(t, get.Data, res.Results[0].Data)
+		assert.Equal(t, get.ETag, res.Results[0].ETag)
+	})
+
+	t.Run("missing fields do not match", func(t *testing.T) {
+		res := doQuery(t, `{"filter":{"NEQ":{"missing":"x"}}}`)
+		assert.Empty(t, res.Results)
+	})
+
+	t.Run("operators", func(t *testing.T) {
+		res := doQuery(t, `{"filter":{"LIKE":{"person.name":"_a%"}},"sort":[{"key":"person.name"}]}`)
+		assert.Equal(t, []string{"5", "9", "8", "2"}, keys(res))
+
+		res = doQuery(t, `{"filter":{"LIKE":{"person.name":"_A%"}}}`)
+		assert.Empty(t, res.Results)
+
+		res = doQuery(t, `{"filter":{"NOT":{"EXISTS":"state"}}}`)
+		assert.Equal(t, []string{"6", "9"}, keys(res))
+
+		res = doQuery(t, `{"filter":{"AND":[{"CONTAINS":{"tags":"blue"}},{"NOT":{"CONTAINS":{"tags":"red"}}}]}}`)
+		assert.Equal(t, []string{"1", "9"}, keys(res))
+
+		res = doQuery(t, `{"filter":{"NOT":{"OR":[{"EQ":{"state":"CA"}},{"EQ":{"state":"WA"}}]}}}`)
+		assert.Equal(t, []string{"4", "6", "8", "9"}, keys(res))
+	})
+
+	t.Run("numbers in strings are sorted as text", func(t *testing.T) {
+		for k, v := range map[string]any{
+			"s10": map[string]any{"text": "10"},
+			"s9":  map[string]any{"text": "9"},
+			"n10": map[string]any{"number": 10},
+			"n9":  map[string]any{"number": 9},
+		} {
+			require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: k, Value: v}))
+		}
+
+		res := doQuery(t, `{"filter":{"EXISTS":"text"},"sort":[{"key":"text"}]}`)
+		assert.Equal(t, []string{"s10", "s9"}, keys(res))
+
+		res = doQuery(t, `{"filter":{"EXISTS":"number"},"sort":[{"key":"number"}]}`)
+		assert.Equal(t, []string{"n9", "n10"}, keys(res))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, q := range []string{
+			`{"filter":{"GT":{"state":"CA"}}}`,
+			`{"filter":{"IN":{"state":[]}}}`,
+			`{"page":{"limit":2,"token":"foo"}}`,
+			`{"sort":[{"key":"state","order":"foo"}]}`,
+		} {
+			req := &state.QueryRequest{}
+			err := json.Unmarshal([]byte(q), &req.Query)
+			require.NoError(t, err)
+			_, err = store.Query(context.Background(), req)
+			require.Error(t, err, q)
+		}
+	})
+}
@@ -178,3 +178,47 @@ func TestReadAndWrite(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestListKeys(t *testing.T) {
+	store := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	store.clock = fakeClock
+	require.NoError(t, store.Init(context.Background(), state.Metadata{}))
+	defer store.Close()
+
+	require.True(t, state.FeatureKeyListing.IsPresent(store.Features()))
+
+	for _, key := range []string{"app||c", "app||a", "app||b", "other||a"} {
+		require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: key, Value: "value"}))
+	}
+	require.NoError(t, store.Set(context.Background(), &state.SetRequest{
+		Key:      "app||expiring",
+		Value:    "value",
+		Metadata: map[string]string{"ttlInSeconds": "1"},
+	}))
+	fakeClock.Step(2 * time.Second)
+
+	t.Run("all keys", func(t *testing.T) {
+		res, err := store.ListKeys(context.Background(), &state.ListKeysRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app||a", "app||b", "app||c", "other||a"}, res.Keys)
+		assert.Empty(t, res.ContinuationToken)
+	})
+
+	t.Run("pages with prefix", func(t *testing.T) {
+		res, err := store.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: "app||", PageSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app||a", "app||b"}, res.Keys)
+		require.NotEmpty(t, res.ContinuationToken)
+
+		res, err = store.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: "app||", PageSize: 2, ContinuationToken: res.ContinuationToken})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app||c"}, res.Keys)
+		assert.Empty(t, res.ContinuationToken)
+	})
+
+	t.Run("invalid page size", func(t *testing.T) {
+		_, err := store.ListKeys(context.Background(), &state.ListKeysRequest{PageSize: -1})
+		require.Error(t, err)
+	})
+}
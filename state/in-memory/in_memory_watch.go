@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+// Watch invokes handler for each change to the keys that match the request, until ctx is canceled.
+func (store *inMemoryStore) Watch(ctx context.Context, req *state.WatchRequest, handler state.WatchHandler) error {
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+	if store.closed.Load() {
+		return errors.New("state store is closed")
+	}
+
+	w := &inMemWatcher{
+		req:     *req,
+		handler: handler,
+		notify:  make(chan struct{}, 1),
+	}
+
+	store.lock.Lock()
+	store.watchers[w] = struct{}{}
+	store.lock.Unlock()
+
+	store.wg.Add(1)
+	go func() {
+		defer store.wg.Done()
+		w.run(ctx, store)
+
+		store.lock.Lock()
+		delete(store.watchers, w)
+		store.lock.Unlock()
+	}()
+
+	return nil
+}
+
+// notifyWatchers queues the event for the watchers of the key.
+// It must be invoked while holding the write lock.
+func (store *inMemoryStore) notifyWatchers(event *state.WatchEvent) {
+	for w := range store.watchers {
+		if w.req.Matches(event.Key) {
+			w.push(event)
+		}
+	}
+}
+
+// inMemWatcher delivers events to the handler of a watch.
+// Events are queued without blocking, because they are produced while holding the lock of the store, which handlers may need too.
+type inMemWatcher struct {
+	req     state.WatchRequest
+	handler state.WatchHandler
+
+	lock   sync.Mutex
+	queue  []*state.WatchEvent
+	notify chan struct{}
+}
+
+func (w *inMemWatcher) push(event *state.WatchEvent) {
+	w.lock.Lock()
+	w.queue = append(w.queue, event)
+	w.lock.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+		// There's a pending notification already
+	}
+}
+
+func (w *inMemWatcher) run(ctx context.Context, store *inMemoryStore) {
+	for {
+		select {
+		case <-w.notify:
+		case <-ctx.Done():
+			return
+		case <-store.closeCh:
+			return
+		}
+
+		w.lock.Lock()
+		events := w.queue
+		w.queue = nil
+		w.lock.Unlock()
+
+		for _, event := range events {
+			if ctx.Err() != nil || store.closed.Load() {
+				return
+			}
+			err := w.handler(ctx, event)
+			if err != nil {
+				store.log.Errorf("Error from the handler of watch for key '%s': %v", w.req.Key, err)
+			}
+		}
+	}
+}
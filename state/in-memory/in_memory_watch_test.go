@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestWatch(t *testing.T) {
+	store := NewInMemoryStateStore(logger.NewLogger("test")).(*inMemoryStore)
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	store.clock = fakeClock
+	require.NoError(t, store.Init(context.Background(), state.Metadata{}))
+	defer store.Close()
+
+	var _ state.Watcher = store
+	require.True(t, state.FeatureWatch.IsPresent(store.Features()))
+
+	watch := func(t *testing.T, req *state.WatchRequest) (<-chan *state.WatchEvent, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		events := make(chan *state.WatchEvent, 10)
+		err := store.Watch(ctx, req, func(ctx context.Context, event *state.WatchEvent) error {
+			events <- event
+			return nil
+		})
+		require.NoError(t, err)
+		return events, cancel
+	}
+	receive := func(t *testing.T, events <-chan *state.WatchEvent) *state.WatchEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("did not receive event in time")
+			return nil
+		}
+	}
+	assertNoEvent := func(t *testing.T, events <-chan *state.WatchEvent) {
+		select {
+		case event := <-events:
+			t.Fatalf("received unexpected event for key '%s'", event.Key)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	t.Run("invalid request", func(t *testing.T) {
+		err := store.Watch(context.Background(), &state.WatchRequest{}, func(ctx context.Context, event *state.WatchEvent) error {
+			return nil
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("set and delete key", func(t *testing.T) {
+		events, _ := watch(t, &state.WatchRequest{Key: "watched"})
+
+		require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "watched", Value: "hello"}))
+		require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "watched-other", Value: "hello"}))
+		event := receive(t, events)
+		assert.Equal(t, state.WatchEventSet, event.Type)
+		assert.Equal(t, "watched", event.Key)
+		res, err := store.Get(context.Background(), &state.GetRequest{Key: "watched"})
+		require.NoError(t, err)
+		require.NotNil(t, event.ETag)
+		assert.Equal(t, *res.ETag, *event.ETag)
+
+		require.NoError(t, store.Delete(context.Background(), &state.DeleteRequest{Key: "watched"}))
+		event = receive(t, events)
+		assert.Equal(t, state.WatchEventDelete, event.Type)
+		assert.Equal(t, "watched", event.Key)
+		assert.Nil(t, event.ETag)
+
+		// Deleting a key that doesn't exist doesn't generate events
+		require.NoError(t, store.Delete(context.Background(), &state.DeleteRequest{Key: "watched"}))
+		assertNoEvent(t, events)
+	})
+
+	t.Run("prefix and transactions", func(t *testing.T) {
+		events, _ := watch(t, &state.WatchRequest{Key: "app||", Prefix: true})
+
+		require.NoError(t, store.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "app||a", Value: "1"},
+				state.SetRequest{Key: "other||a", Value: "1"},
+				state.SetRequest{Key: "app||b", Value: "2"},
+			},
+		}))
+		assert.Equal(t, "app||a", receive(t, events).Key)
+		assert.Equal(t, "app||b", receive(t, events).Key)
+
+		_, err := store.DeleteWithPrefix(context.Background(), state.DeleteWithPrefixRequest{Prefix: "app"})
+		require.NoError(t, err)
+		keys := []string{receive(t, events).Key, receive(t, events).Key}
+		assert.ElementsMatch(t, []string{"app||a", "app||b"}, keys)
+	})
+
+	t.Run("expired keys", func(t *testing.T) {
+		events, _ := watch(t, &state.WatchRequest{Key: "expiring"})
+
+		require.NoError(t, store.Set(context.Background(), &state.SetRequest{
+			Key:      "expiring",
+			Value:    "hello",
+			Metadata: map[string]string{"ttlInSeconds": "1"},
+		}))
+		assert.Equal(t, state.WatchEventSet, receive(t, events).Type)
+
+		fakeClock.Step(2 * time.Second)
+		store.doCleanExpiredItems()
+		event := receive(t, events)
+		assert.Equal(t, state.WatchEventDelete, event.Type)
+		assert.Equal(t, "expiring", event.Key)
+	})
+
+	t.Run("stop watching", func(t *testing.T) {
+		events, cancel := watch(t, &state.WatchRequest{Key: "stopped"})
+		cancel()
+		assert.Eventually(t, func() bool {
+			store.lock.RLock()
+			defer store.lock.RUnlock()
+			for w := range store.watchers {
+				if w.req.Key == "stopped" {
+					return false
+				}
+			}
+			return true
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "stopped", Value: "hello"}))
+		assertNoEvent(t, events)
+	})
+}
@@ -562,6 +562,20 @@ func (m *MongoDB) Query(ctx context.Context, req *state.QueryRequest) (*state.Qu
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
+
+	if len(q.aggregations) > 0 {
+		aggregates, token, err := q.executeAggregation(ctx, m.collection)
+		if err != nil {
+			return &state.QueryResponse{}, err
+		}
+
+		return &state.QueryResponse{
+			Results:    []state.QueryItem{},
+			Aggregates: aggregates,
+			Token:      token,
+		}, nil
+	}
+
 	data, token, err := q.execute(ctx, m.collection)
 	if err != nil {
 		return &state.QueryResponse{}, err
@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -30,9 +31,12 @@ import (
 )
 
 type Query struct {
-	query  string
-	filter interface{}
-	opts   *options.FindOptions
+	query        string
+	filter       interface{}
+	opts         *options.FindOptions
+	aggregations []query.Aggregation
+	groupBy      []string
+	pipeline     mongo.Pipeline
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -171,6 +175,26 @@ func (q *Query) visitFilters(op string, filters []query.Filter) (string, error)
 				return "", err
 			}
 			arr = append(arr, str)
+		case *query.NOT:
+			if str, err = q.VisitNOT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LIKE:
+			if str, err = q.VisitLIKE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.EXISTS:
+			if str, err = q.VisitEXISTS(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.CONTAINS:
+			if str, err = q.VisitCONTAINS(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
 		default:
 			return "", fmt.Errorf("unsupported filter type %#v", f)
 		}
@@ -189,14 +213,65 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 	return q.visitFilters("$or", f.Filters)
 }
 
+func (q *Query) VisitNOT(f *query.NOT) (string, error) {
+	// { $nor: [ { <expression> } ] }
+	// Unlike $not, $nor can negate any expression
+	return q.visitFilters("$nor", []query.Filter{f.Filter})
+}
+
+func (q *Query) VisitLIKE(f *query.LIKE) (string, error) {
+	// { <key>: { $regex: <regex>, $options: "s" } }
+	regex, err := json.Marshal(f.Regexp())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`{ "value.%s": { "$regex": %s, "$options": "s" } }`, f.Key, regex), nil
+}
+
+func (q *Query) VisitEXISTS(f *query.EXISTS) (string, error) {
+	// { <key>: { $exists: true } }
+	return fmt.Sprintf(`{ "value.%s": { "$exists": true } }`, f.Key), nil
+}
+
+func (q *Query) VisitCONTAINS(f *query.CONTAINS) (string, error) {
+	// { <key>: { $elemMatch: { $eq: <val> } } }
+	val, err := json.Marshal(f.Val)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`{ "value.%s": { "$elemMatch": { "$eq": %s } } }`, f.Key, val), nil
+}
+
+func (q *Query) VisitProjection(fields []string) error {
+	// The key and the etag are always returned
+	projection := bson.D{{Key: "_id", Value: 1}, {Key: "_etag", Value: 1}}
+	for _, f := range fields {
+		projection = append(projection, bson.E{Key: "value." + f, Value: 1})
+	}
+	q.opts = options.Find().SetProjection(projection)
+	return nil
+}
+
+func (q *Query) VisitAggregations(aggregations []query.Aggregation, groupBy []string) error {
+	q.aggregations = aggregations
+	q.groupBy = groupBy
+	return nil
+}
+
 func (q *Query) Finalize(filters string, qq *query.Query) error {
+	if len(q.aggregations) > 0 {
+		return q.finalizeAggregation(filters, qq)
+	}
+
 	q.query = filters
 	if len(filters) == 0 {
 		q.filter = bson.D{}
 	} else if err := bson.UnmarshalExtJSON([]byte(filters), false, &q.filter); err != nil {
 		return err
 	}
-	q.opts = options.Find()
+	if q.opts == nil {
+		q.opts = options.Find()
+	}
 
 	// sorting
 	if len(qq.Sort) > 0 {
@@ -225,6 +300,82 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	return nil
 }
 
+// finalizeAggregation builds an aggregation pipeline.
+// The fields to group by are renamed in the _id of the groups, since the names of the fields can't contain dots.
+func (q *Query) finalizeAggregation(filters string, qq *query.Query) error {
+	var stages []string
+
+	// { $match: { <filters> } }
+	if len(filters) > 0 {
+		stages = append(stages, `{ "$match": `+filters+` }`)
+	}
+
+	// { $group: { _id: { g0: <field>, ... }, <alias>: { <op>: <expression> }, ... } }
+	group := `{ "$group": { "_id": `
+	if len(q.groupBy) > 0 {
+		fields := make([]string, len(q.groupBy))
+		for i, g := range q.groupBy {
+			fields[i] = fmt.Sprintf(`"g%d": "$value.%s"`, i, g)
+		}
+		group += "{ " + strings.Join(fields, ", ") + " }"
+	} else {
+		group += "null"
+	}
+	for _, a := range q.aggregations {
+		var expr string
+		switch {
+		case a.Op == query.COUNT && a.Key == "":
+			expr = `{ "$sum": 1 }`
+		case a.Op == query.COUNT:
+			// Missing and null fields are lower than any other value
+			expr = fmt.Sprintf(`{ "$sum": { "$cond": [ { "$gt": [ "$value.%s", null ] }, 1, 0 ] } }`, a.Key)
+		default:
+			expr = fmt.Sprintf(`{ "$%s": "$value.%s" }`, strings.ToLower(a.Op), a.Key)
+		}
+		group += fmt.Sprintf(`, "%s": %s`, a.Alias, expr)
+	}
+	stages = append(stages, group+" } }")
+
+	// { $sort: { _id.<g>: <order>, ... } }
+	if len(qq.Sort) > 0 {
+		fields := make([]string, len(qq.Sort))
+		for i, s := range qq.Sort {
+			order := 1 // ascending
+			if s.Order == query.DESC {
+				order = -1
+			}
+			fields[i] = fmt.Sprintf(`"_id.g%d": %d`, slices.Index(q.groupBy, s.Key), order)
+		}
+		stages = append(stages, `{ "$sort": { `+strings.Join(fields, ", ")+` } }`)
+	}
+
+	// { $skip: <n> }, { $limit: <n> }
+	// The options are used to compute the token
+	q.opts = options.Find()
+	if len(qq.Page.Token) != 0 {
+		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
+		if err != nil {
+			return err
+		}
+		q.opts.SetSkip(skip)
+		stages = append(stages, fmt.Sprintf(`{ "$skip": %d }`, skip))
+	}
+	if qq.Page.Limit > 0 {
+		q.opts.SetLimit(int64(qq.Page.Limit))
+		stages = append(stages, fmt.Sprintf(`{ "$limit": %d }`, qq.Page.Limit))
+	}
+
+	q.query = "[ " + strings.Join(stages, ", ") + " ]"
+	q.pipeline = make(mongo.Pipeline, len(stages))
+	for i, stage := range stages {
+		if err := bson.UnmarshalExtJSON([]byte(stage), false, &q.pipeline[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (q *Query) execute(ctx context.Context, collection *mongo.Collection) ([]state.QueryItem, string, error) {
 	cur, err := collection.Find(ctx, q.filter, []*options.FindOptions{q.opts}...)
 	if err != nil {
@@ -266,15 +417,55 @@ func (q *Query) execute(ctx context.Context, collection *mongo.Collection) ([]st
 	if err = cur.Err(); err != nil {
 		return nil, "", err
 	}
-	// set next query token only if limit is specified
-	var token string
-	if q.opts.Limit != nil && *q.opts.Limit != 0 {
-		var skip int64
-		if q.opts.Skip != nil {
-			skip = *q.opts.Skip
+
+	return ret, q.nextToken(len(ret)), nil
+}
+
+func (q *Query) executeAggregation(ctx context.Context, collection *mongo.Collection) ([]state.QueryAggregate, string, error) {
+	cur, err := collection.Aggregate(ctx, q.pipeline)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cur.Close(ctx)
+	ret := []state.QueryAggregate{}
+	for cur.Next(ctx) {
+		// Convert the document to relaxed JSON, so the values have the same types as in the results of other queries
+		var data []byte
+		if data, err = bson.MarshalExtJSON(cur.Current, false, true); err != nil {
+			return nil, "", err
+		}
+		var doc map[string]any
+		if err = json.Unmarshal(data, &doc); err != nil {
+			return nil, "", err
 		}
-		token = strconv.FormatInt(skip+int64(len(ret)), 10)
+
+		result := state.QueryAggregate{}
+		if len(q.groupBy) > 0 {
+			id, _ := doc["_id"].(map[string]any)
+			result.Group = make(map[string]any, len(q.groupBy))
+			for i, g := range q.groupBy {
+				result.Group[g] = id["g"+strconv.Itoa(i)]
+			}
+		}
+		delete(doc, "_id")
+		result.Values = doc
+		ret = append(ret, result)
 	}
+	if err = cur.Err(); err != nil {
+		return nil, "", err
+	}
+
+	return ret, q.nextToken(len(ret)), nil
+}
 
-	return ret, token, nil
+// nextToken returns the token for the next page of results, which is set only if limit is specified.
+func (q *Query) nextToken(count int) string {
+	if q.opts.Limit == nil || *q.opts.Limit == 0 {
+		return ""
+	}
+	var skip int64
+	if q.opts.Skip != nil {
+		skip = *q.opts.Skip
+	}
+	return strconv.FormatInt(skip+int64(count), 10)
 }
@@ -20,6 +20,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/dapr/components-contrib/state/query"
 )
@@ -57,6 +58,21 @@ func TestMongoQuery(t *testing.T) {
 			input: "../../tests/state/query/q7.json",
 			query: `{ "$or": [ { "value.person.id": {"$lt": 123} }, { "$and": [ { "value.person.org": {"$gte": 2} }, { "value.person.id": { "$in": [ 567, 890 ] } } ] } ] }`,
 		},
+		{
+			input: "../../tests/state/query/q9.json",
+			query: `{ "$and": [ { "value.person.name": { "$regex": "^J.*$", "$options": "s" } }, { "value.person.org": { "$exists": true } }, { "$nor": [ { "value.state": "CA" } ] }, { "value.tags": { "$elemMatch": { "$eq": "blue" } } } ] }`,
+		},
+		{
+			input: "../../tests/state/query/q10-projection.json",
+			query: `{ "value.state": "CA" }`,
+		},
+		{
+			input: "../../tests/state/query/q11-aggregation.json",
+			query: `[ { "$match": { "value.state": { "$in": [ "CA", "WA" ] } } }, ` +
+				`{ "$group": { "_id": { "g0": "$value.state", "g1": "$value.person.org" }, "total": { "$sum": 1 }, "sum_id": { "$sum": "$value.person.id" }, ` +
+				`"avg_id": { "$avg": "$value.person.id" }, "min_id": { "$min": "$value.person.id" }, "max_id": { "$max": "$value.person.id" } } }, ` +
+				`{ "$sort": { "_id.g0": -1 } } ]`,
+		},
 	}
 	for _, test := range tests {
 		data, err := os.ReadFile(test.input)
@@ -72,3 +88,63 @@ func TestMongoQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestMongoQueryLIKE(t *testing.T) {
+	var qq query.Query
+	err := json.Unmarshal([]byte(`{"filter":{"LIKE":{"name":"1.0\\%_%"}}}`), &qq)
+	require.NoError(t, err)
+
+	q := &Query{}
+	err = query.NewQueryBuilder(q).BuildQuery(&qq)
+	require.NoError(t, err)
+	assert.Equal(t, `{ "value.name": { "$regex": "^1\\.0%..*$", "$options": "s" } }`, q.query)
+}
+
+func TestMongoQueryProjection(t *testing.T) {
+	var qq query.Query
+	err := json.Unmarshal([]byte(`{"projection": ["person.name", "state"]}`), &qq)
+	require.NoError(t, err)
+
+	q := &Query{}
+	err = query.NewQueryBuilder(q).BuildQuery(&qq)
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{
+		{Key: "_id", Value: 1},
+		{Key: "_etag", Value: 1},
+		{Key: "value.person.name", Value: 1},
+		{Key: "value.state", Value: 1},
+	}, q.opts.Projection)
+}
+
+func TestMongoQueryAggregationPipeline(t *testing.T) {
+	var qq query.Query
+	err := json.Unmarshal([]byte(`{"aggregations": [{"op": "COUNT", "key": "person.org", "alias": "orgs"}], "page": {"limit": 2, "token": "4"}}`), &qq)
+	require.NoError(t, err)
+
+	q := &Query{}
+	err = query.NewQueryBuilder(q).BuildQuery(&qq)
+	require.NoError(t, err)
+	assert.Equal(t, `[ { "$group": { "_id": null, "orgs": { "$sum": { "$cond": [ { "$gt": [ "$value.person.org", null ] }, 1, 0 ] } } } }, { "$skip": 4 }, { "$limit": 2 } ]`, q.query)
+	assert.Len(t, q.pipeline, 3)
+	assert.Equal(t, "6", q.nextToken(2))
+}
+
+func TestMongoQueryContains(t *testing.T) {
+	tests := map[string]string{
+		`"blue"`:       `{ "value.tags": { "$elemMatch": { "$eq": "blue" } } }`,
+		`3`:            `{ "value.tags": { "$elemMatch": { "$eq": 3 } } }`,
+		`null`:         `{ "value.tags": { "$elemMatch": { "$eq": null } } }`,
+		`{"a": "b"}`:   `{ "value.tags": { "$elemMatch": { "$eq": {"a":"b"} } } }`,
+		`["a", "b\""]`: `{ "value.tags": { "$elemMatch": { "$eq": ["a","b\""] } } }`,
+	}
+	for val, expected := range tests {
+		var qq query.Query
+		err := json.Unmarshal([]byte(`{"filter": {"CONTAINS": {"tags": `+val+`}}}`), &qq)
+		require.NoError(t, err)
+
+		q := &Query{}
+		err = query.NewQueryBuilder(q).BuildQuery(&qq)
+		require.NoError(t, err)
+		assert.Equal(t, expected, q.query)
+	}
+}
@@ -231,6 +231,8 @@ func (m *MySQL) Features() []state.Feature {
 		state.FeatureETag,
 		state.FeatureTransactional,
 		state.FeatureTTL,
+		state.FeatureQueryAPI,
+		state.FeatureKeyListing,
 	}
 }
 
@@ -536,6 +538,39 @@ func (m *MySQL) Get(parentCtx context.Context, req *state.GetRequest) (*state.Ge
 	}, nil
 }
 
+// ListKeys returns the keys in the store, sorted by the collation of the id column.
+// The continuation token is the last key of the previous page.
+func (m *MySQL) ListKeys(parentCtx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, m.timeout)
+	defer cancel()
+	// "!" is the escape character, as the meaning of "\" in string literals depends on the SQL mode
+	//nolint:gosec
+	query := `SELECT id FROM ` + m.tableName + `
+		WHERE id LIKE ? ESCAPE '!'
+			AND id > ?
+			AND (expiredate IS NULL OR expiredate > CURRENT_TIMESTAMP)
+		ORDER BY id
+		LIMIT ?`
+	rows, err := m.db.QueryContext(ctx, query, commonsql.EscapeLike(req.Prefix, '!')+"%", req.ContinuationToken, req.PageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := &state.ListKeysResponse{}
+	res.Keys, res.ContinuationToken, err = utils.ReadKeysPage(rows, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // Set adds/updates an entity on store
 // Store Interface.
 func (m *MySQL) Set(ctx context.Context, req *state.SetRequest) error {
@@ -0,0 +1,326 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	commonsql "github.com/dapr/components-contrib/common/component/sql"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+const (
+	// Maximum value for LIMIT, used when an OFFSET is set without a limit.
+	// See: https://dev.mysql.com/doc/refman/8.0/en/select.html
+	maxLimit = "18446744073709551615"
+
+	// Expression that extracts a field from the value column as text; the JSON path is passed as a parameter.
+	fieldTextExpr = "JSON_UNQUOTE(JSON_EXTRACT(value, ?))"
+)
+
+// Query executes a query against the store.
+func (m *MySQL) Query(parentCtx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	q := &Query{
+		tableName: m.tableName,
+	}
+	qbuilder := query.NewQueryBuilder(q)
+	if err := qbuilder.BuildQuery(&req.Query); err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, m.timeout)
+	defer cancel()
+	data, token, err := q.execute(ctx, m.db)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	return &state.QueryResponse{
+		Results: data,
+		Token:   token,
+	}, nil
+}
+
+// Query builds a SQL query for MySQL and MariaDB, using the JSON functions to access the fields of the stored values.
+// Fields are extracted as text (like the "->>" operator), so they're compared with the text representation of the values for equality.
+type Query struct {
+	query     string
+	params    []any
+	limit     int
+	skip      *int64
+	tableName string
+}
+
+func (q *Query) VisitEQ(f *query.EQ) (string, error) {
+	return q.whereFieldText(f.Key, "=", f.Val), nil
+}
+
+func (q *Query) VisitNEQ(f *query.NEQ) (string, error) {
+	return q.whereFieldText(f.Key, "!=", f.Val), nil
+}
+
+func (q *Query) VisitGT(f *query.GT) (string, error) {
+	return q.whereFieldCompare(f.Key, ">", f.Val)
+}
+
+func (q *Query) VisitGTE(f *query.GTE) (string, error) {
+	return q.whereFieldCompare(f.Key, ">=", f.Val)
+}
+
+func (q *Query) VisitLT(f *query.LT) (string, error) {
+	return q.whereFieldCompare(f.Key, "<", f.Val)
+}
+
+func (q *Query) VisitLTE(f *query.LTE) (string, error) {
+	return q.whereFieldCompare(f.Key, "<=", f.Val)
+}
+
+func (q *Query) VisitIN(f *query.IN) (string, error) {
+	if len(f.Vals) == 0 {
+		return "", fmt.Errorf("empty IN operator for key %q", f.Key)
+	}
+
+	// The IN operator is not supported with JSON values in MySQL, so we use a list of OR's like the PostgreSQL state store
+	str := "("
+	str += q.whereFieldText(f.Key, "=", f.Vals[0])
+
+	for _, v := range f.Vals[1:] {
+		str += " OR "
+		str += q.whereFieldText(f.Key, "=", v)
+	}
+	str += ")"
+	return str, nil
+}
+
+func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
+	var (
+		arr []string
+		str string
+		err error
+	)
+
+	for _, fil := range filters {
+		switch f := fil.(type) {
+		case *query.EQ:
+			str, err = q.VisitEQ(f)
+		case *query.NEQ:
+			str, err = q.VisitNEQ(f)
+		case *query.GT:
+			str, err = q.VisitGT(f)
+		case *query.GTE:
+			str, err = q.VisitGTE(f)
+		case *query.LT:
+			str, err = q.VisitLT(f)
+		case *query.LTE:
+			str, err = q.VisitLTE(f)
+		case *query.IN:
+			str, err = q.VisitIN(f)
+		case *query.OR:
+			str, err = q.VisitOR(f)
+		case *query.AND:
+			str, err = q.VisitAND(f)
+		case *query.NOT:
+			str, err = q.VisitNOT(f)
+		case *query.LIKE:
+			str, err = q.VisitLIKE(f)
+		case *query.EXISTS:
+			str, err = q.VisitEXISTS(f)
+		case *query.CONTAINS:
+			str, err = q.VisitCONTAINS(f)
+		default:
+			return "", fmt.Errorf("unsupported filter type %#v", f)
+		}
+		if err != nil {
+			return "", err
+		}
+		arr = append(arr, str)
+	}
+
+	sep := " " + op + " "
+
+	return "(" + strings.Join(arr, sep) + ")", nil
+}
+
+func (q *Query) VisitAND(f *query.AND) (string, error) {
+	return q.visitFilters("AND", f.Filters)
+}
+
+func (q *Query) VisitOR(f *query.OR) (string, error) {
+	return q.visitFilters("OR", f.Filters)
+}
+
+func (q *Query) VisitNOT(f *query.NOT) (string, error) {
+	str, err := q.visitFilters("AND", []query.Filter{f.Filter})
+	if err != nil {
+		return "", err
+	}
+	return "NOT COALESCE(" + str + ", FALSE)", nil
+}
+
+func (q *Query) VisitLIKE(f *query.LIKE) (string, error) {
+	// The pattern is translated to use "!" as the escape character, because the meaning of "\\" depends on the NO_BACKSLASH_ESCAPES SQL mode
+	// The binary collation makes the match case-sensitive
+	pattern := f.Translate(func(s string) string {
+		return commonsql.EscapeLike(s, '!')
+	}, func(c rune) string {
+		return string(c)
+	})
+	q.params = append(q.params, jsonPath(f.Key), pattern)
+	return fieldTextExpr + " COLLATE utf8mb4_bin LIKE ? ESCAPE '!'", nil
+}
+
+func (q *Query) VisitEXISTS(f *query.EXISTS) (string, error) {
+	q.params = append(q.params, jsonPath(f.Key))
+	return "JSON_CONTAINS_PATH(value, 'one', ?)", nil
+}
+
+func (q *Query) VisitCONTAINS(f *query.CONTAINS) (string, error) {
+	// JSON_CONTAINS expects the candidate as a JSON document
+	val, err := json.Marshal(f.Val)
+	if err != nil {
+		return "", fmt.Errorf("invalid value for key %q: %w", f.Key, err)
+	}
+	q.params = append(q.params, string(val), jsonPath(f.Key))
+	return "JSON_CONTAINS(value, ?, ?)", nil
+}
+
+func (q *Query) Finalize(filters string, qq *query.Query) error {
+	// Binary values are stored as base64-encoded JSON strings, so they are excluded from queries
+	q.query = "SELECT id, value, eTag FROM " + q.tableName +
+		" WHERE isbinary = FALSE AND (expiredate IS NULL OR expiredate > CURRENT_TIMESTAMP)"
+
+	if filters != "" {
+		q.query += " AND " + filters
+	}
+
+	if len(qq.Sort) > 0 {
+		q.query += " ORDER BY "
+
+		for sortIndex, sortItem := range qq.Sort {
+			if sortIndex > 0 {
+				q.query += ", "
+			}
+			// Sort on the JSON values so numbers are sorted numerically in MySQL
+			q.query += "JSON_EXTRACT(value, ?)"
+			q.params = append(q.params, jsonPath(sortItem.Key))
+			switch sortItem.Order {
+			case "", query.ASC:
+				// Nop
+			case query.DESC:
+				q.query += " DESC"
+			default:
+				return fmt.Errorf("invalid sort order %q", sortItem.Order)
+			}
+		}
+	}
+
+	if qq.Page.Limit > 0 {
+		q.limit = qq.Page.Limit
+	}
+
+	if len(qq.Page.Token) != 0 {
+		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
+		if err != nil {
+			return err
+		}
+		q.skip = &skip
+	}
+
+	// In MySQL, OFFSET can only be used together with LIMIT
+	switch {
+	case q.limit > 0 && q.skip != nil:
+		q.query += " LIMIT " + strconv.Itoa(q.limit) + " OFFSET " + strconv.FormatInt(*q.skip, 10)
+	case q.limit > 0:
+		q.query += " LIMIT " + strconv.Itoa(q.limit)
+	case q.skip != nil:
+		q.query += " LIMIT " + maxLimit + " OFFSET " + strconv.FormatInt(*q.skip, 10)
+	}
+
+	return nil
+}
+
+func (q *Query) execute(ctx context.Context, db querier) ([]state.QueryItem, string, error) {
+	rows, err := db.QueryContext(ctx, q.query, q.params...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	ret := []state.QueryItem{}
+	for rows.Next() {
+		var (
+			key  string
+			data []byte
+			etag string
+		)
+		if err = rows.Scan(&key, &data, &etag); err != nil {
+			return nil, "", err
+		}
+		ret = append(ret, state.QueryItem{
+			Key:  key,
+			Data: data,
+			ETag: &etag,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var token string
+	if q.limit != 0 {
+		var skip int64
+		if q.skip != nil {
+			skip = *q.skip
+		}
+		token = strconv.FormatInt(skip+int64(len(ret)), 10)
+	}
+
+	return ret, token, nil
+}
+
+// whereFieldText compares the text representation of a field with the value.
+func (q *Query) whereFieldText(key string, op string, value any) string {
+	q.params = append(q.params, jsonPath(key), fmt.Sprintf("%v", value))
+	return fieldTextExpr + op + "?"
+}
+
+// whereFieldCompare compares a field with the value, which must be numeric.
+// The value is passed as a number, so MySQL compares the field numerically.
+func (q *Query) whereFieldCompare(key string, op string, value any) (string, error) {
+	switch v := value.(type) {
+	case float64, float32, int, int64, int32:
+		q.params = append(q.params, jsonPath(key), v)
+		return fieldTextExpr + op + "?", nil
+	case string:
+		return "", fmt.Errorf("unsupported type of value %s; string type not permitted", v)
+	default:
+		return "", fmt.Errorf("unsupported type of value %v; only numeric values are permitted", v)
+	}
+}
+
+// Returns the JSON path for a (dot-separated) field name.
+// Each member name is quoted, so it can contain characters that are not allowed in identifiers.
+func jsonPath(key string) string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = strconv.Quote(p)
+	}
+	return "$." + strings.Join(parts, ".")
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+func TestMySQLQueryBuildQuery(t *testing.T) {
+	const base = "SELECT id, value, eTag FROM state WHERE isbinary = FALSE AND (expiredate IS NULL OR expiredate > CURRENT_TIMESTAMP)"
+	const field = "JSON_UNQUOTE(JSON_EXTRACT(value, ?))"
+
+	tests := []struct {
+		input  string
+		query  string
+		params []any
+	}{
+		{
+			input: "../../tests/state/query/q1.json",
+			query: base + " LIMIT 2",
+		},
+		{
+			input:  "../../tests/state/query/q2.json",
+			query:  base + " AND " + field + "=? LIMIT 2",
+			params: []any{`$."state"`, "CA"},
+		},
+		{
+			input:  "../../tests/state/query/q2-token.json",
+			query:  base + " AND " + field + "=? LIMIT 2 OFFSET 2",
+			params: []any{`$."state"`, "CA"},
+		},
+		{
+			input:  "../../tests/state/query/q3.json",
+			query:  base + " AND (" + field + "=? AND (" + field + "=? OR " + field + "=?)) ORDER BY JSON_EXTRACT(value, ?) DESC, JSON_EXTRACT(value, ?)",
+			params: []any{`$."person"."org"`, "A", `$."state"`, "CA", `$."state"`, "WA", `$."state"`, `$."person"."name"`},
+		},
+		{
+			input:  "../../tests/state/query/q4-notequal.json",
+			query:  base + " AND (" + field + "=? OR (" + field + "!=? AND (" + field + "=? OR " + field + "=?))) ORDER BY JSON_EXTRACT(value, ?) DESC, JSON_EXTRACT(value, ?) LIMIT 2",
+			params: []any{`$."person"."org"`, "A", `$."person"."org"`, "B", `$."state"`, "CA", `$."state"`, "WA", `$."state"`, `$."person"."name"`},
+		},
+		{
+			input:  "../../tests/state/query/q6.json",
+			query:  base + " AND (" + field + "=? OR (" + field + "=? AND (" + field + "=? OR " + field + "=?))) ORDER BY JSON_EXTRACT(value, ?) LIMIT 2",
+			params: []any{`$."person"."id"`, "123", `$."person"."org"`, "B", `$."person"."id"`, "567", `$."person"."id"`, "890", `$."person"."id"`},
+		},
+		{
+			input:  "../../tests/state/query/q8.json",
+			query:  base + " AND (" + field + ">=? OR (" + field + "<? AND (" + field + "=? OR " + field + "=?))) ORDER BY JSON_EXTRACT(value, ?) DESC, JSON_EXTRACT(value, ?) LIMIT 2",
+			params: []any{`$."person"."org"`, float64(123), `$."person"."org"`, float64(10), `$."state"`, "CA", `$."state"`, "WA", `$."state"`, `$."person"."name"`},
+		},
+		{
+			input:  "../../tests/state/query/q9.json",
+			query:  base + " AND (" + field + " COLLATE utf8mb4_bin LIKE ? ESCAPE '!' AND JSON_CONTAINS_PATH(value, 'one', ?) AND NOT COALESCE((" + field + "=?), FALSE) AND JSON_CONTAINS(value, ?, ?)) ORDER BY JSON_EXTRACT(value, ?) LIMIT 2",
+			params: []any{`$."person"."name"`, "J%", `$."person"."org"`, `$."state"`, "CA", `"blue"`, `$."tags"`, `$."person"."name"`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			data, err := os.ReadFile(test.input)
+			require.NoError(t, err)
+			var qq query.Query
+			err = json.Unmarshal(data, &qq)
+			require.NoError(t, err)
+
+			q := &Query{
+				tableName: defaultTableName,
+			}
+			qbuilder := query.NewQueryBuilder(q)
+			err = qbuilder.BuildQuery(&qq)
+			require.NoError(t, err)
+			assert.Equal(t, test.query, q.query)
+			assert.Equal(t, test.params, q.params)
+		})
+	}
+
+	t.Run("LIKE pattern", func(t *testing.T) {
+		var qq query.Query
+		err := json.Unmarshal([]byte(`{"filter":{"LIKE":{"name":"100\\%!_%"}}}`), &qq)
+		require.NoError(t, err)
+
+		q := &Query{
+			tableName: defaultTableName,
+		}
+		err = query.NewQueryBuilder(q).BuildQuery(&qq)
+		require.NoError(t, err)
+		assert.Equal(t, base+" AND "+field+" COLLATE utf8mb4_bin LIKE ? ESCAPE '!'", q.query)
+		assert.Equal(t, []any{`$."name"`, "100!%!!_%"}, q.params)
+	})
+
+	t.Run("range with non-numeric value", func(t *testing.T) {
+		for _, filter := range []string{`{"GT":{"name":"a"}}`, `{"LTE":{"active":true}}`} {
+			var qq query.Query
+			err := json.Unmarshal([]byte(`{"filter":`+filter+`}`), &qq)
+			require.NoError(t, err)
+
+			q := &Query{
+				tableName: defaultTableName,
+			}
+			err = query.NewQueryBuilder(q).BuildQuery(&qq)
+			require.Error(t, err, filter)
+		}
+	})
+
+	t.Run("token without limit", func(t *testing.T) {
+		var qq query.Query
+		err := json.Unmarshal([]byte(`{"page":{"token":"3"}}`), &qq)
+		require.NoError(t, err)
+
+		q := &Query{
+			tableName: defaultTableName,
+		}
+		err = query.NewQueryBuilder(q).BuildQuery(&qq)
+		require.NoError(t, err)
+		assert.Equal(t, base+" LIMIT "+maxLimit+" OFFSET 3", q.query)
+	})
+}
+
+func TestMySQLQuery(t *testing.T) {
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "value", "eTag"}).
+		AddRow("key1", `{"state": "CA"}`, "etag1").
+		AddRow("key2", `{"state": "CA"}`, "etag2")
+	m.mock1.ExpectQuery(regexp.QuoteMeta("SELECT id, value, eTag FROM state WHERE")).
+		WithArgs(`$."state"`, "CA").
+		WillReturnRows(rows)
+
+	req := &state.QueryRequest{}
+	err := json.Unmarshal([]byte(`{"filter":{"EQ":{"state":"CA"}},"page":{"limit":2}}`), &req.Query)
+	require.NoError(t, err)
+
+	res, err := m.mySQL.Query(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, res.Results, 2)
+	assert.Equal(t, "key1", res.Results[0].Key)
+	assert.Equal(t, `{"state": "CA"}`, string(res.Results[0].Data))
+	assert.Equal(t, "etag1", *res.Results[0].ETag)
+	assert.Equal(t, "key2", res.Results[1].Key)
+	assert.Equal(t, "2", res.Token)
+	require.NoError(t, m.mock1.ExpectationsWereMet())
+}
@@ -450,6 +450,43 @@ func TestGetSucceeds(t *testing.T) {
 	})
 }
 
+func TestListKeys(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.mySQL.Close()
+
+	t.Run("first page", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id"}).AddRow("app||a").AddRow("app||b").AddRow("app||c")
+		m.mock1.ExpectQuery("SELECT id FROM state").WithArgs("app||%", "", 3).WillReturnRows(rows)
+
+		// Act
+		res, err := m.mySQL.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: "app||", PageSize: 2})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app||a", "app||b"}, res.Keys)
+		assert.Equal(t, "app||b", res.ContinuationToken)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id"}).AddRow("app||c")
+		m.mock1.ExpectQuery("SELECT id FROM state").WithArgs("app||%", "app||b", 3).WillReturnRows(rows)
+
+		// Act
+		res, err := m.mySQL.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: "app||", PageSize: 2, ContinuationToken: "app||b"})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app||c"}, res.Keys)
+		assert.Empty(t, res.ContinuationToken)
+	})
+
+	t.Run("invalid page size", func(t *testing.T) {
+		_, err := m.mySQL.ListKeys(context.Background(), &state.ListKeysRequest{PageSize: -1})
+		require.Error(t, err)
+	})
+}
+
 // Verifies that the correct query is executed to test if the table
 // already exists in the database or not.
 func TestTableExists(t *testing.T) {
@@ -81,6 +81,19 @@ func performMigrations(ctx context.Context, db pginterfaces.PGXPoolConn, opts po
 			}
 			return nil
 		},
+
+		// Migration 2: index the keys with the "C" collation, which orders them by their bytes, to list them by prefix
+		func(ctx context.Context) error {
+			opts.Logger.Infof("Creating index on key column of state table '%s'", opts.StateTableName)
+			_, err := db.Exec(ctx, fmt.Sprintf(
+				`CREATE INDEX ON %s (key COLLATE "C")`,
+				opts.StateTableName,
+			))
+			if err != nil {
+				return fmt.Errorf("failed to create index on state table: %w", err)
+			}
+			return nil
+		},
 	},
 	)
 }
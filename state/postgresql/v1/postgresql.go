@@ -23,6 +23,7 @@ import (
 func NewPostgreSQLStateStore(logger logger.Logger) state.Store {
 	return postgresql.NewPostgreSQLQueryStateStore(logger, postgresql.Options{
 		ETagColumn:    "xmin",
+		KeyCollation:  "C",
 		EnableAzureAD: true,
 		EnableAWSIAM:  true,
 		MigrateFn:     performMigrations,
@@ -42,6 +42,7 @@ type pgMetadata struct {
 	MetadataTableName string         `mapstructure:"metadataTableName"` // Could be in the format "schema.table" or just "table"
 	Timeout           time.Duration  `mapstructure:"timeout" mapstructurealiases:"timeoutInSeconds"`
 	CleanupInterval   *time.Duration `mapstructure:"cleanupInterval" mapstructurealiases:"cleanupIntervalInSeconds"`
+	EnableWatch       bool           `mapstructure:"enableWatch"` // Creates the trigger on the state table that's required by Watch
 
 	aws.DeprecatedPostgresIAM `mapstructure:",squash"`
 }
@@ -53,6 +54,7 @@ func (m *pgMetadata) InitWithMetadata(meta state.Metadata, opts pgauth.InitWithM
 	m.MetadataTableName = "dapr_metadata"
 	m.CleanupInterval = ptr.Of(defaultCleanupInternal)
 	m.Timeout = defaultTimeout
+	m.EnableWatch = false
 
 	// Decode the metadata
 	err := metadata.DecodeMetadata(meta.Properties, &m)
@@ -72,6 +72,23 @@ func TestMetadata(t *testing.T) {
 		assert.Equal(t, "my_state", m.TableName(pgTableState))
 	})
 
+	t.Run("watch is disabled by default", func(t *testing.T) {
+		m := pgMetadata{}
+		props := map[string]string{
+			"connectionString": "foo",
+		}
+
+		opts := postgresql.InitWithMetadataOpts{}
+		err := m.InitWithMetadata(state.Metadata{Base: metadata.Base{Properties: props}}, opts)
+		require.NoError(t, err)
+		assert.False(t, m.EnableWatch)
+
+		props["enableWatch"] = "true"
+		err = m.InitWithMetadata(state.Metadata{Base: metadata.Base{Properties: props}}, opts)
+		require.NoError(t, err)
+		assert.True(t, m.EnableWatch)
+	})
+
 	t.Run("default timeout", func(t *testing.T) {
 		m := pgMetadata{}
 		props := map[string]string{
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -54,6 +56,10 @@ type PostgreSQL struct {
 	enableAWSIAM  bool
 
 	awsAuthProvider awsAuth.Provider
+
+	closed  atomic.Bool
+	closeCh chan struct{}
+	wg      sync.WaitGroup
 }
 
 type Options struct {
@@ -79,6 +85,7 @@ func NewPostgreSQLStateStoreWithOptions(logger logger.Logger, opts Options) stat
 		logger:        logger,
 		enableAzureAD: !opts.NoAzureAD,
 		enableAWSIAM:  !opts.NoAWSIAM,
+		closeCh:       make(chan struct{}),
 	}
 	s.BulkStore = state.NewDefaultBulkStore(s)
 	return s
@@ -138,6 +145,12 @@ func (p *PostgreSQL) Init(ctx context.Context, meta state.Metadata) (err error)
 		return err
 	}
 
+	// The trigger used by Watch is created or dropped on each start, so it follows the metadata
+	err = p.setWatchTrigger(ctx)
+	if err != nil {
+		return err
+	}
+
 	if p.metadata.CleanupInterval != nil {
 		gc, err := sqlinternal.ScheduleGarbageCollector(sqlinternal.GCOptions{
 			Logger: p.logger,
@@ -209,16 +222,88 @@ CREATE INDEX ON %[1]s (expires_at);
 			}
 			return nil
 		},
+		// Migration 2: add the "datatype" column, which records whether the value is a JSON document that can be queried
+		func(ctx context.Context) error {
+			p.logger.Infof("Adding 'datatype' column to state table: '%s'", stateTable)
+			_, err := p.db.Exec(ctx, "ALTER TABLE "+stateTable+" ADD COLUMN IF NOT EXISTS datatype text")
+			if err != nil {
+				return fmt.Errorf("failed to add 'datatype' column to state table: '%s', %v", stateTable, err)
+			}
+			return p.backfillDataType(ctx, stateTable)
+		},
+		// Migration 3: index the keys with the "C" collation, which orders them by their bytes, to list them by prefix
+		func(ctx context.Context) error {
+			p.logger.Infof("Creating index on 'key' column of state table: '%s'", stateTable)
+			_, err := p.db.Exec(ctx, `CREATE INDEX ON `+stateTable+` (key COLLATE "C")`)
+			if err != nil {
+				return fmt.Errorf("failed to create index on 'key' column of state table: '%s', %v", stateTable, err)
+			}
+			return nil
+		},
 	})
 }
 
+// backfillDataType sets the "datatype" column for the rows that were stored before the column was added.
+// It runs once, in the migration that adds the column. Rows stored without a "datatype" afterwards, by instances still running a previous version during an upgrade, are not returned by queries.
+// Values can only be inspected in Go, so rows are processed in batches.
+func (p *PostgreSQL) backfillDataType(ctx context.Context, stateTable string) error {
+	const batchSize = 1000
+	for {
+		rows, err := p.db.Query(ctx, "SELECT key, value FROM "+stateTable+" WHERE datatype IS NULL LIMIT "+strconv.Itoa(batchSize))
+		if err != nil {
+			return fmt.Errorf("failed to read rows of state table: '%s', %v", stateTable, err)
+		}
+		var (
+			key      string
+			value    []byte
+			keys     []string
+			jsonKeys []string
+		)
+		for rows.Next() {
+			err = rows.Scan(&key, &value)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to read rows of state table: '%s', %v", stateTable, err)
+			}
+			keys = append(keys, key)
+			if getDataType(value) == dataTypeJSON {
+				jsonKeys = append(jsonKeys, key)
+			}
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("failed to read rows of state table: '%s', %v", stateTable, err)
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		_, err = p.db.Exec(ctx,
+			"UPDATE "+stateTable+" SET datatype = CASE WHEN key = ANY($1) THEN '"+dataTypeJSON+"' ELSE '"+dataTypeBytes+"' END WHERE key = ANY($2) AND datatype IS NULL",
+			jsonKeys, keys,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update rows of state table: '%s', %v", stateTable, err)
+		}
+		if len(keys) < batchSize {
+			return nil
+		}
+	}
+}
+
 // Features returns the features available in this state store.
 func (p *PostgreSQL) Features() []state.Feature {
-	return []state.Feature{
+	features := []state.Feature{
 		state.FeatureETag,
 		state.FeatureTransactional,
+		state.FeatureQueryAPI,
 		state.FeatureTTL,
+		state.FeatureKeyListing,
+	}
+	if p.metadata.EnableWatch {
+		features = append(features, state.FeatureWatch)
 	}
+	return features
 }
 
 func (p *PostgreSQL) GetDB() *pgxpool.Pool {
@@ -280,9 +365,9 @@ func (p *PostgreSQL) doSet(parentCtx context.Context, db pginterfaces.DBQuerier,
 			return state.NewETagError(state.ETagMismatch, err)
 		}
 
-		params = []any{req.Key, value, etag.String()}
+		params = []any{req.Key, value, getDataType(value), etag.String()}
 	} else {
-		params = []any{req.Key, value}
+		params = []any{req.Key, value, getDataType(value)}
 	}
 
 	if ttlSeconds > 0 {
@@ -303,12 +388,13 @@ func (p *PostgreSQL) doSet(parentCtx context.Context, db pginterfaces.DBQuerier,
 
 		query = `
 INSERT INTO ` + p.metadata.TableName(pgTableState) + ` AS t
-  (key, value, etag, expires_at)
+  (key, value, datatype, etag, expires_at)
 VALUES
-  ($1, $2, gen_random_uuid(),` + queryExpiresAt + `)
+  ($1, $2, $3, gen_random_uuid(),` + queryExpiresAt + `)
 ON CONFLICT (key)
 DO UPDATE SET
   value = $2,
+  datatype = $3,
   updated_at = now(),
   etag = gen_random_uuid(),
   expires_at = ` + queryExpiresAt + whereClause
@@ -318,12 +404,13 @@ DO UPDATE SET
 UPDATE ` + p.metadata.TableName(pgTableState) + `
 SET
   value = $2,
+  datatype = $3,
   updated_at = now(),
   etag = gen_random_uuid(),
   expires_at = ` + queryExpiresAt + `
 WHERE
   key = $1
-  AND etag = $3
+  AND etag = $4
   AND (expires_at IS NULL OR expires_at >= now());`
 	}
 
@@ -460,6 +547,49 @@ WHERE
 	return res[:n], nil
 }
 
+// ListKeys returns the keys in the store, sorted by their bytes.
+// The continuation token is the last key of the previous page.
+func (p *PostgreSQL) ListKeys(parentCtx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys with the prefix are selected as a range rather than with LIKE, using the collation of the index created by the migrations
+	args := []any{req.Prefix, req.ContinuationToken}
+	query := `SELECT key
+		FROM ` + p.metadata.TableName(pgTableState) + `
+		WHERE
+			key COLLATE "C" >= $1
+			AND key COLLATE "C" > $2
+			AND (expires_at IS NULL OR expires_at >= now())`
+	if end := sqlinternal.PrefixRangeEnd(req.Prefix); end != "" {
+		args = append(args, end)
+		query += `
+			AND key COLLATE "C" < $` + strconv.Itoa(len(args))
+	}
+	args = append(args, req.PageSize+1)
+	query += `
+		ORDER BY key COLLATE "C"
+		LIMIT $` + strconv.Itoa(len(args))
+
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+	rows, err := p.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := &state.ListKeysResponse{}
+	res.Keys, res.ContinuationToken, err = stateutils.ReadKeysPage(rows, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // Delete removes an item from the state store.
 func (p *PostgreSQL) Delete(ctx context.Context, req *state.DeleteRequest) error {
 	if req == nil {
@@ -547,6 +677,12 @@ func (p *PostgreSQL) CleanupExpired() error {
 
 // Close implements io.Close.
 func (p *PostgreSQL) Close() error {
+	if p.closed.CompareAndSwap(false, true) {
+		close(p.closeCh)
+	}
+	// Watches must release their connections before the pool is closed
+	p.wg.Wait()
+
 	if p.db != nil {
 		p.db.Close()
 		p.db = nil
@@ -735,6 +735,42 @@ func TestMultiOperationOrder(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestListKeys(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	t.Run("first page", func(t *testing.T) {
+		m.db.ExpectQuery("SELECT key").
+			WithArgs("app_1||", "", "app_1|}", 3).
+			WillReturnRows(pgxmock.NewRows([]string{"key"}).AddRow("app_1||a").AddRow("app_1||b").AddRow("app_1||c"))
+
+		// Act
+		res, err := m.pg.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: "app_1||", PageSize: 2})
+
+		// Assert
+		require.NoError(t, err)
+		require.Equal(t, []string{"app_1||a", "app_1||b"}, res.Keys)
+		require.Equal(t, "app_1||b", res.ContinuationToken)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		m.db.ExpectQuery("SELECT key").
+			WithArgs("app_1||", "app_1||b", "app_1|}", 3).
+			WillReturnRows(pgxmock.NewRows([]string{"key"}).AddRow("app_1||c"))
+
+		// Act
+		res, err := m.pg.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: "app_1||", PageSize: 2, ContinuationToken: "app_1||b"})
+
+		// Assert
+		require.NoError(t, err)
+		require.Equal(t, []string{"app_1||c"}, res.Keys)
+		require.Empty(t, res.ContinuationToken)
+	})
+
+	require.NoError(t, m.db.ExpectationsWereMet())
+}
+
 func createSetRequest() state.SetRequest {
 	return state.SetRequest{
 		Key:   randomKey(),
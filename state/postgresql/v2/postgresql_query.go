@@ -0,0 +1,312 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	pginterfaces "github.com/dapr/components-contrib/common/component/postgresql/interfaces"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+// Values of the "datatype" column.
+// Values are always stored as BYTEA, and only those stored as JSON documents can be queried.
+const (
+	dataTypeJSON  = "json"
+	dataTypeBytes = "bytes"
+)
+
+// getDataType returns the value of the "datatype" column for a value.
+func getDataType(value []byte) string {
+	// Values must also be valid UTF-8 to be converted to text, and PostgreSQL doesn't allow the NUL character in JSONB strings
+	if !json.Valid(value) || !utf8.Valid(value) || bytes.Contains(value, []byte(`\u0000`)) {
+		return dataTypeBytes
+	}
+	return dataTypeJSON
+}
+
+// Query executes a query against store.
+func (p *PostgreSQL) Query(parentCtx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	q := &Query{
+		query:     "",
+		params:    []any{},
+		tableName: p.metadata.TableName(pgTableState),
+	}
+	qbuilder := query.NewQueryBuilder(q)
+	if err := qbuilder.BuildQuery(&req.Query); err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+
+	data, token, err := q.execute(ctx, p.db)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	return &state.QueryResponse{
+		Results: data,
+		Token:   token,
+	}, nil
+}
+
+type Query struct {
+	query     string
+	params    []any
+	limit     int
+	skip      *int64
+	tableName string
+}
+
+func (q *Query) VisitEQ(f *query.EQ) (string, error) {
+	return q.whereField(f.Key, "=", f.Val), nil
+}
+
+func (q *Query) VisitNEQ(f *query.NEQ) (string, error) {
+	return q.whereField(f.Key, "!=", f.Val), nil
+}
+
+func (q *Query) VisitGT(f *query.GT) (string, error) {
+	return q.whereFieldCompare(f.Key, ">", f.Val)
+}
+
+func (q *Query) VisitGTE(f *query.GTE) (string, error) {
+	return q.whereFieldCompare(f.Key, ">=", f.Val)
+}
+
+func (q *Query) VisitLT(f *query.LT) (string, error) {
+	return q.whereFieldCompare(f.Key, "<", f.Val)
+}
+
+func (q *Query) VisitLTE(f *query.LTE) (string, error) {
+	return q.whereFieldCompare(f.Key, "<=", f.Val)
+}
+
+func (q *Query) VisitIN(f *query.IN) (string, error) {
+	if len(f.Vals) == 0 {
+		return "", fmt.Errorf("empty IN operator for key %q", f.Key)
+	}
+
+	conds := make([]string, len(f.Vals))
+	for i, v := range f.Vals {
+		conds[i] = q.whereField(f.Key, "=", v)
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", nil
+}
+
+func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
+	var (
+		arr []string
+		str string
+		err error
+	)
+
+	for _, fil := range filters {
+		switch f := fil.(type) {
+		case *query.EQ:
+			str, err = q.VisitEQ(f)
+		case *query.NEQ:
+			str, err = q.VisitNEQ(f)
+		case *query.GT:
+			str, err = q.VisitGT(f)
+		case *query.GTE:
+			str, err = q.VisitGTE(f)
+		case *query.LT:
+			str, err = q.VisitLT(f)
+		case *query.LTE:
+			str, err = q.VisitLTE(f)
+		case *query.IN:
+			str, err = q.VisitIN(f)
+		case *query.OR:
+			str, err = q.VisitOR(f)
+		case *query.AND:
+			str, err = q.VisitAND(f)
+		case *query.NOT:
+			str, err = q.VisitNOT(f)
+		case *query.LIKE:
+			str, err = q.VisitLIKE(f)
+		case *query.EXISTS:
+			str, err = q.VisitEXISTS(f)
+		case *query.CONTAINS:
+			str, err = q.VisitCONTAINS(f)
+		default:
+			return "", fmt.Errorf("unsupported filter type %#v", f)
+		}
+		if err != nil {
+			return "", err
+		}
+		arr = append(arr, str)
+	}
+
+	return "(" + strings.Join(arr, " "+op+" ") + ")", nil
+}
+
+func (q *Query) VisitAND(f *query.AND) (string, error) {
+	return q.visitFilters("AND", f.Filters)
+}
+
+func (q *Query) VisitOR(f *query.OR) (string, error) {
+	return q.visitFilters("OR", f.Filters)
+}
+
+func (q *Query) VisitNOT(f *query.NOT) (string, error) {
+	str, err := q.visitFilters("AND", []query.Filter{f.Filter})
+	if err != nil {
+		return "", err
+	}
+	return "NOT COALESCE(" + str + ", FALSE)", nil
+}
+
+func (q *Query) VisitLIKE(f *query.LIKE) (string, error) {
+	// The pattern uses the same wildcards and escape character as LIKE in PostgreSQL, so it's passed as-is
+	return q.whereField(f.Key, " LIKE ", f.Pattern) + ` ESCAPE '\'`, nil
+}
+
+func (q *Query) VisitEXISTS(f *query.EXISTS) (string, error) {
+	// The "->" operator returns NULL when the field doesn't exist, and a JSON null when the field is null
+	return q.fieldJSON(f.Key) + " IS NOT NULL", nil
+}
+
+func (q *Query) VisitCONTAINS(f *query.CONTAINS) (string, error) {
+	// The value is compared as JSON, so its type must match the type of the array elements
+	val, err := json.Marshal([]any{f.Val})
+	if err != nil {
+		return "", fmt.Errorf("invalid value for CONTAINS operator: %w", err)
+	}
+	field := q.fieldJSON(f.Key)
+	q.params = append(q.params, string(val))
+	return field + " @> $" + strconv.Itoa(len(q.params)) + "::jsonb", nil
+}
+
+func (q *Query) Finalize(filters string, qq *query.Query) error {
+	// Values are stored as BYTEA, so the ones that are JSON documents are converted to JSONB in a subquery.
+	// The CASE expression ensures the conversion is never attempted on other values, regardless of the order in which the filters are evaluated.
+	// Rows without a datatype, stored by instances still running a previous version, are excluded.
+	// The original value is returned, so results are the same as with Get.
+	q.query = `SELECT key, data, etag FROM (SELECT key, value AS data, etag, CASE WHEN datatype = '` + dataTypeJSON + `' THEN convert_from(value, 'UTF8')::jsonb END AS value FROM ` + q.tableName +
+		` WHERE datatype = '` + dataTypeJSON + `' AND (expires_at IS NULL OR expires_at >= now())) AS t`
+
+	if filters != "" {
+		q.query += " WHERE " + filters
+	}
+
+	orderBy := make([]string, 0, len(qq.Sort)+1)
+	for _, sortItem := range qq.Sort {
+		item := q.field(sortItem.Key)
+		if sortItem.Order != "" {
+			item += " " + sortItem.Order
+		}
+		orderBy = append(orderBy, item)
+	}
+	if qq.Page.Limit > 0 || qq.Page.Token != "" {
+		// Sort by key too, so pages are stable
+		orderBy = append(orderBy, "key")
+	}
+	if len(orderBy) > 0 {
+		q.query += " ORDER BY " + strings.Join(orderBy, ", ")
+	}
+
+	if qq.Page.Limit > 0 {
+		q.query += " LIMIT " + strconv.Itoa(qq.Page.Limit)
+		q.limit = qq.Page.Limit
+	}
+
+	if qq.Page.Token != "" {
+		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
+		if err != nil || skip < 0 {
+			return fmt.Errorf("invalid pagination token: %s", qq.Page.Token)
+		}
+		q.query += " OFFSET " + strconv.FormatInt(skip, 10)
+		q.skip = &skip
+	}
+
+	return nil
+}
+
+func (q *Query) execute(ctx context.Context, db pginterfaces.DBQuerier) ([]state.QueryItem, string, error) {
+	rows, err := db.Query(ctx, q.query, q.params...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	ret := []state.QueryItem{}
+	for rows.Next() {
+		var (
+			key  string
+			data []byte
+			etag string
+		)
+		if err = rows.Scan(&key, &data, &etag); err != nil {
+			return nil, "", err
+		}
+		ret = append(ret, state.QueryItem{
+			Key:  key,
+			Data: data,
+			ETag: &etag,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	// The token for the next page is set only if the query has a limit
+	var token string
+	if q.limit > 0 {
+		var skip int64
+		if q.skip != nil {
+			skip = *q.skip
+		}
+		token = strconv.FormatInt(skip+int64(len(ret)), 10)
+	}
+
+	return ret, token, nil
+}
+
+// whereField returns the condition comparing the field, as text, with the value.
+// Values are compared as text like in the v1 of the component, so queries return the same results.
+func (q *Query) whereField(key string, op string, value any) string {
+	field := q.field(key)
+	q.params = append(q.params, fmt.Sprintf("%v", value))
+	return field + op + "$" + strconv.Itoa(len(q.params))
+}
+
+func (q *Query) whereFieldCompare(key string, op string, value any) (string, error) {
+	if v, ok := value.(string); ok {
+		return "", fmt.Errorf("unsupported type of value %s; string type not permitted", v)
+	}
+	return q.whereField(key, op, value), nil
+}
+
+// Returns the expression that extracts a (dot-separated) field from the value, as text.
+// The path is passed as a parameter, which is added to the list of parameters.
+func (q *Query) field(key string) string {
+	q.params = append(q.params, strings.Split(key, "."))
+	return "value#>>$" + strconv.Itoa(len(q.params)) + "::text[]"
+}
+
+// Returns the expression that extracts a field as JSON, rather than as text like field.
+func (q *Query) fieldJSON(key string) string {
+	q.params = append(q.params, strings.Split(key, "."))
+	return "value#>$" + strconv.Itoa(len(q.params)) + "::text[]"
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state/query"
+)
+
+func TestPostgresqlQueryBuildQuery(t *testing.T) {
+	const base = "SELECT key, data, etag FROM (SELECT key, value AS data, etag, CASE WHEN datatype = 'json' THEN convert_from(value, 'UTF8')::jsonb END AS value FROM state WHERE datatype = 'json' AND (expires_at IS NULL OR expires_at >= now())) AS t"
+
+	tests := []struct {
+		input string
+		query string
+	}{
+		{
+			input: "../../../tests/state/query/q1.json",
+			query: base + " ORDER BY key LIMIT 2",
+		},
+		{
+			input: "../../../tests/state/query/q2.json",
+			query: base + " WHERE value#>>$1::text[]=$2 ORDER BY key LIMIT 2",
+		},
+		{
+			input: "../../../tests/state/query/q2-token.json",
+			query: base + " WHERE value#>>$1::text[]=$2 ORDER BY key LIMIT 2 OFFSET 2",
+		},
+		{
+			input: "../../../tests/state/query/q3.json",
+			query: base + " WHERE (value#>>$1::text[]=$2 AND (value#>>$3::text[]=$4 OR value#>>$5::text[]=$6)) ORDER BY value#>>$7::text[] DESC, value#>>$8::text[]",
+		},
+		{
+			input: "../../../tests/state/query/q4-notequal.json",
+			query: base + " WHERE (value#>>$1::text[]=$2 OR (value#>>$3::text[]!=$4 AND (value#>>$5::text[]=$6 OR value#>>$7::text[]=$8))) ORDER BY value#>>$9::text[] DESC, value#>>$10::text[], key LIMIT 2",
+		},
+		{
+			input: "../../../tests/state/query/q8.json",
+			query: base + " WHERE (value#>>$1::text[]>=$2 OR (value#>>$3::text[]<$4 AND (value#>>$5::text[]=$6 OR value#>>$7::text[]=$8))) ORDER BY value#>>$9::text[] DESC, value#>>$10::text[], key LIMIT 2",
+		},
+		{
+			input: "../../../tests/state/query/q9.json",
+			query: base + " WHERE (value#>>$1::text[] LIKE $2 ESCAPE '\\' AND value#>$3::text[] IS NOT NULL AND NOT COALESCE((value#>>$4::text[]=$5), FALSE) AND value#>$6::text[] @> $7::jsonb) ORDER BY value#>>$8::text[], key LIMIT 2",
+		},
+	}
+	for _, test := range tests {
+		data, err := os.ReadFile(test.input)
+		require.NoError(t, err)
+		var qq query.Query
+		err = json.Unmarshal(data, &qq)
+		require.NoError(t, err)
+
+		q := &Query{
+			tableName: "state",
+		}
+		qbuilder := query.NewQueryBuilder(q)
+		err = qbuilder.BuildQuery(&qq)
+		require.NoError(t, err)
+		assert.Equal(t, test.query, q.query)
+	}
+
+	t.Run("field names are passed as parameters", func(t *testing.T) {
+		var qq query.Query
+		err := json.Unmarshal([]byte(`{"filter":{"EQ":{"a'||(SELECT current_user)||'.b":"x"}},"sort":[{"key":"a'||(SELECT current_user)||'.b"}]}`), &qq)
+		require.NoError(t, err)
+		q := &Query{tableName: "state"}
+		require.NoError(t, query.NewQueryBuilder(q).BuildQuery(&qq))
+		assert.Equal(t, base+" WHERE value#>>$1::text[]=$2 ORDER BY value#>>$3::text[]", q.query)
+		assert.Equal(t, []any{[]string{"a'||(SELECT current_user)||'", "b"}, "x", []string{"a'||(SELECT current_user)||'", "b"}}, q.params)
+	})
+
+	t.Run("projections and aggregations are not supported", func(t *testing.T) {
+		data, err := os.ReadFile("../../../tests/state/query/q10-projection.json")
+		require.NoError(t, err)
+		var qq query.Query
+		require.NoError(t, json.Unmarshal(data, &qq))
+
+		err = query.NewQueryBuilder(&Query{tableName: "state"}).BuildQuery(&qq)
+		require.ErrorIs(t, err, query.ErrAggregationsNotSupported)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		qq := query.Query{}
+		qq.Page.Token = "abc"
+		err := query.NewQueryBuilder(&Query{tableName: "state"}).BuildQuery(&qq)
+		require.ErrorContains(t, err, "invalid pagination token")
+	})
+}
+
+func TestGetDataType(t *testing.T) {
+	assert.Equal(t, dataTypeJSON, getDataType([]byte(`{"message":"hello"}`)))
+	assert.Equal(t, dataTypeJSON, getDataType([]byte(`"hello"`)))
+	assert.Equal(t, dataTypeJSON, getDataType([]byte(`42`)))
+	assert.Equal(t, dataTypeBytes, getDataType([]byte(`hello`)))
+	assert.Equal(t, dataTypeBytes, getDataType([]byte{0xFF, 0x00, 0x01}))
+	assert.Equal(t, dataTypeBytes, getDataType([]byte(`{"message":"\u0000"}`)))
+	assert.Equal(t, dataTypeBytes, getDataType([]byte("\"\xff\"")))
+}
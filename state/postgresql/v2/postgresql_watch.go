@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+// Operations in the payload of notifications sent by the trigger on the state table.
+const (
+	watchOpSet    = "set"
+	watchOpDelete = "delete"
+)
+
+const (
+	// Payloads of notifications must be shorter than 8000 bytes.
+	watchMaxPayload = 8000
+	// Number of characters of the keys sent with their hash when they're too long for the payload; escaped in JSON, they take less than 4000 bytes.
+	watchKeyPrefixLength = 500
+)
+
+// watchNotification is the payload of notifications sent by the trigger on the state table.
+// Keys that don't fit in the payload are sent as their hash, with the beginning of the key.
+type watchNotification struct {
+	Op        string  `json:"op"`
+	Key       string  `json:"key,omitempty"`
+	KeyHash   string  `json:"keyHash,omitempty"`
+	KeyPrefix string  `json:"keyPrefix,omitempty"`
+	ETag      *string `json:"etag,omitempty"`
+}
+
+// watchChannelName returns the name of the channel where changes to the state table are notified.
+// Channel names are identifiers, so they are derived from a hash of the table name, which can be longer than the maximum length and contain a schema.
+func watchChannelName(stateTable string) string {
+	h := sha256.Sum256([]byte(stateTable))
+	return "dapr_state_" + hex.EncodeToString(h[:8])
+}
+
+// watchTriggerQuery returns the query that creates the trigger that notifies the changes to the state table.
+// Notifications contain the key and the new ETag only, as their payload is limited to 8000 bytes; longer keys are replaced with their MD5 hash and their first characters.
+// The table is locked first, so instances that start at the same time don't conflict.
+func watchTriggerQuery(stateTable string) string {
+	return fmt.Sprintf(`
+BEGIN;
+LOCK TABLE %[1]s IN SHARE ROW EXCLUSIVE MODE;
+
+CREATE OR REPLACE FUNCTION %[1]s_notify() RETURNS trigger AS $$
+DECLARE
+  rec record;
+  op text;
+  payload text;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    rec := OLD;
+    op := '%[4]s';
+  ELSE
+    rec := NEW;
+    op := '%[3]s';
+  END IF;
+  payload := json_build_object('op', op, 'key', rec.key, 'etag', CASE WHEN op = '%[3]s' THEN rec.etag END)::text;
+  IF octet_length(payload) >= %[5]d THEN
+    payload := json_build_object('op', op, 'keyHash', md5(rec.key), 'keyPrefix', left(rec.key, %[6]d), 'etag', CASE WHEN op = '%[3]s' THEN rec.etag END)::text;
+  END IF;
+  PERFORM pg_notify('%[2]s', payload);
+  RETURN rec;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS dapr_notify ON %[1]s;
+CREATE TRIGGER dapr_notify AFTER INSERT OR UPDATE OR DELETE ON %[1]s
+  FOR EACH ROW EXECUTE FUNCTION %[1]s_notify();
+COMMIT;
+`, stateTable, watchChannelName(stateTable), watchOpSet, watchOpDelete, watchMaxPayload, watchKeyPrefixLength)
+}
+
+// setWatchTrigger creates the trigger used by Watch if it's enabled in the metadata, and drops it otherwise.
+func (p *PostgreSQL) setWatchTrigger(ctx context.Context) error {
+	stateTable := p.metadata.TableName(pgTableState)
+	if !p.metadata.EnableWatch {
+		// The trigger is looked up first, as some PostgreSQL-compatible databases (such as CockroachDB) don't support DROP TRIGGER
+		var exists bool
+		err := p.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'dapr_notify' AND tgrelid = $1::regclass)", stateTable).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to look up trigger on state table: '%s', %v", stateTable, err)
+		}
+		if !exists {
+			return nil
+		}
+
+		p.logger.Infof("Watch is disabled, dropping trigger on state table: '%s'", stateTable)
+		_, err = p.db.Exec(ctx, "DROP TRIGGER IF EXISTS dapr_notify ON "+stateTable)
+		if err != nil {
+			return fmt.Errorf("failed to drop trigger on state table: '%s', %v", stateTable, err)
+		}
+		return nil
+	}
+
+	p.logger.Infof("Creating trigger on state table: '%s'", stateTable)
+	_, err := p.db.Exec(ctx, watchTriggerQuery(stateTable))
+	if err != nil {
+		return fmt.Errorf("failed to create trigger on state table: '%s', %v", stateTable, err)
+	}
+	return nil
+}
+
+// Watch invokes handler for each change to the keys that match the request, until ctx is canceled.
+// Each watch holds a connection of the pool, which is used to LISTEN for the notifications sent by a trigger on the state table.
+// Expired items are notified as deleted when they are removed by the garbage collector.
+func (p *PostgreSQL) Watch(ctx context.Context, req *state.WatchRequest, handler state.WatchHandler) error {
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+	if !p.metadata.EnableWatch {
+		return errors.New("watch is not enabled for this state store: set enableWatch in the metadata")
+	}
+	pool, ok := p.db.(*pgxpool.Pool)
+	if !ok {
+		return errors.New("watch requires a connection pool")
+	}
+	if p.closed.Load() {
+		return errors.New("state store is closed")
+	}
+
+	// Stop watching when the state store is closed, as the pool can't be closed while connections are acquired
+	ctx, cancel := context.WithCancel(ctx)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		select {
+		case <-ctx.Done():
+		case <-p.closeCh:
+			cancel()
+		}
+	}()
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	channel := watchChannelName(p.metadata.TableName(pgTableState))
+	_, err = conn.Exec(ctx, "LISTEN "+channel)
+	if err != nil {
+		conn.Release()
+		cancel()
+		return fmt.Errorf("failed to listen to channel '%s': %w", channel, err)
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer cancel()
+		defer func() {
+			// Stop listening before returning the connection to the pool, unless it was closed when ctx was canceled
+			if !conn.Conn().IsClosed() {
+				unlistenCtx, unlistenCancel := context.WithTimeout(context.Background(), 5*time.Second)
+				_, err := conn.Exec(unlistenCtx, "UNLISTEN "+channel)
+				unlistenCancel()
+				if err != nil {
+					conn.Conn().Close(context.Background())
+				}
+			}
+			conn.Release()
+		}()
+
+		for {
+			notification, err := conn.Conn().WaitForNotification(ctx)
+			if err != nil {
+				if !pgconn.Timeout(err) && !errors.Is(err, context.Canceled) {
+					p.logger.Errorf("Error waiting for notification for watch of key '%s': %v", req.Key, err)
+				}
+				return
+			}
+
+			event, err := parseWatchNotification(notification.Payload, req)
+			if err != nil {
+				p.logger.Warnf("Ignoring invalid notification for watch of key '%s': %v", req.Key, err)
+				continue
+			}
+			if event == nil {
+				continue
+			}
+
+			err = handler(ctx, event)
+			if err != nil {
+				p.logger.Errorf("Error from the handler of watch for key '%s': %v", req.Key, err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// parseWatchNotification returns the event of a notification, or nil if the notification doesn't match req.
+// Keys sent as their hash can only be matched by watches of that key, as the full key is unknown.
+func parseWatchNotification(payload string, req *state.WatchRequest) (*state.WatchEvent, error) {
+	var n watchNotification
+	err := json.Unmarshal([]byte(payload), &n)
+	if err != nil {
+		return nil, err
+	}
+
+	var event state.WatchEvent
+	switch n.Op {
+	case watchOpSet:
+		event.Type = state.WatchEventSet
+		event.ETag = n.ETag
+	case watchOpDelete:
+		event.Type = state.WatchEventDelete
+	default:
+		return nil, fmt.Errorf("unknown operation '%s'", n.Op)
+	}
+
+	event.Key = n.Key
+	if n.KeyHash != "" {
+		if req.Prefix || !strings.HasPrefix(req.Key, n.KeyPrefix) {
+			return nil, nil
+		}
+		h := md5.Sum([]byte(req.Key)) //nolint:gosec
+		if hex.EncodeToString(h[:]) != n.KeyHash {
+			return nil, nil
+		}
+		event.Key = req.Key
+	}
+	if !req.Matches(event.Key) {
+		return nil, nil
+	}
+	return &event, nil
+}
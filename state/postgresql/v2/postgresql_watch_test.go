@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+func TestWatchChannelName(t *testing.T) {
+	name := watchChannelName("state")
+	assert.Equal(t, name, watchChannelName("state"))
+	assert.NotEqual(t, name, watchChannelName("myschema.state"))
+	assert.Len(t, name, len("dapr_state_")+16)
+
+	query := watchTriggerQuery("myschema.state")
+	assert.Contains(t, query, "CREATE OR REPLACE FUNCTION myschema.state_notify()")
+	assert.Contains(t, query, "ON myschema.state")
+	assert.Equal(t, 1, strings.Count(query, "pg_notify('"+watchChannelName("myschema.state")+"'"))
+	assert.Contains(t, query, "LOCK TABLE myschema.state")
+}
+
+func TestParseWatchNotification(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		event, err := parseWatchNotification(`{"op":"set","key":"app||key","etag":"e9b9e142-74b1-4a2e-8e90-3f4ffeea2e70"}`, &state.WatchRequest{Key: "app||key"})
+		require.NoError(t, err)
+		assert.Equal(t, state.WatchEventSet, event.Type)
+		assert.Equal(t, "app||key", event.Key)
+		require.NotNil(t, event.ETag)
+		assert.Equal(t, "e9b9e142-74b1-4a2e-8e90-3f4ffeea2e70", *event.ETag)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		event, err := parseWatchNotification(`{"op":"delete","key":"app||key","etag":null}`, &state.WatchRequest{Key: "app||", Prefix: true})
+		require.NoError(t, err)
+		assert.Equal(t, state.WatchEventDelete, event.Type)
+		assert.Equal(t, "app||key", event.Key)
+		assert.Nil(t, event.ETag)
+	})
+
+	t.Run("other keys", func(t *testing.T) {
+		event, err := parseWatchNotification(`{"op":"set","key":"app||other","etag":"e9b9e142-74b1-4a2e-8e90-3f4ffeea2e70"}`, &state.WatchRequest{Key: "app||key"})
+		require.NoError(t, err)
+		assert.Nil(t, event)
+	})
+
+	t.Run("hashed keys", func(t *testing.T) {
+		key := "app||" + strings.Repeat("k", 10000)
+		h := md5.Sum([]byte(key)) //nolint:gosec
+		payload := `{"op":"set","keyHash":"` + hex.EncodeToString(h[:]) + `","keyPrefix":"` + key[:watchKeyPrefixLength] + `","etag":"e9b9e142-74b1-4a2e-8e90-3f4ffeea2e70"}`
+
+		event, err := parseWatchNotification(payload, &state.WatchRequest{Key: key})
+		require.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, key, event.Key)
+
+		// The full key isn't known, so it can't be matched by prefix
+		event, err = parseWatchNotification(payload, &state.WatchRequest{Key: "app||", Prefix: true})
+		require.NoError(t, err)
+		assert.Nil(t, event)
+
+		event, err = parseWatchNotification(payload, &state.WatchRequest{Key: key + "k"})
+		require.NoError(t, err)
+		assert.Nil(t, event)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseWatchNotification(`{"op":"truncate"}`, &state.WatchRequest{Key: "app||key"})
+		require.Error(t, err)
+		_, err = parseWatchNotification(`not json`, &state.WatchRequest{Key: "app||key"})
+		require.Error(t, err)
+	})
+}
@@ -16,6 +16,8 @@ package query
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 )
 
 type Filter interface {
@@ -77,6 +79,26 @@ func ParseFilter(obj interface{}) (Filter, error) {
 			f := &OR{}
 			err := f.Parse(v)
 
+			return f, err
+		case "NOT":
+			f := &NOT{}
+			err := f.Parse(v)
+
+			return f, err
+		case "LIKE":
+			f := &LIKE{}
+			err := f.Parse(v)
+
+			return f, err
+		case "EXISTS":
+			f := &EXISTS{}
+			err := f.Parse(v)
+
+			return f, err
+		case "CONTAINS":
+			f := &CONTAINS{}
+			err := f.Parse(v)
+
 			return f, err
 		default:
 			return nil, fmt.Errorf("unsupported filter %q", k)
@@ -255,6 +277,124 @@ func (f *OR) Parse(obj interface{}) (err error) {
 	return
 }
 
+// NOT negates a filter, matching all the items that are not matched by it.
+// Items where the fields referenced by the filter don't exist are not matched by the filter, so they are matched by its negation.
+type NOT struct {
+	Filter Filter
+}
+
+func (f *NOT) Parse(obj interface{}) (err error) {
+	f.Filter, err = ParseFilter(obj)
+
+	return
+}
+
+// LIKE matches string fields against a pattern.
+// In the pattern, "%" matches any sequence of characters and "_" matches any single character; for example, "abc%" matches all the values that start with "abc".
+// A backslash makes the character that follows it match literally: the pattern `100\%` matches the value "100%". Matching is case-sensitive.
+type LIKE struct {
+	Key     string
+	Pattern string
+}
+
+func (f *LIKE) Parse(obj interface{}) error {
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return errors.New("LIKE filter must be a map")
+	}
+	if len(m) != 1 {
+		return errors.New("LIKE filter must contain a single key/value pair")
+	}
+	for k, v := range m {
+		f.Key = k
+		if f.Pattern, ok = v.(string); !ok {
+			return errors.New("LIKE filter value must be a string")
+		}
+	}
+	trimmed := strings.TrimRight(f.Pattern, `\`)
+	if (len(f.Pattern)-len(trimmed))%2 != 0 {
+		return errors.New("LIKE filter pattern must not end with an escape character")
+	}
+
+	return nil
+}
+
+// Translate converts the pattern to the syntax of a state store.
+// Each run of literal text, with the escapes removed, is replaced by the result of literal, which escapes it as needed; each wildcard ("%" or "_") is replaced by the result of wildcard.
+func (f *LIKE) Translate(literal func(string) string, wildcard func(rune) string) string {
+	var b, text strings.Builder
+	escaped := false
+	for _, c := range f.Pattern {
+		switch {
+		case escaped:
+			text.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%', c == '_':
+			if text.Len() > 0 {
+				b.WriteString(literal(text.String()))
+				text.Reset()
+			}
+			b.WriteString(wildcard(c))
+		default:
+			text.WriteRune(c)
+		}
+	}
+	if text.Len() > 0 {
+		b.WriteString(literal(text.String()))
+	}
+
+	return b.String()
+}
+
+// Regexp returns a regular expression that is equivalent to the pattern, for state stores that support regular expressions rather than LIKE patterns.
+func (f *LIKE) Regexp() string {
+	return "^" + f.Translate(regexp.QuoteMeta, func(c rune) string {
+		if c == '%' {
+			return ".*"
+		}
+		return "."
+	}) + "$"
+}
+
+// EXISTS matches the items that contain the field, even if its value is null.
+type EXISTS struct {
+	Key string
+}
+
+func (f *EXISTS) Parse(obj interface{}) error {
+	key, ok := obj.(string)
+	if !ok || key == "" {
+		return errors.New("EXISTS filter must be the name of a field")
+	}
+	f.Key = key
+
+	return nil
+}
+
+// CONTAINS matches array fields that contain the value.
+type CONTAINS struct {
+	Key string
+	Val interface{}
+}
+
+func (f *CONTAINS) Parse(obj interface{}) error {
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return errors.New("CONTAINS filter must be a map")
+	}
+	if len(m) != 1 {
+		return errors.New("CONTAINS filter must contain a single key/value pair")
+	}
+	for k, v := range m {
+		f.Key = k
+		f.Val = v
+	}
+
+	return nil
+}
+
 func parseFilters(t string, obj interface{}) ([]Filter, error) {
 	arr, ok := obj.([]interface{})
 	if !ok {
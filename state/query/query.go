@@ -15,7 +15,11 @@ package query
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 )
 
 const (
@@ -26,6 +30,21 @@ const (
 	DESC   = "DESC"
 )
 
+// Aggregation operators.
+const (
+	COUNT = "COUNT"
+	SUM   = "SUM"
+	MIN   = "MIN"
+	MAX   = "MAX"
+	AVG   = "AVG"
+)
+
+// ErrAggregationsNotSupported is returned when a query with projections or aggregations is executed on a store that doesn't support them.
+var ErrAggregationsNotSupported = errors.New("projections and aggregations are not supported by this state store")
+
+// Aliases, and each part of the fields that are projected or aggregated, are used as names in the native queries, so they're restricted to identifiers.
+var aliasRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 type Sorting struct {
 	Key   string `json:"key"`
 	Order string `json:"order,omitempty"`
@@ -36,11 +55,24 @@ type Pagination struct {
 	Token string `json:"token,omitempty"`
 }
 
+// Aggregation is a value computed over the items matching the filter, or over each group of items when the query has a group-by clause.
+type Aggregation struct {
+	// One of COUNT, SUM, MIN, MAX and AVG.
+	Op string `json:"op"`
+	// Field the value is computed on; it's optional for COUNT, which counts the items when empty.
+	Key string `json:"key,omitempty"`
+	// Name of the value in the results.
+	Alias string `json:"alias"`
+}
+
 // used only for intermediate query value.
 type QueryFields struct {
-	Filters map[string]interface{} `json:"filter"`
-	Sort    []Sorting              `json:"sort"`
-	Page    Pagination             `json:"page"`
+	Filters      map[string]interface{} `json:"filter"`
+	Sort         []Sorting              `json:"sort"`
+	Page         Pagination             `json:"page"`
+	Projection   []string               `json:"projection,omitempty"`
+	Aggregations []Aggregation          `json:"aggregations,omitempty"`
+	GroupBy      []string               `json:"groupBy,omitempty"`
 }
 
 type Query struct {
@@ -69,10 +101,27 @@ type Visitor interface {
 	VisitAND(*AND) (string, error)
 	// returns "or" expression
 	VisitOR(*OR) (string, error)
+	// returns "not" expression
+	VisitNOT(*NOT) (string, error)
+	// returns "like" expression
+	VisitLIKE(*LIKE) (string, error)
+	// returns "field exists" expression
+	VisitEXISTS(*EXISTS) (string, error)
+	// returns "array contains" expression
+	VisitCONTAINS(*CONTAINS) (string, error)
 	// receives concatenated filters and finalizes the native query
 	Finalize(string, *Query) error
 }
 
+// AggregationVisitor is implemented by visitors that support projections and aggregations.
+// Its methods are invoked before Finalize, and only when the query has a projection or aggregations.
+type AggregationVisitor interface {
+	// receives the fields to include in the results
+	VisitProjection([]string) error
+	// receives the aggregations and the fields to group by
+	VisitAggregations([]Aggregation, []string) error
+}
+
 type Builder struct {
 	visitor Visitor
 }
@@ -89,6 +138,26 @@ func (h *Builder) BuildQuery(q *Query) error {
 		return err
 	}
 
+	if len(q.Projection) > 0 || len(q.Aggregations) > 0 {
+		av, ok := h.visitor.(AggregationVisitor)
+		if !ok {
+			return ErrAggregationsNotSupported
+		}
+		// Queries that were not parsed from JSON were not validated yet
+		err = q.validateAggregations()
+		if err != nil {
+			return err
+		}
+		if len(q.Projection) > 0 {
+			err = av.VisitProjection(q.Projection)
+		} else {
+			err = av.VisitAggregations(q.Aggregations, q.GroupBy)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
 	return h.visitor.Finalize(filters, q)
 }
 
@@ -115,6 +184,14 @@ func (h *Builder) buildFilter(filter Filter) (string, error) {
 		return h.visitor.VisitOR(f)
 	case *AND:
 		return h.visitor.VisitAND(f)
+	case *NOT:
+		return h.visitor.VisitNOT(f)
+	case *LIKE:
+		return h.visitor.VisitLIKE(f)
+	case *EXISTS:
+		return h.visitor.VisitEXISTS(f)
+	case *CONTAINS:
+		return h.visitor.VisitCONTAINS(f)
 	default:
 		return "", fmt.Errorf("unsupported filter type %#v", filter)
 	}
@@ -125,6 +202,10 @@ func (q *Query) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	err = q.validateAggregations()
+	if err != nil {
+		return err
+	}
 	if len(q.QueryFields.Filters) == 0 {
 		return nil
 	}
@@ -137,3 +218,81 @@ func (q *Query) UnmarshalJSON(data []byte) error {
 	q.Filter = filter
 	return nil
 }
+
+// validateAggregations validates the projection and the aggregations of the query.
+func (q *Query) validateAggregations() error {
+	if len(q.Projection) > 0 && len(q.Aggregations) > 0 {
+		return errors.New("query can't have both a projection and aggregations")
+	}
+	if len(q.GroupBy) > 0 && len(q.Aggregations) == 0 {
+		return errors.New("query with a group-by clause must have aggregations")
+	}
+
+	// A field can't be projected together with one of its sub-fields
+	for i, a := range q.Projection {
+		if a == "" {
+			return errors.New("empty field in projection")
+		}
+		if err := validateField(a); err != nil {
+			return fmt.Errorf("invalid field in projection: %w", err)
+		}
+		for _, b := range q.Projection[i+1:] {
+			if a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".") {
+				return fmt.Errorf("overlapping fields %q and %q in projection", a, b)
+			}
+		}
+	}
+
+	aliases := make(map[string]struct{}, len(q.Aggregations))
+	for _, a := range q.Aggregations {
+		switch a.Op {
+		case COUNT:
+			// Key is optional
+		case SUM, MIN, MAX, AVG:
+			if a.Key == "" {
+				return fmt.Errorf("aggregation %s requires a key", a.Op)
+			}
+		default:
+			return fmt.Errorf("unsupported aggregation %q", a.Op)
+		}
+		if a.Key != "" {
+			if err := validateField(a.Key); err != nil {
+				return fmt.Errorf("invalid key for aggregation %s: %w", a.Op, err)
+			}
+		}
+		if !aliasRegexp.MatchString(a.Alias) {
+			return fmt.Errorf("invalid alias %q for aggregation %s: must start with a letter or underscore and contain only letters, digits and underscores", a.Alias, a.Op)
+		}
+		if _, ok := aliases[a.Alias]; ok {
+			return fmt.Errorf("duplicate alias %q in aggregations", a.Alias)
+		}
+		aliases[a.Alias] = struct{}{}
+	}
+
+	for _, key := range q.GroupBy {
+		if err := validateField(key); err != nil {
+			return fmt.Errorf("invalid field in group-by clause: %w", err)
+		}
+	}
+
+	// Results of aggregations can only be sorted by the fields they're grouped by
+	if len(q.Aggregations) > 0 {
+		for _, s := range q.Sort {
+			if !slices.Contains(q.GroupBy, s.Key) {
+				return fmt.Errorf("query with aggregations can't be sorted by %q, which is not in the group-by clause", s.Key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateField validates a (dot-separated) field of a projection or an aggregation.
+func validateField(field string) error {
+	for _, part := range strings.Split(field, ".") {
+		if !aliasRegexp.MatchString(part) {
+			return fmt.Errorf("%q: each part must start with a letter or underscore and contain only letters, digits and underscores", field)
+		}
+	}
+	return nil
+}
@@ -131,6 +131,91 @@ func TestQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: "../../tests/state/query/q9.json",
+			query: Query{
+				QueryFields: QueryFields{
+					Filters: map[string]any{
+						"AND": []any{
+							map[string]any{
+								"LIKE": map[string]any{
+									"person.name": "J%",
+								},
+							},
+							map[string]any{
+								"EXISTS": "person.org",
+							},
+							map[string]any{
+								"NOT": map[string]any{
+									"EQ": map[string]any{
+										"state": "CA",
+									},
+								},
+							},
+							map[string]any{
+								"CONTAINS": map[string]any{
+									"tags": "blue",
+								},
+							},
+						},
+					},
+					Sort: []Sorting{
+						{Key: "person.name", Order: ""},
+					},
+					Page: Pagination{Limit: 2, Token: ""},
+				},
+				Filter: &AND{
+					Filters: []Filter{
+						&LIKE{Key: "person.name", Pattern: "J%"},
+						&EXISTS{Key: "person.org"},
+						&NOT{Filter: &EQ{Key: "state", Val: "CA"}},
+						&CONTAINS{Key: "tags", Val: "blue"},
+					},
+				},
+			},
+		},
+		{
+			input: "../../tests/state/query/q10-projection.json",
+			query: Query{
+				QueryFields: QueryFields{
+					Filters: map[string]any{
+						"EQ": map[string]any{
+							"state": "CA",
+						},
+					},
+					Sort: []Sorting{
+						{Key: "person.name", Order: ""},
+					},
+					Page:       Pagination{Limit: 2, Token: ""},
+					Projection: []string{"person.name", "person.org", "state"},
+				},
+				Filter: &EQ{Key: "state", Val: "CA"},
+			},
+		},
+		{
+			input: "../../tests/state/query/q11-aggregation.json",
+			query: Query{
+				QueryFields: QueryFields{
+					Filters: map[string]any{
+						"IN": map[string]any{
+							"state": []any{"CA", "WA"},
+						},
+					},
+					Sort: []Sorting{
+						{Key: "state", Order: "DESC"},
+					},
+					Aggregations: []Aggregation{
+						{Op: COUNT, Alias: "total"},
+						{Op: SUM, Key: "person.id", Alias: "sum_id"},
+						{Op: AVG, Key: "person.id", Alias: "avg_id"},
+						{Op: MIN, Key: "person.id", Alias: "min_id"},
+						{Op: MAX, Key: "person.id", Alias: "max_id"},
+					},
+					GroupBy: []string{"state", "person.org"},
+				},
+				Filter: &IN{Key: "state", Vals: []any{"CA", "WA"}},
+			},
+		},
 	}
 	for _, test := range tests {
 		data, err := os.ReadFile(test.input)
@@ -141,3 +226,82 @@ func TestQuery(t *testing.T) {
 		assert.Equal(t, test.query, q)
 	}
 }
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, input := range []string{
+		`{"LIKE": {"person.name": 1}}`,
+		`{"LIKE": "person.name"}`,
+		`{"LIKE": {"person.name": "abc\\"}}`,
+		`{"EXISTS": {"person.name": true}}`,
+		`{"EXISTS": ""}`,
+		`{"NOT": [{"EQ": {"state": "CA"}}]}`,
+		`{"CONTAINS": {"tags": "blue", "colors": "red"}}`,
+	} {
+		var obj any
+		require.NoError(t, json.Unmarshal([]byte(input), &obj))
+		_, err := ParseFilter(obj)
+		require.Error(t, err, input)
+	}
+}
+
+func TestLIKERegexp(t *testing.T) {
+	tests := map[string]string{
+		"abc":     "^abc$",
+		"abc%":    "^abc.*$",
+		"%a.c%":   `^.*a\.c.*$`,
+		"a_c":     "^a.c$",
+		"%":       "^.*$",
+		"(a)%_b%": `^\(a\).*.b.*$`,
+		`100\%`:   `^100%$`,
+		`a\_b\\c`: `^a_b\\c$`,
+		`\a%`:     `^a.*$`,
+	}
+	for pattern, expect := range tests {
+		f := &LIKE{Key: "k", Pattern: pattern}
+		assert.Equal(t, expect, f.Regexp(), pattern)
+	}
+}
+
+func TestAggregationErrors(t *testing.T) {
+	for _, input := range []string{
+		`{"projection": ["state"], "aggregations": [{"op": "COUNT", "alias": "total"}]}`,
+		`{"projection": ["person", "person.name"]}`,
+		`{"projection": ["state", "state"]}`,
+		`{"projection": [""]}`,
+		`{"projection": ["person.na'me"]}`,
+		`{"projection": ["person..name"]}`,
+		`{"aggregations": [{"op": "SUM", "key": "n')||(SELECT 1", "alias": "total"}]}`,
+		`{"aggregations": [{"op": "COUNT", "alias": "total"}], "groupBy": ["sta'te"]}`,
+		`{"groupBy": ["state"]}`,
+		`{"aggregations": [{"op": "MEDIAN", "key": "person.id", "alias": "m"}]}`,
+		`{"aggregations": [{"op": "SUM", "alias": "total"}]}`,
+		`{"aggregations": [{"op": "COUNT"}]}`,
+		`{"aggregations": [{"op": "COUNT", "alias": "to'tal"}]}`,
+		`{"aggregations": [{"op": "COUNT", "alias": "total"}, {"op": "MAX", "key": "n", "alias": "total"}]}`,
+		`{"aggregations": [{"op": "COUNT", "alias": "total"}], "groupBy": ["state"], "sort": [{"key": "person.name"}]}`,
+	} {
+		var q Query
+		err := json.Unmarshal([]byte(input), &q)
+		require.Error(t, err, input)
+	}
+}
+
+type filterVisitor struct {
+	Visitor
+}
+
+func (filterVisitor) Finalize(string, *Query) error {
+	return nil
+}
+
+func TestBuildQueryAggregationsNotSupported(t *testing.T) {
+	var q Query
+	err := json.Unmarshal([]byte(`{"projection": ["state"]}`), &q)
+	require.NoError(t, err)
+
+	err = NewQueryBuilder(filterVisitor{}).BuildQuery(&q)
+	require.ErrorIs(t, err, ErrAggregationsNotSupported)
+
+	err = NewQueryBuilder(filterVisitor{}).BuildQuery(&Query{})
+	require.NoError(t, err)
+}
@@ -160,11 +160,15 @@ func (r *StateStore) Init(ctx context.Context, metadata state.Metadata) error {
 
 // Features returns the features available in this state store.
 func (r *StateStore) Features() []state.Feature {
+	features := []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureTTL}
 	if r.clientHasJSON {
-		return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureTTL, state.FeatureQueryAPI}
-	} else {
-		return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureTTL}
+		features = append(features, state.FeatureQueryAPI)
+	}
+	// Keyspace notifications and SCAN are local to each node of a cluster
+	if r.clientSettings == nil || r.clientSettings.RedisType != rediscomponent.ClusterType {
+		features = append(features, state.FeatureWatch, state.FeatureKeyListing)
 	}
+	return features
 }
 
 func (r *StateStore) getConnectedSlaves(ctx context.Context) (int, error) {
@@ -544,6 +548,47 @@ func (r *StateStore) Query(ctx context.Context, req *state.QueryRequest) (*state
 	}, nil
 }
 
+// ListKeys returns the keys in the store using SCAN, so keys are not sorted and the page size is a hint only.
+// Keys that are modified while listing may be returned more than once.
+// The continuation token is the cursor returned by SCAN.
+func (r *StateStore) ListKeys(ctx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if r.clientSettings.RedisType == rediscomponent.ClusterType {
+		return nil, errors.New("redis store: listing keys is not supported in cluster mode")
+	}
+
+	cursor := req.ContinuationToken
+	if cursor == "" {
+		cursor = "0"
+	}
+	res, err := r.client.DoRead(ctx, "SCAN", cursor, "MATCH", escapeGlob(req.Prefix)+"*", "COUNT", req.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("redis store: error listing keys: %w", err)
+	}
+
+	vals, ok := res.([]any)
+	if !ok || len(vals) != 2 {
+		return nil, fmt.Errorf("redis store: invalid response to SCAN: %v", res)
+	}
+	cursor, _ = vals[0].(string)
+	keys, _ := vals[1].([]any)
+	resp := &state.ListKeysResponse{
+		Keys: make([]string, 0, len(keys)),
+	}
+	for _, k := range keys {
+		if key, ok := k.(string); ok {
+			resp.Keys = append(resp.Keys, key)
+		}
+	}
+	if cursor != "0" {
+		resp.ContinuationToken = cursor
+	}
+	return resp, nil
+}
+
 func (r *StateStore) Close() error {
 	return r.client.Close()
 }
@@ -553,3 +598,16 @@ func (r *StateStore) GetComponentMetadata() (metadataInfo daprmetadata.MetadataM
 	daprmetadata.GetMetadataInfoFromStructType(reflect.TypeOf(settingsStruct), &metadataInfo, daprmetadata.StateStoreType)
 	return
 }
+
+// escapeGlob escapes the characters with special meaning in patterns of SCAN and PSUBSCRIBE.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
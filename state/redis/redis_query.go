@@ -25,7 +25,10 @@ import (
 	"github.com/dapr/components-contrib/state/query"
 )
 
-var ErrMultipleSortBy error = errors.New("multiple SORTBY steps are not allowed. Sort multiple fields in a single step")
+var (
+	ErrMultipleSortBy     error = errors.New("multiple SORTBY steps are not allowed. Sort multiple fields in a single step")
+	ErrExistsNotSupported error = errors.New("EXISTS operator is not supported by Redis")
+)
 
 type Query struct {
 	schemaName string
@@ -243,6 +246,26 @@ func (q *Query) visitFilters(op string, filters []query.Filter) (string, error)
 				return "", err
 			}
 			arr = append(arr, str)
+		case *query.NOT:
+			if str, err = q.VisitNOT(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, str)
+		case *query.LIKE:
+			if str, err = q.VisitLIKE(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, fmt.Sprintf("(%s)", str))
+		case *query.EXISTS:
+			if str, err = q.VisitEXISTS(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, fmt.Sprintf("(%s)", str))
+		case *query.CONTAINS:
+			if str, err = q.VisitCONTAINS(f); err != nil {
+				return "", err
+			}
+			arr = append(arr, fmt.Sprintf("(%s)", str))
 		default:
 			return "", fmt.Errorf("unsupported filter type %#v", f)
 		}
@@ -261,6 +284,67 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 	return q.visitFilters("|", f.Filters)
 }
 
+func (q *Query) VisitNOT(f *query.NOT) (string, error) {
+	// -( <expression> )
+	str, err := q.visitFilters(" ", []query.Filter{f.Filter})
+	if err != nil {
+		return "", err
+	}
+
+	return "-" + str, nil
+}
+
+func (q *Query) VisitLIKE(f *query.LIKE) (string, error) {
+	// prefix: @<key>:(<val>*)
+	// suffix: @<key>:(*<val>)
+	// infix:  @<key>:(*<val>*)
+	// Text fields are matched with the full-text search of RediSearch, which is case-insensitive
+	alias, err := q.getAlias(f.Key)
+	if err != nil {
+		return "", err
+	}
+
+	pattern, prefix := strings.CutPrefix(f.Pattern, "%")
+	val, suffix := strings.CutSuffix(pattern, "%")
+	if suffix && (len(val)-len(strings.TrimRight(val, `\`)))%2 != 0 {
+		// The last "%" is escaped
+		val, suffix = pattern, false
+	}
+	wildcards := false
+	val = (&query.LIKE{Pattern: val}).Translate(escapeQueryText, func(rune) string {
+		wildcards = true
+		return ""
+	})
+	if val == "" || wildcards {
+		return "", fmt.Errorf("unsupported LIKE pattern %q; only prefix, suffix and infix patterns are permitted", f.Pattern)
+	}
+	if prefix {
+		val = "*" + val
+	}
+	if suffix {
+		val += "*"
+	}
+
+	return fmt.Sprintf("@%s:(%s)", alias, val), nil
+}
+
+func (q *Query) VisitEXISTS(f *query.EXISTS) (string, error) {
+	return "", ErrExistsNotSupported
+}
+
+func (q *Query) VisitCONTAINS(f *query.CONTAINS) (string, error) {
+	// Arrays are indexed with a JSON path that matches their elements, such as "tags[*]"
+	key := f.Key + "[*]"
+	if _, ok := q.aliases[key]; !ok {
+		key = f.Key
+	}
+
+	return q.VisitEQ(&query.EQ{
+		Key: key,
+		Val: f.Val,
+	})
+}
+
 func (q *Query) Finalize(filters string, qq *query.Query) error {
 	if len(filters) == 0 {
 		filters = "*"
@@ -400,3 +484,15 @@ func parseQueryResponsePre28(ret any) ([]state.QueryItem, error) {
 
 	return res, nil
 }
+
+// escapeQueryText escapes the characters with special meaning in RediSearch queries, which would otherwise separate the words of the text.
+func escapeQueryText(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(",.<>{}[]\"':;!@#$%^&*()-+=~|/\\ ", c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
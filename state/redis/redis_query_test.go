@@ -54,6 +54,10 @@ func TestRedisQuery(t *testing.T) {
 			input: "../../tests/state/query/q7.json",
 			query: []interface{}{"((@id:[-inf (123.000000])|((@org:[2.000000 +inf]) (((@id:[567.000000 567.000000])|(@id:[890.000000 890.000000])))))", "SORTBY", "id", "LIMIT", "0", "2"},
 		},
+		{
+			input: "../../tests/state/query/q9.json",
+			err:   ErrExistsNotSupported,
+		},
 	}
 	for _, test := range tests {
 		data, err := os.ReadFile(test.input)
@@ -63,7 +67,7 @@ func TestRedisQuery(t *testing.T) {
 		require.NoError(t, err)
 
 		q := &Query{
-			aliases: map[string]string{"person.org": "org", "person.id": "id", "state": "state"},
+			aliases: map[string]string{"person.org": "org", "person.id": "id", "person.name": "name", "state": "state", "tags[*]": "tags"},
 		}
 		qbuilder := query.NewQueryBuilder(q)
 		if err = qbuilder.BuildQuery(&qq); err != nil {
@@ -73,3 +77,64 @@ func TestRedisQuery(t *testing.T) {
 		}
 	}
 }
+
+func TestRedisQueryOperators(t *testing.T) {
+	tests := []struct {
+		filter string
+		query  string
+		err    bool
+	}{
+		{
+			filter: `{"LIKE": {"person.name": "Jo%"}}`,
+			query:  "@name:(Jo*)",
+		},
+		{
+			filter: `{"LIKE": {"person.name": "%hn"}}`,
+			query:  "@name:(*hn)",
+		},
+		{
+			filter: `{"LIKE": {"person.name": "%oh%"}}`,
+			query:  "@name:(*oh*)",
+		},
+		{
+			filter: `{"LIKE": {"person.name": "J_hn"}}`,
+			err:    true,
+		},
+		{
+			filter: `{"LIKE": {"person.name": "J\\_hn"}}`,
+			query:  "@name:(J_hn)",
+		},
+		{
+			filter: `{"LIKE": {"person.name": "Jo-hn%"}}`,
+			query:  `@name:(Jo\-hn*)`,
+		},
+		{
+			filter: `{"LIKE": {"person.name": "%50\\%"}}`,
+			query:  `@name:(*50\%)`,
+		},
+		{
+			filter: `{"NOT": {"EQ": {"state": "CA"}}}`,
+			query:  "-((@state:(CA)))",
+		},
+		{
+			filter: `{"AND": [{"CONTAINS": {"tags": "blue"}}, {"CONTAINS": {"person.id": 1}}]}`,
+			query:  "((@tags:(blue)) (@id:[1.000000 1.000000]))",
+		},
+	}
+	for _, test := range tests {
+		var qq query.Query
+		err := json.Unmarshal([]byte(`{"filter":`+test.filter+`}`), &qq)
+		require.NoError(t, err)
+
+		q := &Query{
+			aliases: map[string]string{"person.id": "id", "person.name": "name", "state": "state", "tags[*]": "tags"},
+		}
+		err = query.NewQueryBuilder(q).BuildQuery(&qq)
+		if test.err {
+			require.Error(t, err, test.filter)
+			continue
+		}
+		require.NoError(t, err, test.filter)
+		assert.Equal(t, []interface{}{test.query}, q.query)
+	}
+}
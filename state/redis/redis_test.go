@@ -510,6 +510,50 @@ func TestGetMetadata(t *testing.T) {
 	assert.Contains(t, metadataInfo, "idleCheckFrequency")
 }
 
+func TestListKeys(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client:         c,
+		clientSettings: &rediscomponent.Settings{},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+	require.True(t, state.FeatureKeyListing.IsPresent(ss.Features()))
+
+	for _, key := range []string{"app||a", "app||b", "app||c", "app*||d", "other||a"} {
+		require.NoError(t, ss.Set(context.Background(), &state.SetRequest{Key: key, Value: "value"}))
+	}
+
+	t.Run("all pages", func(t *testing.T) {
+		keys := []string{}
+		req := &state.ListKeysRequest{Prefix: "app||", PageSize: 2}
+		for {
+			res, err := ss.ListKeys(context.Background(), req)
+			require.NoError(t, err)
+			keys = append(keys, res.Keys...)
+			if res.ContinuationToken == "" {
+				break
+			}
+			req.ContinuationToken = res.ContinuationToken
+		}
+		assert.ElementsMatch(t, []string{"app||a", "app||b", "app||c"}, keys)
+	})
+
+	t.Run("prefix with special characters", func(t *testing.T) {
+		res, err := ss.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: "app*"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app*||d"}, res.Keys)
+		assert.Empty(t, res.ContinuationToken)
+	})
+}
+
+func TestEscapeGlob(t *testing.T) {
+	assert.Equal(t, "app||key", escapeGlob("app||key"))
+	assert.Equal(t, `a\*b\?c\[d\]e\\`, escapeGlob(`a*b?c[d]e\`))
+}
+
 func setupMiniredis() (*miniredis.Miniredis, rediscomponent.RedisClient) {
 	s, err := miniredis.Run()
 	if err != nil {
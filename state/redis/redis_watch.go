@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	rediscomponent "github.com/dapr/components-contrib/common/component/redis"
+	"github.com/dapr/components-contrib/state"
+)
+
+// Keyspace notifications required by Watch: keyspace channel (K), generic (g) and hash (h) commands, expired (x) and evicted (e) keys.
+// Module key type events (d) are required for keys stored with RedisJSON.
+const (
+	watchKeyspaceEvents     = "Kghxe"
+	watchJSONKeyspaceEvents = "d"
+)
+
+// Watch invokes handler for each change to the keys that match the request, until ctx is canceled.
+// Changes are received from the keyspace notifications of Redis, which must be enabled in the server, or by the state store if allowed by the metadata.
+// The ETag of set events is read after the notification is received, so it may belong to a later change of the key.
+func (r *StateStore) Watch(ctx context.Context, req *state.WatchRequest, handler state.WatchHandler) error {
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+	if r.clientSettings.RedisType == rediscomponent.ClusterType {
+		// Keyspace notifications are local to each node of the cluster
+		return errors.New("redis store: watch is not supported in cluster mode")
+	}
+
+	err = r.checkKeyspaceNotifications(ctx)
+	if err != nil {
+		return err
+	}
+
+	channelPrefix := "__keyspace@" + strconv.Itoa(r.clientSettings.DB) + "__:"
+	pattern := channelPrefix + escapeGlob(req.Key)
+	if req.Prefix {
+		pattern += "*"
+	}
+
+	var lastSet *state.WatchEvent
+	err = r.client.PSubscribe(ctx, pattern, func(channel string, payload string) {
+		event := r.toWatchEvent(ctx, strings.TrimPrefix(channel, channelPrefix), payload)
+		if event == nil {
+			return
+		}
+
+		// Writes can generate multiple notifications with the same ETag, which are delivered once only
+		if event.Type == state.WatchEventSet && lastSet != nil && lastSet.Key == event.Key && *lastSet.ETag == *event.ETag {
+			return
+		}
+		lastSet = nil
+		if event.Type == state.WatchEventSet {
+			lastSet = event
+		}
+
+		err := handler(ctx, event)
+		if err != nil {
+			r.logger.Errorf("Error from the handler of watch for key '%s': %v", req.Key, err)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("redis store: error subscribing to keyspace notifications: %w", err)
+	}
+
+	return nil
+}
+
+// toWatchEvent returns the watch event for a keyspace notification, or nil if the notification must be ignored.
+func (r *StateStore) toWatchEvent(ctx context.Context, key string, notification string) *state.WatchEvent {
+	switch notification {
+	case "del", "expired", "evicted", "json.del":
+		return &state.WatchEvent{
+			Type: state.WatchEventDelete,
+			Key:  key,
+		}
+	case "hincrby", "json.set":
+		// The version is incremented as the last step of every write
+		etag, err := r.getVersion(ctx, key, notification == "json.set")
+		if err != nil {
+			r.logger.Warnf("Failed to retrieve the ETag of watched key '%s': %v", key, err)
+			return nil
+		}
+		if etag == nil {
+			// The key was deleted already, or it is being written and doesn't have a version yet
+			return nil
+		}
+		return &state.WatchEvent{
+			Type: state.WatchEventSet,
+			Key:  key,
+			ETag: etag,
+		}
+	default:
+		return nil
+	}
+}
+
+func (r *StateStore) getVersion(ctx context.Context, key string, isJSON bool) (*string, error) {
+	var (
+		res any
+		err error
+	)
+	if isJSON {
+		res, err = r.client.DoRead(ctx, "JSON.GET", key, ".version")
+	} else {
+		res, err = r.client.DoRead(ctx, "HGET", key, "version")
+	}
+	if err != nil {
+		if err.Error() == string(r.client.GetNilValueError()) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	switch v := res.(type) {
+	case string:
+		return &v, nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected type for version: %T", res)
+	}
+}
+
+// checkKeyspaceNotifications returns an error if the keyspace notifications required by Watch are not enabled in the server.
+// If allowed by the metadata, the missing notifications are added to the ones enabled in the server instead.
+// Managed services may not allow reading the configuration, but may have notifications enabled already, so that error is logged only.
+func (r *StateStore) checkKeyspaceNotifications(ctx context.Context) error {
+	res, err := r.client.DoRead(ctx, "CONFIG", "GET", "notify-keyspace-events")
+	if err != nil {
+		r.logger.Warnf("Failed to read the configuration of keyspace notifications, watch may not receive events: %v", err)
+		return nil
+	}
+	current := parseConfigGetValue(res)
+
+	missing := missingKeyspaceEvents(current, watchKeyspaceEvents)
+	missingJSON := ""
+	if r.clientHasJSON {
+		missingJSON = missingKeyspaceEvents(current+missing, watchJSONKeyspaceEvents)
+	}
+	if missing == "" && missingJSON == "" {
+		return nil
+	}
+
+	if !r.clientSettings.EnableKeyspaceNotifications {
+		if missing != "" {
+			return fmt.Errorf("redis store: watch requires keyspace notifications '%s' to be enabled in the server (notify-keyspace-events is '%s'), or enableKeyspaceNotifications to be set in the metadata", missing, current)
+		}
+		r.logger.Warnf("Keyspace notifications '%s' are not enabled in the server, watch won't receive events for keys stored as JSON", missingJSON)
+		return nil
+	}
+
+	err = r.client.DoWrite(ctx, "CONFIG", "SET", "notify-keyspace-events", current+missing+missingJSON)
+	if err != nil && missingJSON != "" {
+		// Older versions of Redis don't support module key type events
+		err = r.client.DoWrite(ctx, "CONFIG", "SET", "notify-keyspace-events", current+missing)
+	}
+	if err != nil {
+		return fmt.Errorf("redis store: error enabling keyspace notifications: %w", err)
+	}
+	return nil
+}
+
+// parseConfigGetValue returns the value from the response to a CONFIG GET command for a single parameter.
+// The response is an array with the name and value with RESP2, and a map with RESP3.
+func parseConfigGetValue(res any) string {
+	switch v := res.(type) {
+	case []any:
+		if len(v) == 2 {
+			val, _ := v[1].(string)
+			return val
+		}
+	case map[any]any:
+		for _, val := range v {
+			s, _ := val.(string)
+			return s
+		}
+	case map[string]any:
+		for _, val := range v {
+			s, _ := val.(string)
+			return s
+		}
+	}
+	return ""
+}
+
+// missingKeyspaceEvents returns the classes of keyspace events in required that are not enabled in current.
+func missingKeyspaceEvents(current string, required string) string {
+	var missing strings.Builder
+	for _, c := range required {
+		if strings.ContainsRune(current, c) {
+			continue
+		}
+		// "A" is an alias for all classes of events, but not for the channels
+		if c != 'K' && c != 'E' && strings.ContainsRune(current, 'A') {
+			continue
+		}
+		missing.WriteRune(c)
+	}
+	return missing.String()
+}
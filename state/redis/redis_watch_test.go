@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2/server"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rediscomponent "github.com/dapr/components-contrib/common/component/redis"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func TestWatch(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client:         c,
+		clientSettings: &rediscomponent.Settings{},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+
+	var _ state.Watcher = ss
+	require.True(t, state.FeatureWatch.IsPresent(ss.Features()))
+
+	// miniredis doesn't send keyspace notifications, so they are published by the test after each write
+	notify := func(key string, notification string) {
+		s.Publish("__keyspace@0__:"+key, notification)
+	}
+	set := func(key string, value string) {
+		require.NoError(t, ss.Set(context.Background(), &state.SetRequest{Key: key, Value: value}))
+		notify(key, "hset")
+		notify(key, "hincrby")
+	}
+	watch := func(t *testing.T, req *state.WatchRequest) <-chan *state.WatchEvent {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		events := make(chan *state.WatchEvent, 10)
+		err := ss.Watch(ctx, req, func(ctx context.Context, event *state.WatchEvent) error {
+			events <- event
+			return nil
+		})
+		require.NoError(t, err)
+		return events
+	}
+	receive := func(t *testing.T, events <-chan *state.WatchEvent) *state.WatchEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("did not receive event in time")
+			return nil
+		}
+	}
+
+	t.Run("set and delete key", func(t *testing.T) {
+		events := watch(t, &state.WatchRequest{Key: "watched"})
+
+		set("watched", "hello")
+		set("watched-other", "hello")
+		event := receive(t, events)
+		assert.Equal(t, state.WatchEventSet, event.Type)
+		assert.Equal(t, "watched", event.Key)
+		res, err := ss.Get(context.Background(), &state.GetRequest{Key: "watched"})
+		require.NoError(t, err)
+		require.NotNil(t, event.ETag)
+		assert.Equal(t, *res.ETag, *event.ETag)
+
+		// Duplicate notifications for the same version are ignored
+		notify("watched", "hincrby")
+		require.NoError(t, ss.Delete(context.Background(), &state.DeleteRequest{Key: "watched"}))
+		notify("watched", "del")
+		event = receive(t, events)
+		assert.Equal(t, state.WatchEventDelete, event.Type)
+		assert.Equal(t, "watched", event.Key)
+		assert.Nil(t, event.ETag)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		events := watch(t, &state.WatchRequest{Key: "app||", Prefix: true})
+
+		set("app||a", "1")
+		set("other||a", "1")
+		notify("app||b", "expired")
+		event := receive(t, events)
+		assert.Equal(t, state.WatchEventSet, event.Type)
+		assert.Equal(t, "app||a", event.Key)
+		event = receive(t, events)
+		assert.Equal(t, state.WatchEventDelete, event.Type)
+		assert.Equal(t, "app||b", event.Key)
+	})
+
+	t.Run("cluster is not supported", func(t *testing.T) {
+		cluster := &StateStore{
+			client:         c,
+			clientSettings: &rediscomponent.Settings{RedisType: rediscomponent.ClusterType},
+			logger:         logger.NewLogger("test"),
+		}
+		assert.False(t, state.FeatureWatch.IsPresent(cluster.Features()))
+		err := cluster.Watch(context.Background(), &state.WatchRequest{Key: "watched"}, func(ctx context.Context, event *state.WatchEvent) error {
+			return nil
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestMissingKeyspaceEvents(t *testing.T) {
+	assert.Equal(t, "Kghxe", missingKeyspaceEvents("", watchKeyspaceEvents))
+	assert.Equal(t, "Khxe", missingKeyspaceEvents("Eg$", watchKeyspaceEvents))
+	assert.Equal(t, "K", missingKeyspaceEvents("AE", watchKeyspaceEvents))
+	assert.Equal(t, "", missingKeyspaceEvents("KA", watchKeyspaceEvents))
+	assert.Equal(t, "d", missingKeyspaceEvents("Kghxe", watchKeyspaceEvents+watchJSONKeyspaceEvents))
+}
+
+func TestCheckKeyspaceNotifications(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	// miniredis doesn't support CONFIG, so it's implemented by the test
+	var (
+		lock   sync.Mutex
+		config string
+		sets   int
+	)
+	s.Server().SetPreHook(func(peer *server.Peer, cmd string, args ...string) bool {
+		if !strings.EqualFold(cmd, "CONFIG") || len(args) < 2 || args[1] != "notify-keyspace-events" {
+			return false
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		switch strings.ToUpper(args[0]) {
+		case "GET":
+			peer.WriteStrings([]string{args[1], config})
+		case "SET":
+			config = args[2]
+			sets++
+			peer.WriteOK()
+		default:
+			return false
+		}
+		return true
+	})
+	newStore := func(enable bool) *StateStore {
+		return &StateStore{
+			client:         c,
+			clientSettings: &rediscomponent.Settings{EnableKeyspaceNotifications: enable},
+			logger:         logger.NewLogger("test"),
+		}
+	}
+
+	t.Run("missing notifications", func(t *testing.T) {
+		config = "Eg"
+		err := newStore(false).checkKeyspaceNotifications(context.Background())
+		require.Error(t, err)
+		assert.Equal(t, "Eg", config)
+		assert.Equal(t, 0, sets)
+	})
+
+	t.Run("notifications are enabled if allowed", func(t *testing.T) {
+		ss := newStore(true)
+		require.NoError(t, ss.checkKeyspaceNotifications(context.Background()))
+		assert.Equal(t, "EgKhxe", config)
+		assert.Equal(t, 1, sets)
+
+		// The configuration is only changed if needed
+		require.NoError(t, ss.checkKeyspaceNotifications(context.Background()))
+		require.NoError(t, newStore(false).checkKeyspaceNotifications(context.Background()))
+		assert.Equal(t, 1, sets)
+	})
+}
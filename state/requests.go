@@ -85,6 +85,53 @@ func (r *DeleteWithPrefixRequest) Validate() error {
 	return nil
 }
 
+// DefaultListKeysPageSize is the number of keys returned by list keys requests that don't set a page size.
+const DefaultListKeysPageSize = 100
+
+// ListKeysRequest is the object describing a request to list keys, one page at a time.
+type ListKeysRequest struct {
+	// If set, only keys that start with the prefix are returned
+	Prefix string `json:"prefix,omitempty"`
+	// Maximum number of keys to return in the page
+	PageSize int `json:"pageSize,omitempty"`
+	// Token returned with the previous page, to continue listing from there
+	ContinuationToken string            `json:"continuationToken,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+}
+
+func (r *ListKeysRequest) Validate() error {
+	if r.PageSize < 0 {
+		return errors.New("page size for listKeys request must not be negative")
+	}
+	if r.PageSize == 0 {
+		r.PageSize = DefaultListKeysPageSize
+	}
+	return nil
+}
+
+// WatchRequest is the object describing a request to watch for changes to a key, or to all keys with a prefix.
+type WatchRequest struct {
+	Key string `json:"key"`
+	// If true, changes to all keys that start with Key are reported
+	Prefix   bool              `json:"prefix,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func (r WatchRequest) Validate() error {
+	if r.Key == "" && !r.Prefix {
+		return errors.New("a key is required for watch request")
+	}
+	return nil
+}
+
+// Matches returns true if changes to key are reported for the request.
+func (r WatchRequest) Matches(key string) bool {
+	if r.Prefix {
+		return strings.HasPrefix(key, r.Key)
+	}
+	return key == r.Key
+}
+
 // DeleteStateOption controls how a state store reacts to a delete request.
 type DeleteStateOption struct {
 	Concurrency string `json:"concurrency,omitempty"` // "concurrency"
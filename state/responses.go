@@ -39,9 +39,10 @@ type BulkGetResponse struct {
 
 // QueryResponse is the response object for querying state.
 type QueryResponse struct {
-	Results  []QueryItem       `json:"results"`
-	Token    string            `json:"token,omitempty"`
-	Metadata map[string]string `json:"metadata,omitempty"`
+	Results    []QueryItem       `json:"results"`
+	Aggregates []QueryAggregate  `json:"aggregates,omitempty"`
+	Token      string            `json:"token,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
 }
 
 // QueryItem is an object representing a single entry in query results.
@@ -53,7 +54,41 @@ type QueryItem struct {
 	ContentType *string `json:"contentType,omitempty"`
 }
 
+// QueryAggregate is a row of results of a query with aggregations.
+// When the query has a group-by clause, there's a row for each group.
+type QueryAggregate struct {
+	// Values of the fields the row is grouped by, keyed by field name.
+	Group map[string]any `json:"group,omitempty"`
+	// Values of the aggregations, keyed by alias.
+	Values map[string]any `json:"values"`
+}
+
 // DeleteWithPrefixResponse is the object representing a delete with prefix state response containing the number of items removed.
 type DeleteWithPrefixResponse struct {
 	Count int64 `json:"count"` // count of items removed
 }
+
+// ListKeysResponse is the object representing a page of keys.
+type ListKeysResponse struct {
+	Keys []string `json:"keys"`
+	// Token to retrieve the next page, which is empty after the last page
+	ContinuationToken string `json:"continuationToken,omitempty"`
+}
+
+// WatchEventType is the type of a change reported to watchers.
+type WatchEventType string
+
+const (
+	// WatchEventSet is reported when a key is created or updated.
+	WatchEventSet WatchEventType = "set"
+	// WatchEventDelete is reported when a key is deleted, including when it expires.
+	WatchEventDelete WatchEventType = "delete"
+)
+
+// WatchEvent is the object describing a change to a watched key.
+type WatchEvent struct {
+	Type WatchEventType `json:"type"`
+	Key  string         `json:"key"`
+	// New ETag of the item, for set events
+	ETag *string `json:"etag,omitempty"`
+}
@@ -49,6 +49,8 @@ func newSQLiteStateStore(logger logger.Logger, dba DBAccess) *SQLiteStore {
 			state.FeatureETag,
 			state.FeatureTransactional,
 			state.FeatureTTL,
+			state.FeatureQueryAPI,
+			state.FeatureKeyListing,
 		},
 		dbaccess: dba,
 	}
@@ -100,6 +102,17 @@ func (s *SQLiteStore) Multi(ctx context.Context, request *state.TransactionalSta
 	return s.dbaccess.ExecuteMulti(ctx, request.Operations)
 }
 
+// Query executes a query against the store.
+func (s *SQLiteStore) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	return s.dbaccess.Query(ctx, req)
+}
+
+// ListKeys returns the keys in the store in lexicographical order.
+// The continuation token is the last key of the previous page.
+func (s *SQLiteStore) ListKeys(ctx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	return s.dbaccess.ListKeys(ctx, req)
+}
+
 // Close implements io.Closer.
 func (s *SQLiteStore) Close() error {
 	if s.dbaccess != nil {
@@ -33,6 +33,7 @@ import (
 	commonsql "github.com/dapr/components-contrib/common/component/sql"
 	sqltransactions "github.com/dapr/components-contrib/common/component/sql/transactions"
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
 	stateutils "github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
 )
@@ -46,6 +47,8 @@ type DBAccess interface {
 	Delete(ctx context.Context, req *state.DeleteRequest) error
 	BulkGet(ctx context.Context, req []state.GetRequest) ([]state.BulkGetResponse, error)
 	ExecuteMulti(ctx context.Context, reqs []state.TransactionalStateOperation) error
+	Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error)
+	ListKeys(ctx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error)
 	Close() error
 }
 
@@ -451,6 +454,66 @@ func (a *sqliteDBAccess) execMultiOperation(parentCtx context.Context, op state.
 	}
 }
 
+func (a *sqliteDBAccess) Query(parentCtx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	q := &Query{
+		tableName: a.metadata.TableName,
+	}
+	qbuilder := query.NewQueryBuilder(q)
+	if err := qbuilder.BuildQuery(&req.Query); err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, a.metadata.Timeout)
+	defer cancel()
+	data, token, err := q.execute(ctx, a.db)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	return &state.QueryResponse{
+		Results: data,
+		Token:   token,
+	}, nil
+}
+
+func (a *sqliteDBAccess) ListKeys(parentCtx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys with the prefix are selected as a range rather than with LIKE, which is case-insensitive and can't use the index with an escape character
+	args := []any{req.Prefix, req.ContinuationToken}
+	//nolint:gosec
+	stmt := `SELECT key FROM ` + a.metadata.TableName + `
+		WHERE
+			key >= ?
+			AND key > ?
+			AND (expiration_time IS NULL OR expiration_time > CURRENT_TIMESTAMP)`
+	if end := commonsql.PrefixRangeEnd(req.Prefix); end != "" {
+		stmt += ` AND key < ?`
+		args = append(args, end)
+	}
+	stmt += ` ORDER BY key LIMIT ?`
+	args = append(args, req.PageSize+1)
+
+	ctx, cancel := context.WithTimeout(parentCtx, a.metadata.Timeout)
+	defer cancel()
+	rows, err := a.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := &state.ListKeysResponse{}
+	res.Keys, res.ContinuationToken, err = stateutils.ReadKeysPage(rows, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // Close implements io.Closer.
 func (a *sqliteDBAccess) Close() (err error) {
 	errs := make([]error, 0)
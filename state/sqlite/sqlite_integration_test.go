@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -144,6 +145,14 @@ func TestSqliteIntegration(t *testing.T) {
 		getBulkExpireTime(t, s)
 	})
 
+	t.Run("Query", func(t *testing.T) {
+		queryItems(t, s)
+	})
+
+	t.Run("List keys", func(t *testing.T) {
+		listKeys(t, s)
+	})
+
 	t.Run("Binary data", func(t *testing.T) {
 		key := randomKey()
 
@@ -163,6 +172,98 @@ func TestSqliteIntegration(t *testing.T) {
 	})
 }
 
+// listKeys validates filtering by prefix and pagination with ListKeys.
+func listKeys(t *testing.T, s state.Store) {
+	lister, ok := s.(state.ListKeys)
+	require.True(t, ok, "ListKeys interface is not implemented")
+	require.True(t, state.FeatureKeyListing.IsPresent(s.Features()))
+
+	prefix := randomKey() + "||"
+	for _, key := range []string{prefix + "c", prefix + "a", prefix + "b", strings.ToUpper(prefix) + "d"} {
+		setItem(t, s, key, "value", nil)
+	}
+	// Expired items are not listed
+	err := s.Set(context.Background(), &state.SetRequest{
+		Key:      prefix + "expired",
+		Value:    "value",
+		Metadata: map[string]string{"ttlInSeconds": "1"},
+	})
+	require.NoError(t, err)
+	time.Sleep(1100 * time.Millisecond)
+
+	res, err := lister.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: prefix, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{prefix + "a", prefix + "b"}, res.Keys)
+	require.NotEmpty(t, res.ContinuationToken)
+
+	res, err = lister.ListKeys(context.Background(), &state.ListKeysRequest{Prefix: prefix, PageSize: 2, ContinuationToken: res.ContinuationToken})
+	require.NoError(t, err)
+	assert.Equal(t, []string{prefix + "c"}, res.Keys)
+	assert.Empty(t, res.ContinuationToken)
+}
+
+// queryItems validates filtering, sorting and pagination with the Query API.
+func queryItems(t *testing.T, s state.Store) {
+	querier, ok := s.(state.Querier)
+	require.True(t, ok, "Querier interface is not implemented")
+
+	// Add a few items that share the same color, plus a binary value which must be ignored by queries
+	color := randomKey()
+	keys := []string{"q-" + randomKey(), "q-" + randomKey(), "q-" + randomKey()}
+	for i, key := range keys {
+		item := map[string]any{"color": color, "n": i, "name": fmt.Sprintf("item-%d", i)}
+		if i > 0 {
+			item["tags"] = []string{fmt.Sprintf("tag-%d", i), "shared"}
+		}
+		setItem(t, s, key, item, nil)
+	}
+	setItem(t, s, randomKey(), []byte("not json"), nil)
+
+	doQuery := func(q string) *state.QueryResponse {
+		req := &state.QueryRequest{}
+		err := json.Unmarshal([]byte(q), &req.Query)
+		require.NoError(t, err)
+		res, err := querier.Query(context.Background(), req)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("filter and sort", func(t *testing.T) {
+		res := doQuery(`{"filter":{"AND":[{"EQ":{"color":"` + color + `"}},{"GTE":{"n":1}}]},"sort":[{"key":"n","order":"DESC"}]}`)
+		require.Len(t, res.Results, 2)
+		assert.Equal(t, keys[2], res.Results[0].Key)
+		assert.Equal(t, keys[1], res.Results[1].Key)
+		assert.NotNil(t, res.Results[0].ETag)
+		assert.Empty(t, res.Token)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		res := doQuery(`{"filter":{"EQ":{"color":"` + color + `"}},"sort":[{"key":"n"}],"page":{"limit":2}}`)
+		require.Len(t, res.Results, 2)
+		assert.Equal(t, keys[0], res.Results[0].Key)
+		assert.Equal(t, "2", res.Token)
+
+		res = doQuery(`{"filter":{"EQ":{"color":"` + color + `"}},"sort":[{"key":"n"}],"page":{"limit":2,"token":"2"}}`)
+		require.Len(t, res.Results, 1)
+		assert.Equal(t, keys[2], res.Results[0].Key)
+		assert.Equal(t, "3", res.Token)
+	})
+
+	t.Run("operators", func(t *testing.T) {
+		res := doQuery(`{"filter":{"AND":[{"EQ":{"color":"` + color + `"}},{"LIKE":{"name":"ITEM-_"}},{"EXISTS":"tags"},{"NOT":{"CONTAINS":{"tags":"tag-1"}}}]}}`)
+		require.Len(t, res.Results, 1)
+		assert.Equal(t, keys[2], res.Results[0].Key)
+
+		res = doQuery(`{"filter":{"AND":[{"EQ":{"color":"` + color + `"}},{"NOT":{"EXISTS":"tags"}}]}}`)
+		require.Len(t, res.Results, 1)
+		assert.Equal(t, keys[0], res.Results[0].Key)
+	})
+
+	for _, key := range keys {
+		deleteItem(t, s, key, nil)
+	}
+}
+
 // setGetUpdateDeleteOneItem validates setting one item, getting it, and deleting it.
 func setGetUpdateDeleteOneItem(t *testing.T, s state.Store) {
 	key := randomKey()
@@ -0,0 +1,274 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+// Query builds a SQL query for SQLite, using the JSON1 functions to access the fields of the stored values.
+type Query struct {
+	query     string
+	params    []any
+	limit     int
+	skip      *int64
+	tableName string
+}
+
+func (q *Query) VisitEQ(f *query.EQ) (string, error) {
+	return q.whereField(f.Key, "=", f.Val), nil
+}
+
+func (q *Query) VisitNEQ(f *query.NEQ) (string, error) {
+	return q.whereField(f.Key, "!=", f.Val), nil
+}
+
+func (q *Query) VisitGT(f *query.GT) (string, error) {
+	return q.whereField(f.Key, ">", f.Val), nil
+}
+
+func (q *Query) VisitGTE(f *query.GTE) (string, error) {
+	return q.whereField(f.Key, ">=", f.Val), nil
+}
+
+func (q *Query) VisitLT(f *query.LT) (string, error) {
+	return q.whereField(f.Key, "<", f.Val), nil
+}
+
+func (q *Query) VisitLTE(f *query.LTE) (string, error) {
+	return q.whereField(f.Key, "<=", f.Val), nil
+}
+
+func (q *Query) VisitIN(f *query.IN) (string, error) {
+	if len(f.Vals) == 0 {
+		return "", fmt.Errorf("empty IN operator for key %q", f.Key)
+	}
+
+	placeholders := strings.Repeat("?, ", len(f.Vals))
+	placeholders = placeholders[:len(placeholders)-2]
+
+	str := q.field(f.Key) + " IN (" + placeholders + ")"
+	q.params = append(q.params, f.Vals...)
+	return str, nil
+}
+
+func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
+	var (
+		arr []string
+		str string
+		err error
+	)
+
+	for _, fil := range filters {
+		switch f := fil.(type) {
+		case *query.EQ:
+			str, err = q.VisitEQ(f)
+		case *query.NEQ:
+			str, err = q.VisitNEQ(f)
+		case *query.GT:
+			str, err = q.VisitGT(f)
+		case *query.GTE:
+			str, err = q.VisitGTE(f)
+		case *query.LT:
+			str, err = q.VisitLT(f)
+		case *query.LTE:
+			str, err = q.VisitLTE(f)
+		case *query.IN:
+			str, err = q.VisitIN(f)
+		case *query.OR:
+			str, err = q.VisitOR(f)
+		case *query.AND:
+			str, err = q.VisitAND(f)
+		case *query.NOT:
+			str, err = q.VisitNOT(f)
+		case *query.LIKE:
+			str, err = q.VisitLIKE(f)
+		case *query.EXISTS:
+			str, err = q.VisitEXISTS(f)
+		case *query.CONTAINS:
+			str, err = q.VisitCONTAINS(f)
+		default:
+			return "", fmt.Errorf("unsupported filter type %#v", f)
+		}
+		if err != nil {
+			return "", err
+		}
+		arr = append(arr, str)
+	}
+
+	sep := " " + op + " "
+
+	return "(" + strings.Join(arr, sep) + ")", nil
+}
+
+func (q *Query) VisitAND(f *query.AND) (string, error) {
+	return q.visitFilters("AND", f.Filters)
+}
+
+func (q *Query) VisitOR(f *query.OR) (string, error) {
+	return q.visitFilters("OR", f.Filters)
+}
+
+func (q *Query) VisitNOT(f *query.NOT) (string, error) {
+	str, err := q.visitFilters("AND", []query.Filter{f.Filter})
+	if err != nil {
+		return "", err
+	}
+	return "NOT COALESCE(" + str + ", FALSE)", nil
+}
+
+func (q *Query) VisitLIKE(f *query.LIKE) (string, error) {
+	// LIKE is case-insensitive for ASCII characters in SQLite, so the pattern is translated to GLOB, which is case-sensitive
+	pattern := f.Translate(escapeGlob, func(c rune) string {
+		if c == '%' {
+			return "*"
+		}
+		return "?"
+	})
+	return q.whereField(f.Key, " GLOB ", pattern), nil
+}
+
+func (q *Query) VisitEXISTS(f *query.EXISTS) (string, error) {
+	// json_type returns NULL when the field doesn't exist, and 'null' when the field is null
+	q.params = append(q.params, "$."+f.Key)
+	return "json_type(value, ?) IS NOT NULL", nil
+}
+
+func (q *Query) VisitCONTAINS(f *query.CONTAINS) (string, error) {
+	// The column is qualified with the table name because json_each has a "value" column too
+	q.params = append(q.params, "$."+f.Key, f.Val)
+	return "EXISTS (SELECT 1 FROM json_each(" + q.tableName + ".value, ?) WHERE json_each.value = ?)", nil
+}
+
+func (q *Query) Finalize(filters string, qq *query.Query) error {
+	// Binary values are stored base64-encoded and are not valid JSON, so they are excluded from queries
+	q.query = "SELECT key, value, etag FROM " + q.tableName +
+		" WHERE is_binary = 0 AND (expiration_time IS NULL OR expiration_time > CURRENT_TIMESTAMP)"
+
+	if filters != "" {
+		q.query += " AND " + filters
+	}
+
+	if len(qq.Sort) > 0 {
+		q.query += " ORDER BY "
+
+		for sortIndex, sortItem := range qq.Sort {
+			if sortIndex > 0 {
+				q.query += ", "
+			}
+			q.query += q.field(sortItem.Key)
+			switch sortItem.Order {
+			case "", query.ASC:
+				// Nop
+			case query.DESC:
+				q.query += " DESC"
+			default:
+				return fmt.Errorf("invalid sort order %q", sortItem.Order)
+			}
+		}
+	}
+
+	// In SQLite, OFFSET can only be used together with LIMIT; a negative limit means "no limit"
+	limit := -1
+	if qq.Page.Limit > 0 {
+		limit = qq.Page.Limit
+		q.limit = qq.Page.Limit
+	}
+
+	if len(qq.Page.Token) != 0 {
+		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
+		if err != nil {
+			return err
+		}
+		q.query += " LIMIT " + strconv.Itoa(limit) + " OFFSET " + strconv.FormatInt(skip, 10)
+		q.skip = &skip
+	} else if limit > 0 {
+		q.query += " LIMIT " + strconv.Itoa(limit)
+	}
+
+	return nil
+}
+
+func (q *Query) execute(ctx context.Context, db querier) ([]state.QueryItem, string, error) {
+	rows, err := db.QueryContext(ctx, q.query, q.params...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	ret := []state.QueryItem{}
+	for rows.Next() {
+		var (
+			key  string
+			data []byte
+			etag string
+		)
+		if err = rows.Scan(&key, &data, &etag); err != nil {
+			return nil, "", err
+		}
+		ret = append(ret, state.QueryItem{
+			Key:  key,
+			Data: data,
+			ETag: &etag,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var token string
+	if q.limit != 0 {
+		var skip int64
+		if q.skip != nil {
+			skip = *q.skip
+		}
+		token = strconv.FormatInt(skip+int64(len(ret)), 10)
+	}
+
+	return ret, token, nil
+}
+
+func (q *Query) whereField(key string, op string, value any) string {
+	filterField := q.field(key)
+	q.params = append(q.params, value)
+	return filterField + op + "?"
+}
+
+// escapeGlob escapes the characters with special meaning in GLOB patterns, enclosing them in brackets.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[':
+			b.WriteString("[" + string(c) + "]")
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Returns the expression that extracts a (dot-separated) field from the value column.
+// The JSON path is passed as a parameter, which is added to the list of parameters.
+func (q *Query) field(key string) string {
+	q.params = append(q.params, "$."+key)
+	return "json_extract(value, ?)"
+}
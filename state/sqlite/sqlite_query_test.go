@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlite
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state/query"
+)
+
+func TestSQLiteQueryBuildQuery(t *testing.T) {
+	const base = "SELECT key, value, etag FROM state WHERE is_binary = 0 AND (expiration_time IS NULL OR expiration_time > CURRENT_TIMESTAMP)"
+
+	tests := []struct {
+		input  string
+		query  string
+		params []any
+	}{
+		{
+			input:  "../../tests/state/query/q1.json",
+			query:  base + " LIMIT 2",
+			params: nil,
+		},
+		{
+			input:  "../../tests/state/query/q2.json",
+			query:  base + " AND json_extract(value, ?)=? LIMIT 2",
+			params: []any{"$.state", "CA"},
+		},
+		{
+			input:  "../../tests/state/query/q2-token.json",
+			query:  base + " AND json_extract(value, ?)=? LIMIT 2 OFFSET 2",
+			params: []any{"$.state", "CA"},
+		},
+		{
+			input:  "../../tests/state/query/q3.json",
+			query:  base + " AND (json_extract(value, ?)=? AND json_extract(value, ?) IN (?, ?)) ORDER BY json_extract(value, ?) DESC, json_extract(value, ?)",
+			params: []any{"$.person.org", "A", "$.state", "CA", "WA", "$.state", "$.person.name"},
+		},
+		{
+			input:  "../../tests/state/query/q4-notequal.json",
+			query:  base + " AND (json_extract(value, ?)=? OR (json_extract(value, ?)!=? AND json_extract(value, ?) IN (?, ?))) ORDER BY json_extract(value, ?) DESC, json_extract(value, ?) LIMIT 2",
+			params: []any{"$.person.org", "A", "$.person.org", "B", "$.state", "CA", "WA", "$.state", "$.person.name"},
+		},
+		{
+			input:  "../../tests/state/query/q6.json",
+			query:  base + " AND (json_extract(value, ?)=? OR (json_extract(value, ?)=? AND json_extract(value, ?) IN (?, ?))) ORDER BY json_extract(value, ?) LIMIT 2",
+			params: []any{"$.person.id", float64(123), "$.person.org", "B", "$.person.id", float64(567), float64(890), "$.person.id"},
+		},
+		{
+			input:  "../../tests/state/query/q8.json",
+			query:  base + " AND (json_extract(value, ?)>=? OR (json_extract(value, ?)<? AND json_extract(value, ?) IN (?, ?))) ORDER BY json_extract(value, ?) DESC, json_extract(value, ?) LIMIT 2",
+			params: []any{"$.person.org", float64(123), "$.person.org", float64(10), "$.state", "CA", "WA", "$.state", "$.person.name"},
+		},
+		{
+			input:  "../../tests/state/query/q9.json",
+			query:  base + " AND (json_extract(value, ?) GLOB ? AND json_type(value, ?) IS NOT NULL AND NOT COALESCE((json_extract(value, ?)=?), FALSE) AND EXISTS (SELECT 1 FROM json_each(state.value, ?) WHERE json_each.value = ?)) ORDER BY json_extract(value, ?) LIMIT 2",
+			params: []any{"$.person.name", "J*", "$.person.org", "$.state", "CA", "$.tags", "blue", "$.person.name"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			data, err := os.ReadFile(test.input)
+			require.NoError(t, err)
+			var qq query.Query
+			err = json.Unmarshal(data, &qq)
+			require.NoError(t, err)
+
+			q := &Query{
+				tableName: "state",
+			}
+			qbuilder := query.NewQueryBuilder(q)
+			err = qbuilder.BuildQuery(&qq)
+			require.NoError(t, err)
+			assert.Equal(t, test.query, q.query)
+			assert.Equal(t, test.params, q.params)
+		})
+	}
+
+	t.Run("LIKE pattern", func(t *testing.T) {
+		var qq query.Query
+		err := json.Unmarshal([]byte(`{"filter":{"LIKE":{"name":"a*[\\%_%"}}}`), &qq)
+		require.NoError(t, err)
+
+		q := &Query{
+			tableName: "state",
+		}
+		err = query.NewQueryBuilder(q).BuildQuery(&qq)
+		require.NoError(t, err)
+		assert.Equal(t, base+" AND json_extract(value, ?) GLOB ?", q.query)
+		assert.Equal(t, []any{"$.name", "a[*][[]%?*"}, q.params)
+	})
+
+	t.Run("token without limit", func(t *testing.T) {
+		var qq query.Query
+		err := json.Unmarshal([]byte(`{"page":{"token":"3"}}`), &qq)
+		require.NoError(t, err)
+
+		q := &Query{
+			tableName: "state",
+		}
+		err = query.NewQueryBuilder(q).BuildQuery(&qq)
+		require.NoError(t, err)
+		assert.Equal(t, base+" LIMIT -1 OFFSET 3", q.query)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		var qq query.Query
+		err := json.Unmarshal([]byte(`{"page":{"limit":2,"token":"foo"}}`), &qq)
+		require.NoError(t, err)
+
+		q := &Query{
+			tableName: "state",
+		}
+		err = query.NewQueryBuilder(q).BuildQuery(&qq)
+		require.Error(t, err)
+	})
+}
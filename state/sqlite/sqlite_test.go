@@ -346,6 +346,14 @@ func (m *fakeDBaccess) ExecuteMulti(ctx context.Context, reqs []state.Transactio
 	return nil
 }
 
+func (m *fakeDBaccess) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	return nil, nil
+}
+
+func (m *fakeDBaccess) ListKeys(ctx context.Context, req *state.ListKeysRequest) (*state.ListKeysResponse, error) {
+	return nil, nil
+}
+
 func (m *fakeDBaccess) Close() error {
 	return nil
 }
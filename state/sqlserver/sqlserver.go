@@ -70,6 +70,7 @@ func New(logger logger.Logger) state.Store {
 			state.FeatureETag,
 			state.FeatureTransactional,
 			state.FeatureTTL,
+			state.FeatureQueryAPI,
 		},
 		logger:          logger,
 		migratorFactory: newMigration,
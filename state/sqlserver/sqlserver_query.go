@@ -0,0 +1,340 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+	"github.com/dapr/kit/ptr"
+)
+
+// Query executes a query against the store.
+func (s *SQLServer) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	q := &Query{
+		schemaName: s.metadata.SchemaName,
+		tableName:  s.metadata.TableName,
+	}
+	qbuilder := query.NewQueryBuilder(q)
+	if err := qbuilder.BuildQuery(&req.Query); err != nil {
+		return &state.QueryResponse{}, err
+	}
+	data, token, err := q.execute(ctx, s.db)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	return &state.QueryResponse{
+		Results: data,
+		Token:   token,
+	}, nil
+}
+
+// Query builds a T-SQL query, using JSON_VALUE to access the fields of the stored values.
+// JSON_VALUE returns fields as text, so they're compared with the text representation of the values for equality, and converted to numbers for comparisons with numeric values.
+type Query struct {
+	query      string
+	params     []any
+	limit      int
+	skip       *int64
+	schemaName string
+	tableName  string
+}
+
+func (q *Query) VisitEQ(f *query.EQ) (string, error) {
+	return q.whereFieldText(f.Key, "=", f.Val), nil
+}
+
+func (q *Query) VisitNEQ(f *query.NEQ) (string, error) {
+	return q.whereFieldText(f.Key, "!=", f.Val), nil
+}
+
+func (q *Query) VisitGT(f *query.GT) (string, error) {
+	return q.whereFieldCompare(f.Key, ">", f.Val)
+}
+
+func (q *Query) VisitGTE(f *query.GTE) (string, error) {
+	return q.whereFieldCompare(f.Key, ">=", f.Val)
+}
+
+func (q *Query) VisitLT(f *query.LT) (string, error) {
+	return q.whereFieldCompare(f.Key, "<", f.Val)
+}
+
+func (q *Query) VisitLTE(f *query.LTE) (string, error) {
+	return q.whereFieldCompare(f.Key, "<=", f.Val)
+}
+
+func (q *Query) VisitIN(f *query.IN) (string, error) {
+	if len(f.Vals) == 0 {
+		return "", fmt.Errorf("empty IN operator for key %q", f.Key)
+	}
+
+	placeholders := make([]string, len(f.Vals))
+	for i, v := range f.Vals {
+		placeholders[i] = q.addParam(fmt.Sprintf("%v", v))
+	}
+	return q.field(f.Key) + " IN (" + strings.Join(placeholders, ", ") + ")", nil
+}
+
+func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
+	var (
+		arr []string
+		str string
+		err error
+	)
+
+	for _, fil := range filters {
+		switch f := fil.(type) {
+		case *query.EQ:
+			str, err = q.VisitEQ(f)
+		case *query.NEQ:
+			str, err = q.VisitNEQ(f)
+		case *query.GT:
+			str, err = q.VisitGT(f)
+		case *query.GTE:
+			str, err = q.VisitGTE(f)
+		case *query.LT:
+			str, err = q.VisitLT(f)
+		case *query.LTE:
+			str, err = q.VisitLTE(f)
+		case *query.IN:
+			str, err = q.VisitIN(f)
+		case *query.OR:
+			str, err = q.VisitOR(f)
+		case *query.AND:
+			str, err = q.VisitAND(f)
+		case *query.NOT:
+			str, err = q.VisitNOT(f)
+		case *query.LIKE:
+			str, err = q.VisitLIKE(f)
+		case *query.EXISTS:
+			str, err = q.VisitEXISTS(f)
+		case *query.CONTAINS:
+			str, err = q.VisitCONTAINS(f)
+		default:
+			return "", fmt.Errorf("unsupported filter type %#v", f)
+		}
+		if err != nil {
+			return "", err
+		}
+		arr = append(arr, str)
+	}
+
+	sep := " " + op + " "
+
+	return "(" + strings.Join(arr, sep) + ")", nil
+}
+
+func (q *Query) VisitAND(f *query.AND) (string, error) {
+	return q.visitFilters("AND", f.Filters)
+}
+
+func (q *Query) VisitOR(f *query.OR) (string, error) {
+	return q.visitFilters("OR", f.Filters)
+}
+
+func (q *Query) VisitNOT(f *query.NOT) (string, error) {
+	str, err := q.visitFilters("AND", []query.Filter{f.Filter})
+	if err != nil {
+		return "", err
+	}
+	// Predicates can't be used as values in T-SQL, so CASE is used instead of COALESCE; predicates that are unknown (because the field doesn't exist) are treated as false
+	return "CASE WHEN " + str + " THEN 1 ELSE 0 END = 0", nil
+}
+
+func (q *Query) VisitLIKE(f *query.LIKE) (string, error) {
+	// The binary collation makes the match case-sensitive
+	pattern := f.Translate(escapeLike, func(c rune) string {
+		return string(c)
+	})
+	return q.field(f.Key) + " COLLATE Latin1_General_100_BIN2 LIKE " + q.addParam(pattern), nil
+}
+
+func (q *Query) VisitEXISTS(f *query.EXISTS) (string, error) {
+	// Fields that are null, objects or arrays are returned as NULL by JSON_VALUE, so look for the key in the parent object instead
+	parent := "$"
+	name := f.Key
+	if i := strings.LastIndexByte(f.Key, '.'); i >= 0 {
+		parent = jsonPath(f.Key[:i])
+		name = f.Key[i+1:]
+	}
+	return "EXISTS (SELECT 1 FROM OPENJSON(CASE WHEN ISJSON([Data]) = 1 THEN [Data] END, " + q.addParam(parent) + ") WHERE [key] = " + q.addParam(name) + ")", nil
+}
+
+func (q *Query) VisitCONTAINS(f *query.CONTAINS) (string, error) {
+	// OPENJSON returns the elements of the array as text
+	return "EXISTS (SELECT 1 FROM OPENJSON(CASE WHEN ISJSON([Data]) = 1 THEN [Data] END, " + q.addParam(jsonPath(f.Key)) + ") WHERE [value] = " + q.addParam(fmt.Sprintf("%v", f.Val)) + ")", nil
+}
+
+func (q *Query) Finalize(filters string, qq *query.Query) error {
+	// The key is converted to a string because the column could be a UUID or an integer
+	// Values that are not JSON (such as binary data) are excluded from queries
+	q.query = fmt.Sprintf("SELECT CAST([Key] AS NVARCHAR(MAX)), [Data], [RowVersion] FROM [%s].[%s] WHERE ISJSON([Data]) = 1 AND ([ExpireDate] IS NULL OR [ExpireDate] > GETDATE())", q.schemaName, q.tableName)
+
+	if filters != "" {
+		q.query += " AND " + filters
+	}
+
+	if qq.Page.Limit > 0 {
+		q.limit = qq.Page.Limit
+	}
+
+	if len(qq.Page.Token) != 0 {
+		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
+		if err != nil {
+			return err
+		}
+		q.skip = &skip
+	}
+
+	switch {
+	case len(qq.Sort) > 0:
+		q.query += " ORDER BY "
+
+		for sortIndex, sortItem := range qq.Sort {
+			if sortIndex > 0 {
+				q.query += ", "
+			}
+			q.query += q.field(sortItem.Key)
+			switch sortItem.Order {
+			case "", query.ASC:
+				// Nop
+			case query.DESC:
+				q.query += " DESC"
+			default:
+				return fmt.Errorf("invalid sort order %q", sortItem.Order)
+			}
+		}
+	case q.limit > 0 || q.skip != nil:
+		// OFFSET and FETCH require an ORDER BY clause
+		q.query += " ORDER BY [Key]"
+	}
+
+	if q.limit > 0 || q.skip != nil {
+		var skip int64
+		if q.skip != nil {
+			skip = *q.skip
+		}
+		q.query += " OFFSET " + strconv.FormatInt(skip, 10) + " ROWS"
+		if q.limit > 0 {
+			q.query += " FETCH NEXT " + strconv.Itoa(q.limit) + " ROWS ONLY"
+		}
+	}
+
+	return nil
+}
+
+func (q *Query) execute(ctx context.Context, db *sql.DB) ([]state.QueryItem, string, error) {
+	rows, err := db.QueryContext(ctx, q.query, q.params...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	ret := []state.QueryItem{}
+	for rows.Next() {
+		var (
+			key        string
+			data       string
+			rowVersion []byte
+		)
+		if err = rows.Scan(&key, &data, &rowVersion); err != nil {
+			return nil, "", err
+		}
+		ret = append(ret, state.QueryItem{
+			Key:  key,
+			Data: []byte(data),
+			ETag: ptr.Of(hex.EncodeToString(rowVersion)),
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var token string
+	if q.limit != 0 {
+		var skip int64
+		if q.skip != nil {
+			skip = *q.skip
+		}
+		token = strconv.FormatInt(skip+int64(len(ret)), 10)
+	}
+
+	return ret, token, nil
+}
+
+// addParam adds a named parameter and returns its placeholder.
+func (q *Query) addParam(value any) string {
+	name := "p" + strconv.Itoa(len(q.params)+1)
+	q.params = append(q.params, sql.Named(name, value))
+	return "@" + name
+}
+
+// field returns the expression that extracts a (dot-separated) field from the Data column as text.
+// JSON_VALUE fails on values that are not valid JSON, and SQL Server doesn't guarantee the order in which predicates are evaluated, so those values are replaced with NULL.
+func (q *Query) field(key string) string {
+	return "JSON_VALUE(CASE WHEN ISJSON([Data]) = 1 THEN [Data] END, " + q.addParam(jsonPath(key)) + ")"
+}
+
+// whereFieldText compares the text representation of a field with the value.
+func (q *Query) whereFieldText(key string, op string, value any) string {
+	filterField := q.field(key)
+	return filterField + op + q.addParam(fmt.Sprintf("%v", value))
+}
+
+// whereFieldCompare compares a field with the value, which must be numeric.
+// The field is converted to a number; TRY_CAST returns NULL for fields that are not numeric.
+func (q *Query) whereFieldCompare(key string, op string, value any) (string, error) {
+	switch v := value.(type) {
+	case float64, float32, int, int64, int32:
+		filterField := "TRY_CAST(" + q.field(key) + " AS FLOAT)"
+		return filterField + op + q.addParam(v), nil
+	case string:
+		return "", fmt.Errorf("unsupported type of value %s; string type not permitted", v)
+	default:
+		return "", fmt.Errorf("unsupported type of value %v; only numeric values are permitted", v)
+	}
+}
+
+// escapeLike escapes the characters with special meaning in LIKE patterns, enclosing them in brackets.
+func escapeLike(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '%', '_', '[':
+			b.WriteString("[" + string(c) + "]")
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Returns the JSON path for a (dot-separated) field name.
+// Each member name is quoted, so it can contain characters that are not allowed in identifiers.
+func jsonPath(key string) string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = strconv.Quote(p)
+	}
+	return "$." + strings.Join(parts, ".")
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state/query"
+)
+
+func TestSQLServerQueryBuildQuery(t *testing.T) {
+	const base = "SELECT CAST([Key] AS NVARCHAR(MAX)), [Data], [RowVersion] FROM [dbo].[state] WHERE ISJSON([Data]) = 1 AND ([ExpireDate] IS NULL OR [ExpireDate] > GETDATE())"
+	field := func(n int) string {
+		return "JSON_VALUE(CASE WHEN ISJSON([Data]) = 1 THEN [Data] END, @p" + strconv.Itoa(n) + ")"
+	}
+	params := func(vals ...any) []any {
+		res := make([]any, len(vals))
+		for i, v := range vals {
+			res[i] = sql.Named("p"+strconv.Itoa(i+1), v)
+		}
+		return res
+	}
+
+	tests := []struct {
+		input  string
+		query  string
+		params []any
+	}{
+		{
+			input: "../../tests/state/query/q1.json",
+			query: base + " ORDER BY [Key] OFFSET 0 ROWS FETCH NEXT 2 ROWS ONLY",
+		},
+		{
+			input:  "../../tests/state/query/q2.json",
+			query:  base + " AND " + field(1) + "=@p2 ORDER BY [Key] OFFSET 0 ROWS FETCH NEXT 2 ROWS ONLY",
+			params: params(`$."state"`, "CA"),
+		},
+		{
+			input:  "../../tests/state/query/q2-token.json",
+			query:  base + " AND " + field(1) + "=@p2 ORDER BY [Key] OFFSET 2 ROWS FETCH NEXT 2 ROWS ONLY",
+			params: params(`$."state"`, "CA"),
+		},
+		{
+			input:  "../../tests/state/query/q3.json",
+			query:  base + " AND (" + field(1) + "=@p2 AND " + field(5) + " IN (@p3, @p4)) ORDER BY " + field(6) + " DESC, " + field(7),
+			params: params(`$."person"."org"`, "A", "CA", "WA", `$."state"`, `$."state"`, `$."person"."name"`),
+		},
+		{
+			input:  "../../tests/state/query/q6.json",
+			query:  base + " AND (" + field(1) + "=@p2 OR (" + field(3) + "=@p4 AND " + field(7) + " IN (@p5, @p6))) ORDER BY " + field(8) + " OFFSET 0 ROWS FETCH NEXT 2 ROWS ONLY",
+			params: params(`$."person"."id"`, "123", `$."person"."org"`, "B", "567", "890", `$."person"."id"`, `$."person"."id"`),
+		},
+		{
+			input:  "../../tests/state/query/q8.json",
+			query:  base + " AND (TRY_CAST(" + field(1) + " AS FLOAT)>=@p2 OR (TRY_CAST(" + field(3) + " AS FLOAT)<@p4 AND " + field(7) + " IN (@p5, @p6))) ORDER BY " + field(8) + " DESC, " + field(9) + " OFFSET 0 ROWS FETCH NEXT 2 ROWS ONLY",
+			params: params(`$."person"."org"`, float64(123), `$."person"."org"`, float64(10), "CA", "WA", `$."state"`, `$."state"`, `$."person"."name"`),
+		},
+		{
+			input:  "../../tests/state/query/q9.json",
+			query:  base + " AND (" + field(1) + " COLLATE Latin1_General_100_BIN2 LIKE @p2 AND EXISTS (SELECT 1 FROM OPENJSON(CASE WHEN ISJSON([Data]) = 1 THEN [Data] END, @p3) WHERE [key] = @p4) AND CASE WHEN (" + field(5) + "=@p6) THEN 1 ELSE 0 END = 0 AND EXISTS (SELECT 1 FROM OPENJSON(CASE WHEN ISJSON([Data]) = 1 THEN [Data] END, @p7) WHERE [value] = @p8)) ORDER BY " + field(9) + " OFFSET 0 ROWS FETCH NEXT 2 ROWS ONLY",
+			params: params(`$."person"."name"`, "J%", `$."person"`, "org", `$."state"`, "CA", `$."tags"`, "blue", `$."person"."name"`),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			data, err := os.ReadFile(test.input)
+			require.NoError(t, err)
+			var qq query.Query
+			err = json.Unmarshal(data, &qq)
+			require.NoError(t, err)
+
+			q := &Query{
+				schemaName: "dbo",
+				tableName:  defaultTable,
+			}
+			qbuilder := query.NewQueryBuilder(q)
+			err = qbuilder.BuildQuery(&qq)
+			require.NoError(t, err)
+			assert.Equal(t, test.query, q.query)
+			assert.Equal(t, test.params, q.params)
+		})
+	}
+
+	t.Run("range with non-numeric value", func(t *testing.T) {
+		for _, filter := range []string{`{"GT":{"name":"a"}}`, `{"LTE":{"active":true}}`} {
+			var qq query.Query
+			err := json.Unmarshal([]byte(`{"filter":`+filter+`}`), &qq)
+			require.NoError(t, err)
+
+			q := &Query{
+				schemaName: "dbo",
+				tableName:  defaultTable,
+			}
+			err = query.NewQueryBuilder(q).BuildQuery(&qq)
+			require.Error(t, err, filter)
+		}
+	})
+
+	t.Run("LIKE pattern", func(t *testing.T) {
+		var qq query.Query
+		err := json.Unmarshal([]byte(`{"filter":{"LIKE":{"name":"[a]\\_%_"}}}`), &qq)
+		require.NoError(t, err)
+
+		q := &Query{
+			schemaName: "dbo",
+			tableName:  defaultTable,
+		}
+		err = query.NewQueryBuilder(q).BuildQuery(&qq)
+		require.NoError(t, err)
+		assert.Equal(t, base+" AND "+field(1)+" COLLATE Latin1_General_100_BIN2 LIKE @p2", q.query)
+		assert.Equal(t, params(`$."name"`, "[[]a][_]%_"), q.params)
+	})
+}
@@ -71,3 +71,19 @@ func Ping(ctx context.Context, store Store) error {
 type DeleteWithPrefix interface {
 	DeleteWithPrefix(ctx context.Context, req DeleteWithPrefixRequest) (DeleteWithPrefixResponse, error)
 }
+
+// ListKeys is an optional interface to list the keys in the state store, optionally filtered by a prefix.
+type ListKeys interface {
+	ListKeys(ctx context.Context, req *ListKeysRequest) (*ListKeysResponse, error)
+}
+
+// Watcher is an optional interface for state stores that can notify consumers of changes to keys.
+type Watcher interface {
+	// Watch invokes handler for each change to the keys that match the request, until ctx is canceled.
+	// It returns once the watch is established; events of a watch are delivered in order, one at a time.
+	Watch(ctx context.Context, req *WatchRequest, handler WatchHandler) error
+}
+
+// WatchHandler is invoked for each change to a watched key.
+// Errors returned by the handler are logged by the state store.
+type WatchHandler func(ctx context.Context, event *WatchEvent) error
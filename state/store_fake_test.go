@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+var _ Store = &storeMap{}
+
+// example of a store which keeps values in memory
+type storeMap struct {
+	BulkStore
+
+	lock     sync.Mutex
+	data     map[string][]byte
+	metadata map[string]map[string]string
+	etags    map[string]int
+	gets     atomic.Int32
+}
+
+func newStoreMap() *storeMap {
+	s := &storeMap{
+		data:     map[string][]byte{},
+		metadata: map[string]map[string]string{},
+		etags:    map[string]int{},
+	}
+	s.BulkStore = NewDefaultBulkStore(s)
+	return s
+}
+
+func (s *storeMap) Init(ctx context.Context, metadata Metadata) error {
+	return nil
+}
+
+func (s *storeMap) Delete(ctx context.Context, req *DeleteRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.data, req.Key)
+	delete(s.metadata, req.Key)
+	return nil
+}
+
+func (s *storeMap) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	s.gets.Add(1)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.data[req.Key]
+	if !ok {
+		return &GetResponse{}, nil
+	}
+	etag := strconv.Itoa(s.etags[req.Key])
+	return &GetResponse{Data: slices.Clone(data), ETag: &etag}, nil
+}
+
+func (s *storeMap) Set(ctx context.Context, req *SetRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, err := json.Marshal(req.Value)
+	if v, ok := req.Value.([]byte); ok {
+		data, err = v, nil
+	}
+	if err != nil {
+		return err
+	}
+	s.data[req.Key] = data
+	s.metadata[req.Key] = req.Metadata
+	s.etags[req.Key]++
+	return nil
+}
+
+func (s *storeMap) Multi(ctx context.Context, request *TransactionalStateRequest) error {
+	for _, op := range request.Operations {
+		var err error
+		switch req := op.(type) {
+		case SetRequest:
+			err = s.Set(ctx, &req)
+		case DeleteRequest:
+			err = s.Delete(ctx, &req)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *storeMap) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := &QueryResponse{}
+	for key, data := range s.data {
+		res.Results = append(res.Results, QueryItem{Key: key, Data: slices.Clone(data)})
+	}
+	return res, nil
+}
+
+func (s *storeMap) Close() error {
+	return nil
+}
+
+func (s *storeMap) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	return
+}
+
+func (s *storeMap) Features() []Feature {
+	return []Feature{FeatureETag, FeatureTransactional, FeatureQueryAPI}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+// KeyRows is implemented by the rows returned by database drivers, such as *sql.Rows and pgx.Rows.
+type KeyRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+// KeysPage returns a page of the keys listed by a ListKeys request, and the continuation token of the next page.
+// Keys must be sorted, and stores list one more key than pageSize to know whether there's a next page.
+// The continuation token is the last key of the page, or empty if it's the last page.
+func KeysPage(keys []string, pageSize int) ([]string, string) {
+	if len(keys) <= pageSize {
+		return keys, ""
+	}
+	keys = keys[:pageSize]
+	return keys, keys[pageSize-1]
+}
+
+// ReadKeysPage reads the rows of a single key selected by a ListKeys request, and returns the page as KeysPage does.
+// Rows must be sorted by key and limited to one more key than pageSize.
+func ReadKeysPage(rows KeyRows, pageSize int) ([]string, string, error) {
+	keys := make([]string, 0, pageSize+1)
+	var key string
+	for rows.Next() {
+		err := rows.Scan(&key)
+		if err != nil {
+			return nil, "", err
+		}
+		keys = append(keys, key)
+	}
+	err := rows.Err()
+	if err != nil {
+		return nil, "", err
+	}
+
+	keys, token := KeysPage(keys, pageSize)
+	return keys, token, nil
+}
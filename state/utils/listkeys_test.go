@@ -0,0 +1,34 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysPage(t *testing.T) {
+	keys, token := KeysPage([]string{"a", "b", "c"}, 2)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Equal(t, "b", token)
+
+	keys, token = KeysPage([]string{"a", "b"}, 2)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Empty(t, token)
+
+	keys, token = KeysPage([]string{}, 2)
+	assert.Empty(t, keys)
+	assert.Empty(t, token)
+}
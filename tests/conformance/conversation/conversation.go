@@ -15,6 +15,7 @@ package conversation
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/dapr/components-contrib/conversation"
@@ -69,5 +70,54 @@ func ConformanceTests(t *testing.T, props map[string]string, conv conversation.C
 			assert.Len(t, resp.Outputs, 1)
 			assert.NotEmpty(t, resp.Outputs[0].Result)
 		})
+
+		t.Run("report usage and finish reason", func(t *testing.T) {
+			req := &conversation.ConversationRequest{
+				Inputs: []conversation.ConversationInput{
+					{
+						Message: "what is the time?",
+					},
+				},
+			}
+
+			resp, err := conv.Converse(context.Background(), req)
+			require.NoError(t, err)
+			require.Len(t, resp.Outputs, 1)
+			assert.Equal(t, conversation.FinishReasonStop, resp.Outputs[0].FinishReason)
+
+			require.NotNil(t, resp.Usage)
+			assert.Positive(t, resp.Usage.PromptTokens)
+			assert.Positive(t, resp.Usage.CompletionTokens)
+			assert.Equal(t, resp.Usage.PromptTokens+resp.Usage.CompletionTokens, resp.Usage.TotalTokens)
+		})
+	})
+
+	// Streaming is optional
+	streaming, ok := conv.(conversation.StreamingConversation)
+	if !ok {
+		return
+	}
+
+	t.Run("converse stream", func(t *testing.T) {
+		t.Run("get non-empty chunks and a final response", func(t *testing.T) {
+			req := &conversation.ConversationRequest{
+				Inputs: []conversation.ConversationInput{
+					{
+						Message: "what is the time?",
+					},
+				},
+			}
+
+			var streamed strings.Builder
+			resp, err := streaming.ConverseStream(context.Background(), req, func(ctx context.Context, chunk *conversation.ConversationStreamChunk) error {
+				assert.NotEmpty(t, chunk.Content)
+				streamed.WriteString(chunk.Content)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, resp.Outputs, 1)
+			assert.NotEmpty(t, resp.Outputs[0].Result)
+			assert.Equal(t, resp.Outputs[0].Result, streamed.String())
+		})
 	})
 }
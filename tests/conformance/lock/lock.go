@@ -177,4 +177,176 @@ func ConformanceTests(t *testing.T, props map[string]string, lockstore lock.Stor
 			return err == nil && res != nil && res.Success
 		}, 5*time.Second, 100*time.Millisecond, "Lock 2 was not released in time after its scheduled expiration")
 	})
+
+	if config.HasOperation("renewlock") {
+		t.Run("RenewLock", func(t *testing.T) {
+			renewer, ok := lockstore.(lock.RenewableStore)
+			require.True(t, ok, "lock store does not implement lock.RenewableStore")
+
+			lockKey := key + "-renew"
+
+			t.Run("fails to renew nonexistent lock", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				res, err := renewer.RenewLock(ctx, &lock.RenewLockRequest{
+					ResourceID:      lockKey,
+					LockOwner:       lockOwner,
+					ExpiryInSeconds: 3,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.Equal(t, lock.LockDoesNotExist, res.Status)
+			})
+
+			t.Run("acquire lock", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				res, err := lockstore.TryLock(ctx, &lock.TryLockRequest{
+					ResourceID:      lockKey,
+					LockOwner:       lockOwner,
+					ExpiryInSeconds: 3,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.True(t, res.Success)
+			})
+
+			t.Run("fails to renew with wrong owner", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				res, err := renewer.RenewLock(ctx, &lock.RenewLockRequest{
+					ResourceID:      lockKey,
+					LockOwner:       "nonowner",
+					ExpiryInSeconds: 15,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.Equal(t, lock.LockBelongsToOthers, res.Status)
+			})
+
+			t.Run("renews successfully", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				res, err := renewer.RenewLock(ctx, &lock.RenewLockRequest{
+					ResourceID:      lockKey,
+					LockOwner:       lockOwner,
+					ExpiryInSeconds: 15,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.Equal(t, lock.Success, res.Status)
+			})
+
+			t.Run("lock does not expire at the original time", func(t *testing.T) {
+				// Wait past the original expiration
+				time.Sleep(4 * time.Second)
+
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				res, err := lockstore.TryLock(ctx, &lock.TryLockRequest{
+					ResourceID:      lockKey,
+					LockOwner:       "nonowner",
+					ExpiryInSeconds: 3,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.False(t, res.Success)
+			})
+		})
+	}
+
+	if config.HasOperation("lockinfo") {
+		t.Run("GetLockInfo", func(t *testing.T) {
+			infoStore, ok := lockstore.(lock.InfoStore)
+			require.True(t, ok, "lock store does not implement lock.InfoStore")
+
+			lockKey := key + "-info"
+
+			t.Run("lock does not exist", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				res, err := infoStore.GetLockInfo(ctx, &lock.GetLockInfoRequest{
+					ResourceID: lockKey,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.False(t, res.Exists)
+				assert.Empty(t, res.LockOwner)
+			})
+
+			t.Run("returns owner and TTL", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				lockRes, err := lockstore.TryLock(ctx, &lock.TryLockRequest{
+					ResourceID:      lockKey,
+					LockOwner:       lockOwner,
+					ExpiryInSeconds: 15,
+				})
+				require.NoError(t, err)
+				require.True(t, lockRes.Success)
+
+				res, err := infoStore.GetLockInfo(ctx, &lock.GetLockInfoRequest{
+					ResourceID: lockKey,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.True(t, res.Exists)
+				assert.Equal(t, lockOwner, res.LockOwner)
+				assert.Greater(t, res.RemainingTTLInSeconds, int32(0))
+				assert.LessOrEqual(t, res.RemainingTTLInSeconds, int32(15))
+			})
+		})
+	}
+
+	if config.HasOperation("lock") {
+		t.Run("Lock", func(t *testing.T) {
+			blocking, ok := lockstore.(lock.BlockingStore)
+			require.True(t, ok, "lock store does not implement lock.BlockingStore")
+
+			lockKey := key + "-blocking"
+
+			t.Run("acquires available lock", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				res, err := blocking.Lock(ctx, &lock.LockRequest{
+					ResourceID:           lockKey,
+					LockOwner:            lockOwner,
+					ExpiryInSeconds:      2,
+					WaitTimeoutInSeconds: 1,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.True(t, res.Success)
+			})
+
+			t.Run("times out waiting for lock", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				res, err := blocking.Lock(ctx, &lock.LockRequest{
+					ResourceID:           lockKey,
+					LockOwner:            "nonowner",
+					ExpiryInSeconds:      15,
+					WaitTimeoutInSeconds: 1,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.False(t, res.Success)
+			})
+
+			t.Run("acquires lock once released", func(t *testing.T) {
+				// The lock held by lockOwner expires after 2s
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				res, err := blocking.Lock(ctx, &lock.LockRequest{
+					ResourceID:           lockKey,
+					LockOwner:            "nonowner",
+					ExpiryInSeconds:      15,
+					WaitTimeoutInSeconds: 5,
+				})
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.True(t, res.Success)
+			})
+		})
+	}
 }
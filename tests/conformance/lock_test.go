@@ -23,7 +23,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/lock"
+	l_inmemory "github.com/dapr/components-contrib/lock/in-memory"
 	l_redis "github.com/dapr/components-contrib/lock/redis"
+	l_sqlite "github.com/dapr/components-contrib/lock/sqlite"
 	conf_lock "github.com/dapr/components-contrib/tests/conformance/lock"
 )
 
@@ -60,6 +62,10 @@ func loadLockStore(name string) lock.Store {
 		return l_redis.NewStandaloneRedisLock(testLogger)
 	case "redis.v7":
 		return l_redis.NewStandaloneRedisLock(testLogger)
+	case "in-memory":
+		return l_inmemory.NewInMemoryLock(testLogger)
+	case "sqlite":
+		return l_sqlite.NewSqliteLock(testLogger)
 	default:
 		return nil
 	}
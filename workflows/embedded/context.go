@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// ErrTimedOut is returned by WaitForExternalEvent when the timeout expires before the event is received.
+var ErrTimedOut = errors.New("timed out waiting for the external event")
+
+// WorkflowFunc is the signature of a workflow.
+// Workflows are executed again from the start when an instance is resumed after a restart, and the results of the steps that were already completed are returned from the history.
+// Because of that, workflows must be deterministic: all side effects must be performed in activities.
+type WorkflowFunc func(ctx *WorkflowContext) (string, error)
+
+// ActivityFunc is the signature of an activity.
+// The context is canceled when the instance is terminated or the engine is closed.
+type ActivityFunc func(ctx context.Context, input string) (string, error)
+
+// WorkflowContext is passed to workflows to schedule the steps of an instance.
+// Its methods must be invoked from the goroutine running the workflow only.
+type WorkflowContext struct {
+	engine *Embedded
+	inst   *instance
+	// Index of the next step in the history
+	step int
+}
+
+// InstanceID returns the ID of the workflow instance.
+func (c *WorkflowContext) InstanceID() string {
+	return c.inst.state.Metadata.InstanceID
+}
+
+// Input returns the input of the workflow instance.
+func (c *WorkflowContext) Input() string {
+	return c.inst.state.Metadata.Input
+}
+
+// IsReplaying returns true if the workflow is re-executing steps that are already in the history.
+func (c *WorkflowContext) IsReplaying() bool {
+	c.inst.lock.Lock()
+	defer c.inst.lock.Unlock()
+	return c.step < len(c.inst.state.History) && c.inst.state.History[c.step].Completed
+}
+
+// SetCustomStatus sets the custom status of the instance, which is returned by Get.
+// The custom status is saved in the state store together with the next step.
+func (c *WorkflowContext) SetCustomStatus(status string) {
+	c.inst.lock.Lock()
+	c.inst.state.Metadata.CustomStatus = status
+	c.inst.lock.Unlock()
+}
+
+// CallActivity executes an activity and returns its result.
+// Activities are executed at least once: if the engine stops while an activity is running, the activity is executed again when the instance is resumed.
+func (c *WorkflowContext) CallActivity(name string, input string) (string, error) {
+	idx, step := c.nextStep(stepActivity, name)
+	if step.Completed {
+		return step.Result, step.err()
+	}
+
+	c.waitWhileSuspended()
+
+	var (
+		res string
+		err error
+	)
+	fn, ok := c.engine.activities[name]
+	if ok {
+		res, err = fn(c.inst.ctx, input)
+	} else {
+		err = fmt.Errorf("activity %q is not registered", name)
+	}
+	if c.inst.ctx.Err() != nil {
+		// The instance was terminated or the engine is closing
+		runtime.Goexit()
+	}
+
+	return c.completeStep(idx, res, err)
+}
+
+// CreateTimer returns after the given duration.
+// Timers are durable: if the instance is resumed after a restart, the timer fires at the time that was originally scheduled.
+func (c *WorkflowContext) CreateTimer(d time.Duration) {
+	idx, step := c.nextStep(stepTimer, "")
+	if step.Completed {
+		return
+	}
+	if step.FireAt == nil {
+		step.FireAt = c.engine.saveStepDeadline(c.inst, idx, d)
+	}
+
+	t := time.NewTimer(time.Until(*step.FireAt))
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-c.inst.ctx.Done():
+		runtime.Goexit()
+	}
+
+	c.waitWhileSuspended()
+	c.completeStep(idx, "", nil)
+}
+
+// WaitForExternalEvent waits until an event with the given name is raised for the instance and returns its data.
+// Events are received in the order they are raised; events raised before the workflow waits for them are buffered.
+// If timeout is greater than zero and the event is not received before the timeout expires, ErrTimedOut is returned.
+func (c *WorkflowContext) WaitForExternalEvent(name string, timeout time.Duration) (string, error) {
+	idx, step := c.nextStep(stepEvent, name)
+	if step.Completed {
+		return step.Result, step.err()
+	}
+	if step.FireAt == nil && timeout > 0 {
+		step.FireAt = c.engine.saveStepDeadline(c.inst, idx, timeout)
+	}
+
+	var timeoutCh <-chan time.Time
+	if step.FireAt != nil {
+		t := time.NewTimer(time.Until(*step.FireAt))
+		defer t.Stop()
+		timeoutCh = t.C
+	}
+
+	for {
+		c.waitWhileSuspended()
+
+		data, ok := c.engine.receiveEvent(c.inst, idx, name)
+		if ok {
+			return data, nil
+		}
+
+		select {
+		case <-c.inst.wakeCh:
+			// Check the inbox again
+		case <-timeoutCh:
+			return c.completeStep(idx, "", ErrTimedOut)
+		case <-c.inst.ctx.Done():
+			runtime.Goexit()
+		}
+	}
+}
+
+// nextStep returns the next step from the history, adding it if the workflow is executing it for the first time.
+// If the step in the history doesn't match the one the workflow is executing, the workflow is not deterministic and the instance fails.
+func (c *WorkflowContext) nextStep(typ string, name string) (int, historyStep) {
+	if c.inst.ctx.Err() != nil {
+		// The instance was terminated or the engine is closing
+		runtime.Goexit()
+	}
+
+	c.inst.lock.Lock()
+	defer c.inst.lock.Unlock()
+
+	idx := c.step
+	c.step++
+
+	history := c.inst.state.History
+	if idx >= len(history) {
+		step := historyStep{Type: typ, Name: name}
+		c.inst.state.History = append(history, step)
+		return idx, step
+	}
+
+	step := history[idx]
+	if step.Type != typ || step.Name != name {
+		panic(fmt.Errorf("workflow is not deterministic: step %d is %s %q in the history, but the workflow executed %s %q", idx, step.Type, step.Name, typ, name))
+	}
+	return idx, step
+}
+
+// completeStep saves the result of a step and returns it.
+func (c *WorkflowContext) completeStep(idx int, res string, err error) (string, error) {
+	c.inst.lock.Lock()
+	defer c.inst.lock.Unlock()
+
+	step := &c.inst.state.History[idx]
+	step.Completed = true
+	step.Result = res
+	if err != nil {
+		step.Error = err.Error()
+	}
+	c.engine.mustSaveState(c.inst)
+
+	return step.Result, step.err()
+}
+
+// waitWhileSuspended blocks while the instance is suspended.
+func (c *WorkflowContext) waitWhileSuspended() {
+	for {
+		c.inst.lock.Lock()
+		suspended := c.inst.state.Metadata.RuntimeStatus == StatusSuspended
+		c.inst.lock.Unlock()
+		if !suspended {
+			return
+		}
+
+		select {
+		case <-c.inst.wakeCh:
+		case <-c.inst.ctx.Done():
+			runtime.Goexit()
+		}
+	}
+}
@@ -0,0 +1,614 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/workflows"
+	"github.com/dapr/kit/logger"
+)
+
+// Runtime statuses of workflow instances.
+const (
+	StatusRunning    = "Running"
+	StatusSuspended  = "Suspended"
+	StatusCompleted  = "Completed"
+	StatusFailed     = "Failed"
+	StatusTerminated = "Terminated"
+)
+
+// Keys of the properties returned by Get.
+const (
+	PropertyInput          = "dapr.workflow.input"
+	PropertyOutput         = "dapr.workflow.output"
+	PropertyCustomStatus   = "dapr.workflow.custom_status"
+	PropertyFailureMessage = "dapr.workflow.failure.error_message"
+)
+
+// Embedded is a workflow engine that runs workflows in-process.
+// It's meant to be used as a library: the state store and the workflows are passed as options to NewEmbeddedWorkflow, so it can't be configured as a component.
+// The history of the instances and the events that are pending are persisted in a transactional state store, so instances that are running when the engine is closed are resumed when it's initialized again.
+// The engine assumes it's the only one accessing the records saved with its key prefix.
+type Embedded struct {
+	store      state.Store
+	txStore    state.TransactionalStore
+	workflows  map[string]WorkflowFunc
+	activities map[string]ActivityFunc
+	metadata   embeddedMetadata
+	logger     logger.Logger
+
+	// Instances that are executed by the engine
+	instances     map[string]*instance
+	instancesLock sync.Mutex
+
+	// IDs of the instances that are not in a terminal state, which are resumed on init
+	activeIDs  map[string]struct{}
+	activeLock sync.Mutex
+
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	initialized atomic.Bool
+	closed      atomic.Bool
+}
+
+// Option is an option for NewEmbeddedWorkflow.
+type Option func(e *Embedded)
+
+// WithStateStore sets the state store used to persist the instances.
+// The state store must be initialized and support transactions.
+func WithStateStore(store state.Store) Option {
+	return func(e *Embedded) {
+		e.store = store
+	}
+}
+
+// WithWorkflow registers a workflow.
+func WithWorkflow(name string, fn WorkflowFunc) Option {
+	return func(e *Embedded) {
+		e.workflows[name] = fn
+	}
+}
+
+// WithActivity registers an activity that can be invoked by workflows.
+func WithActivity(name string, fn ActivityFunc) Option {
+	return func(e *Embedded) {
+		e.activities[name] = fn
+	}
+}
+
+// NewEmbeddedWorkflow returns a new embedded workflow engine.
+// The state store must be set with WithStateStore.
+func NewEmbeddedWorkflow(logger logger.Logger, opts ...Option) *Embedded {
+	e := &Embedded{
+		workflows:  map[string]WorkflowFunc{},
+		activities: map[string]ActivityFunc{},
+		instances:  map[string]*instance{},
+		activeIDs:  map[string]struct{}{},
+		logger:     logger,
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	return e
+}
+
+// Init the workflow engine and resume the instances that were running.
+func (e *Embedded) Init(metadata workflows.Metadata) error {
+	err := e.metadata.InitWithMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	if e.store == nil {
+		return errors.New("a state store is required: it must be set with WithStateStore")
+	}
+	txStore, ok := e.store.(state.TransactionalStore)
+	if !ok || !state.FeatureTransactional.IsPresent(e.store.Features()) {
+		return errors.New("the state store must support transactions")
+	}
+	e.txStore = txStore
+
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+
+	ids, err := e.loadActiveIndex(e.ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		s, err := e.loadState(e.ctx, id)
+		if err != nil {
+			return err
+		}
+		if s == nil || s.isTerminal() {
+			continue
+		}
+		e.activeIDs[id] = struct{}{}
+
+		fn, ok := e.workflows[s.Metadata.WorkflowName]
+		if !ok {
+			e.logger.Warnf("Workflow instance %q can't be resumed because workflow %q is not registered", id, s.Metadata.WorkflowName)
+			continue
+		}
+		e.logger.Debugf("Resuming workflow instance %q", id)
+		e.runInstance(s, fn)
+	}
+
+	e.initialized.Store(true)
+	return nil
+}
+
+// Start a new workflow instance.
+func (e *Embedded) Start(ctx context.Context, req *workflows.StartRequest) (*workflows.StartResponse, error) {
+	if err := e.checkInitialized(); err != nil {
+		return nil, err
+	}
+	if e.closed.Load() {
+		return nil, errors.New("workflow engine is closed")
+	}
+	if req.WorkflowName == "" {
+		return nil, errors.New("workflow name is required")
+	}
+	fn, ok := e.workflows[req.WorkflowName]
+	if !ok {
+		return nil, fmt.Errorf("workflow %q is not registered", req.WorkflowName)
+	}
+
+	var instanceID string
+	if req.InstanceID != nil && *req.InstanceID != "" {
+		instanceID = *req.InstanceID
+	} else {
+		instanceID = uuid.NewString()
+	}
+
+	// Reserve the ID while the instance is saved, so concurrent calls can't start it twice
+	// An entry is kept in instances until the goroutine of the previous instance with the same ID exits, even after it's terminated
+	e.instancesLock.Lock()
+	if _, ok := e.instances[instanceID]; ok {
+		e.instancesLock.Unlock()
+		return nil, fmt.Errorf("workflow instance %q already exists", instanceID)
+	}
+	inst := &instance{}
+	e.instances[instanceID] = inst
+	e.instancesLock.Unlock()
+	started := false
+	defer func() {
+		if !started {
+			e.removeInstance(instanceID, inst)
+		}
+	}()
+
+	existing, err := e.loadState(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && !existing.isTerminal() {
+		return nil, fmt.Errorf("workflow instance %q already exists", instanceID)
+	}
+
+	now := time.Now().UTC()
+	s := &instanceState{
+		Metadata: instanceMetadata{
+			InstanceID:    instanceID,
+			WorkflowName:  req.WorkflowName,
+			RuntimeStatus: StatusRunning,
+			Input:         req.WorkflowInput.GetValue(),
+			CreatedAt:     now,
+		},
+	}
+	err = e.saveStateWithActive(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	e.runInstance(s, fn)
+	started = true
+
+	return &workflows.StartResponse{
+		InstanceID: instanceID,
+	}, nil
+}
+
+// Terminate a workflow instance.
+// The workflow is stopped at the next step it executes and its output is not set.
+func (e *Embedded) Terminate(ctx context.Context, req *workflows.TerminateRequest) error {
+	if err := e.checkInitialized(); err != nil {
+		return err
+	}
+
+	inst := e.getInstance(req.InstanceID)
+	if inst == nil {
+		// The instance isn't being executed: update the status in the state store
+		s, err := e.loadExistingState(ctx, req.InstanceID)
+		if err != nil {
+			return err
+		}
+		if s.isTerminal() {
+			return nil
+		}
+		s.Metadata.RuntimeStatus = StatusTerminated
+		return e.saveStateWithActive(ctx, s)
+	}
+
+	inst.lock.Lock()
+	defer inst.lock.Unlock()
+
+	if inst.state.isTerminal() {
+		return nil
+	}
+	status := inst.state.Metadata.RuntimeStatus
+	inst.state.Metadata.RuntimeStatus = StatusTerminated
+	err := e.saveStateWithActive(ctx, inst.state)
+	if err != nil {
+		inst.state.Metadata.RuntimeStatus = status
+		return err
+	}
+	inst.cancel()
+
+	return nil
+}
+
+// Get the state of a workflow instance.
+func (e *Embedded) Get(ctx context.Context, req *workflows.GetRequest) (*workflows.StateResponse, error) {
+	if err := e.checkInitialized(); err != nil {
+		return nil, err
+	}
+
+	inst := e.getInstance(req.InstanceID)
+	if inst != nil {
+		inst.lock.Lock()
+		defer inst.lock.Unlock()
+		return inst.state.toStateResponse(), nil
+	}
+
+	s, err := e.loadExistingState(ctx, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toStateResponse(), nil
+}
+
+// RaiseEvent raises an event for a workflow instance.
+// The event is added to the inbox of the instance until the workflow waits for it.
+func (e *Embedded) RaiseEvent(ctx context.Context, req *workflows.RaiseEventRequest) error {
+	if err := e.checkInitialized(); err != nil {
+		return err
+	}
+	if req.EventName == "" {
+		return errors.New("event name is required")
+	}
+
+	inst := e.getInstance(req.InstanceID)
+	if inst == nil {
+		s, err := e.loadExistingState(ctx, req.InstanceID)
+		if err != nil {
+			return err
+		}
+		if s.isTerminal() {
+			e.logger.Debugf("Discarding event %q raised for workflow instance %q in status %s", req.EventName, req.InstanceID, s.Metadata.RuntimeStatus)
+			return nil
+		}
+		s.Inbox = append(s.Inbox, newInboxEvent(req))
+		return e.saveState(ctx, s, nil)
+	}
+
+	inst.lock.Lock()
+	defer inst.lock.Unlock()
+
+	if inst.state.isTerminal() {
+		e.logger.Debugf("Discarding event %q raised for workflow instance %q in status %s", req.EventName, req.InstanceID, inst.state.Metadata.RuntimeStatus)
+		return nil
+	}
+	inst.state.Inbox = append(inst.state.Inbox, newInboxEvent(req))
+	err := e.saveState(ctx, inst.state, nil)
+	if err != nil {
+		// Restore the inbox as it was saved
+		inst.state.Inbox = inst.state.Inbox[:len(inst.state.Inbox)-1]
+		return err
+	}
+	inst.wake()
+
+	return nil
+}
+
+// Purge deletes a workflow instance that is in a terminal state.
+func (e *Embedded) Purge(ctx context.Context, req *workflows.PurgeRequest) error {
+	if err := e.checkInitialized(); err != nil {
+		return err
+	}
+	if e.getInstance(req.InstanceID) != nil {
+		return fmt.Errorf("workflow instance %q is not in a terminal state", req.InstanceID)
+	}
+
+	s, err := e.loadExistingState(ctx, req.InstanceID)
+	if err != nil {
+		return err
+	}
+	if !s.isTerminal() {
+		return fmt.Errorf("workflow instance %q is not in a terminal state", req.InstanceID)
+	}
+
+	return e.deleteState(ctx, req.InstanceID)
+}
+
+// Pause suspends a running workflow instance.
+// The workflow is paused before executing the next step; events raised while the instance is suspended are kept in the inbox.
+func (e *Embedded) Pause(ctx context.Context, req *workflows.PauseRequest) error {
+	return e.setSuspended(ctx, req.InstanceID, true)
+}
+
+// Resume a suspended workflow instance.
+func (e *Embedded) Resume(ctx context.Context, req *workflows.ResumeRequest) error {
+	return e.setSuspended(ctx, req.InstanceID, false)
+}
+
+// Close stops executing the instances, which are resumed when the engine is initialized again.
+// The state store is not closed.
+func (e *Embedded) Close() error {
+	if !e.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	if e.cancel != nil {
+		e.cancel()
+	}
+	e.wg.Wait()
+	return nil
+}
+
+func (e *Embedded) GetComponentMetadata() (metadataInfo contribMetadata.MetadataMap) {
+	metadataStruct := embeddedMetadata{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.WorkflowType)
+	return
+}
+
+func (e *Embedded) setSuspended(ctx context.Context, instanceID string, suspended bool) error {
+	if err := e.checkInitialized(); err != nil {
+		return err
+	}
+
+	from, to := StatusRunning, StatusSuspended
+	if !suspended {
+		from, to = StatusSuspended, StatusRunning
+	}
+
+	inst := e.getInstance(instanceID)
+	if inst == nil {
+		s, err := e.loadExistingState(ctx, instanceID)
+		if err != nil {
+			return err
+		}
+		if s.Metadata.RuntimeStatus != from {
+			return fmt.Errorf("workflow instance %q is in status %s", instanceID, s.Metadata.RuntimeStatus)
+		}
+		s.Metadata.RuntimeStatus = to
+		return e.saveState(ctx, s, nil)
+	}
+
+	inst.lock.Lock()
+	defer inst.lock.Unlock()
+
+	if inst.state.Metadata.RuntimeStatus != from {
+		return fmt.Errorf("workflow instance %q is in status %s", instanceID, inst.state.Metadata.RuntimeStatus)
+	}
+	inst.state.Metadata.RuntimeStatus = to
+	err := e.saveState(ctx, inst.state, nil)
+	if err != nil {
+		inst.state.Metadata.RuntimeStatus = from
+		return err
+	}
+	inst.wake()
+
+	return nil
+}
+
+// checkInitialized returns an error if Init hasn't completed successfully, as the state store can't be used before.
+func (e *Embedded) checkInitialized() error {
+	if !e.initialized.Load() {
+		return errors.New("workflow engine is not initialized")
+	}
+	return nil
+}
+
+func (e *Embedded) getInstance(instanceID string) *instance {
+	e.instancesLock.Lock()
+	defer e.instancesLock.Unlock()
+	inst := e.instances[instanceID]
+	if inst == nil || inst.state == nil {
+		// Instances that are being started are not executed yet
+		return nil
+	}
+	return inst
+}
+
+// removeInstance removes an instance from the ones that are executed, unless it was replaced by a new instance with the same ID.
+func (e *Embedded) removeInstance(instanceID string, inst *instance) {
+	e.instancesLock.Lock()
+	defer e.instancesLock.Unlock()
+	if e.instances[instanceID] == inst {
+		delete(e.instances, instanceID)
+	}
+}
+
+func (e *Embedded) loadExistingState(ctx context.Context, instanceID string) (*instanceState, error) {
+	s, err := e.loadState(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("workflow instance %q not found", instanceID)
+	}
+	return s, nil
+}
+
+// saveStateWithActive saves an instance that was started or reached a terminal state, updating the list of active instances.
+func (e *Embedded) saveStateWithActive(ctx context.Context, s *instanceState) error {
+	e.activeLock.Lock()
+	defer e.activeLock.Unlock()
+
+	instanceID := s.Metadata.InstanceID
+	active := make([]string, 0, len(e.activeIDs)+1)
+	for id := range e.activeIDs {
+		if id != instanceID {
+			active = append(active, id)
+		}
+	}
+	if !s.isTerminal() {
+		active = append(active, instanceID)
+	}
+	slices.Sort(active)
+
+	err := e.saveState(ctx, s, active)
+	if err != nil {
+		return err
+	}
+
+	if s.isTerminal() {
+		delete(e.activeIDs, instanceID)
+	} else {
+		e.activeIDs[instanceID] = struct{}{}
+	}
+	return nil
+}
+
+// runInstance starts executing a workflow instance in background.
+func (e *Embedded) runInstance(s *instanceState, fn WorkflowFunc) {
+	inst := newInstance(e.ctx, s)
+
+	e.instancesLock.Lock()
+	e.instances[s.Metadata.InstanceID] = inst
+	e.instancesLock.Unlock()
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		defer func() {
+			e.removeInstance(s.Metadata.InstanceID, inst)
+			inst.cancel()
+		}()
+
+		// If the instance is terminated or the engine is closed while the workflow is running, the goroutine exits in one of the steps and the result is not saved
+		output, err := e.executeWorkflow(inst, fn)
+		e.completeInstance(inst, output, err)
+	}()
+}
+
+// executeWorkflow invokes the workflow, recovering from panics.
+func (e *Embedded) executeWorkflow(inst *instance, fn WorkflowFunc) (output string, err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if rErr, ok := r.(error); ok {
+			err = rErr
+		} else {
+			err = fmt.Errorf("workflow panicked: %v", r)
+		}
+	}()
+
+	return fn(&WorkflowContext{
+		engine: e,
+		inst:   inst,
+	})
+}
+
+// completeInstance saves the result of a workflow.
+func (e *Embedded) completeInstance(inst *instance, output string, err error) {
+	inst.lock.Lock()
+	defer inst.lock.Unlock()
+
+	if inst.state.isTerminal() {
+		// The instance was terminated while the workflow was running
+		return
+	}
+
+	if err != nil {
+		inst.state.Metadata.RuntimeStatus = StatusFailed
+		inst.state.Metadata.FailureMessage = err.Error()
+	} else {
+		inst.state.Metadata.RuntimeStatus = StatusCompleted
+		inst.state.Metadata.Output = output
+	}
+
+	saveErr := e.saveStateWithActive(e.ctx, inst.state)
+	if saveErr != nil {
+		e.logger.Errorf("Failed to save the result of workflow instance %q: %v", inst.state.Metadata.InstanceID, saveErr)
+	}
+}
+
+// mustSaveState saves the state of an instance from a workflow step; the caller must hold the instance's lock.
+// If the state can't be saved, the workflow can't continue and the instance fails.
+func (e *Embedded) mustSaveState(inst *instance) {
+	err := e.saveState(e.ctx, inst.state, nil)
+	if err != nil {
+		if inst.ctx.Err() != nil {
+			runtime.Goexit()
+		}
+		panic(err)
+	}
+}
+
+// saveStepDeadline sets the time when a timer (or the timeout of an event) fires, and saves it so it's not reset if the instance is resumed.
+func (e *Embedded) saveStepDeadline(inst *instance, idx int, d time.Duration) *time.Time {
+	inst.lock.Lock()
+	defer inst.lock.Unlock()
+
+	fireAt := time.Now().UTC().Add(d)
+	inst.state.History[idx].FireAt = &fireAt
+	e.mustSaveState(inst)
+	return &fireAt
+}
+
+// receiveEvent removes the first event with the given name from the inbox of the instance, and saves it as the result of the step.
+func (e *Embedded) receiveEvent(inst *instance, idx int, name string) (string, bool) {
+	inst.lock.Lock()
+	defer inst.lock.Unlock()
+
+	i := slices.IndexFunc(inst.state.Inbox, func(ev inboxEvent) bool {
+		return ev.Name == name
+	})
+	if i < 0 {
+		return "", false
+	}
+
+	data := inst.state.Inbox[i].Data
+	inst.state.Inbox = slices.Delete(inst.state.Inbox, i, i+1)
+	step := &inst.state.History[idx]
+	step.Completed = true
+	step.Result = data
+	e.mustSaveState(inst)
+
+	return data, true
+}
+
+func newInboxEvent(req *workflows.RaiseEventRequest) inboxEvent {
+	return inboxEvent{
+		Name:     req.EventName,
+		Data:     req.EventData.GetValue(),
+		RaisedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,369 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package embedded
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/components-contrib/workflows"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+var testLogger = logger.NewLogger("test")
+
+func newStore(t *testing.T) state.Store {
+	t.Helper()
+	store := inmemory.NewInMemoryStateStore(testLogger)
+	require.NoError(t, store.Init(context.Background(), state.Metadata{}))
+	t.Cleanup(func() {
+		store.Close()
+	})
+	return store
+}
+
+func newEngine(t *testing.T, opts ...Option) workflows.Workflow {
+	t.Helper()
+	wf := NewEmbeddedWorkflow(testLogger, opts...)
+	require.NoError(t, wf.Init(workflows.Metadata{Base: metadata.Base{
+		Properties: map[string]string{},
+	}}))
+	t.Cleanup(func() {
+		wf.Close()
+	})
+	return wf
+}
+
+func start(t *testing.T, wf workflows.Workflow, name string, instanceID string, input string) {
+	t.Helper()
+	res, err := wf.Start(context.Background(), &workflows.StartRequest{
+		InstanceID:    ptr.Of(instanceID),
+		WorkflowName:  name,
+		WorkflowInput: wrapperspb.String(input),
+	})
+	require.NoError(t, err)
+	require.Equal(t, instanceID, res.InstanceID)
+}
+
+func waitForStatus(t *testing.T, wf workflows.Workflow, instanceID string, status string) *workflows.WorkflowState {
+	t.Helper()
+	var res *workflows.StateResponse
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		var err error
+		res, err = wf.Get(context.Background(), &workflows.GetRequest{InstanceID: instanceID})
+		require.NoError(c, err)
+		assert.Equal(c, status, res.Workflow.RuntimeStatus)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NotNil(t, res)
+	return res.Workflow
+}
+
+func raiseEvent(t *testing.T, wf workflows.Workflow, instanceID string, name string, data string) {
+	t.Helper()
+	err := wf.RaiseEvent(context.Background(), &workflows.RaiseEventRequest{
+		InstanceID: instanceID,
+		EventName:  name,
+		EventData:  wrapperspb.String(data),
+	})
+	require.NoError(t, err)
+}
+
+func TestInit(t *testing.T) {
+	t.Run("state store is required", func(t *testing.T) {
+		wf := NewEmbeddedWorkflow(testLogger)
+		err := wf.Init(workflows.Metadata{})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid key prefix", func(t *testing.T) {
+		wf := NewEmbeddedWorkflow(testLogger, WithStateStore(newStore(t)))
+		err := wf.Init(workflows.Metadata{Base: metadata.Base{
+			Properties: map[string]string{"keyPrefix": "a||b"},
+		}})
+		require.Error(t, err)
+	})
+
+	t.Run("not initialized", func(t *testing.T) {
+		wf := NewEmbeddedWorkflow(testLogger, WithStateStore(newStore(t)), WithWorkflow("noop", func(ctx *WorkflowContext) (string, error) {
+			return "", nil
+		}))
+		_, err := wf.Start(context.Background(), &workflows.StartRequest{WorkflowName: "noop"})
+		require.ErrorContains(t, err, "not initialized")
+		_, err = wf.Get(context.Background(), &workflows.GetRequest{InstanceID: "1"})
+		require.ErrorContains(t, err, "not initialized")
+		err = wf.Pause(context.Background(), &workflows.PauseRequest{InstanceID: "1"})
+		require.ErrorContains(t, err, "not initialized")
+		require.NoError(t, wf.Close())
+	})
+}
+
+func TestWorkflow(t *testing.T) {
+	double := func(_ context.Context, input string) (string, error) {
+		n, err := strconv.Atoi(input)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(n * 2), nil
+	}
+
+	wf := newEngine(t,
+		WithStateStore(newStore(t)),
+		WithActivity("double", double),
+		WithWorkflow("activities", func(ctx *WorkflowContext) (string, error) {
+			res, err := ctx.CallActivity("double", ctx.Input())
+			if err != nil {
+				return "", err
+			}
+			ctx.SetCustomStatus("doubled once")
+			return ctx.CallActivity("double", res)
+		}),
+		WithWorkflow("events", func(ctx *WorkflowContext) (string, error) {
+			first, err := ctx.WaitForExternalEvent("first", 0)
+			if err != nil {
+				return "", err
+			}
+			second, err := ctx.WaitForExternalEvent("second", 0)
+			if err != nil {
+				return "", err
+			}
+			return first + second, nil
+		}),
+		WithWorkflow("timeout", func(ctx *WorkflowContext) (string, error) {
+			_, err := ctx.WaitForExternalEvent("never", 50*time.Millisecond)
+			if errors.Is(err, ErrTimedOut) {
+				return "timed out", nil
+			}
+			return "", err
+		}),
+		WithWorkflow("timer", func(ctx *WorkflowContext) (string, error) {
+			ctx.CreateTimer(50 * time.Millisecond)
+			return "done", nil
+		}),
+		WithWorkflow("fail", func(ctx *WorkflowContext) (string, error) {
+			return ctx.CallActivity("double", "not a number")
+		}),
+	)
+
+	t.Run("activities", func(t *testing.T) {
+		start(t, wf, "activities", "activities-1", "3")
+		res := waitForStatus(t, wf, "activities-1", StatusCompleted)
+		assert.Equal(t, "activities", res.WorkflowName)
+		assert.Equal(t, "3", res.Properties[PropertyInput])
+		assert.Equal(t, "12", res.Properties[PropertyOutput])
+		assert.Equal(t, "doubled once", res.Properties[PropertyCustomStatus])
+		assert.False(t, res.CreatedAt.IsZero())
+	})
+
+	t.Run("events", func(t *testing.T) {
+		start(t, wf, "events", "events-1", "")
+
+		// Events raised before the workflow waits for them are buffered
+		raiseEvent(t, wf, "events-1", "second", "b")
+		waitForStatus(t, wf, "events-1", StatusRunning)
+		raiseEvent(t, wf, "events-1", "first", "a")
+
+		res := waitForStatus(t, wf, "events-1", StatusCompleted)
+		assert.Equal(t, "ab", res.Properties[PropertyOutput])
+	})
+
+	t.Run("event timeout", func(t *testing.T) {
+		start(t, wf, "timeout", "timeout-1", "")
+		res := waitForStatus(t, wf, "timeout-1", StatusCompleted)
+		assert.Equal(t, "timed out", res.Properties[PropertyOutput])
+	})
+
+	t.Run("timer", func(t *testing.T) {
+		start(t, wf, "timer", "timer-1", "")
+		res := waitForStatus(t, wf, "timer-1", StatusCompleted)
+		assert.Equal(t, "done", res.Properties[PropertyOutput])
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		start(t, wf, "fail", "fail-1", "")
+		res := waitForStatus(t, wf, "fail-1", StatusFailed)
+		assert.Contains(t, res.Properties[PropertyFailureMessage], "invalid syntax")
+		assert.Empty(t, res.Properties[PropertyOutput])
+	})
+
+	t.Run("terminate", func(t *testing.T) {
+		start(t, wf, "events", "terminate-1", "")
+		err := wf.Terminate(context.Background(), &workflows.TerminateRequest{InstanceID: "terminate-1"})
+		require.NoError(t, err)
+		waitForStatus(t, wf, "terminate-1", StatusTerminated)
+
+		// Events raised for terminated instances are discarded
+		raiseEvent(t, wf, "terminate-1", "first", "a")
+		res := waitForStatus(t, wf, "terminate-1", StatusTerminated)
+		assert.Empty(t, res.Properties[PropertyOutput])
+	})
+
+	t.Run("restart terminated instance", func(t *testing.T) {
+		start(t, wf, "events", "restart-1", "")
+		err := wf.Terminate(context.Background(), &workflows.TerminateRequest{InstanceID: "restart-1"})
+		require.NoError(t, err)
+
+		// The ID can be reused once the terminated instance has stopped
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			_, err := wf.Start(context.Background(), &workflows.StartRequest{InstanceID: ptr.Of("restart-1"), WorkflowName: "events"})
+			assert.NoError(c, err)
+		}, 5*time.Second, 10*time.Millisecond)
+		raiseEvent(t, wf, "restart-1", "first", "a")
+		raiseEvent(t, wf, "restart-1", "second", "b")
+		res := waitForStatus(t, wf, "restart-1", StatusCompleted)
+		assert.Equal(t, "ab", res.Properties[PropertyOutput])
+	})
+
+	t.Run("concurrent start", func(t *testing.T) {
+		var (
+			wg      sync.WaitGroup
+			started atomic.Int32
+		)
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := wf.Start(context.Background(), &workflows.StartRequest{InstanceID: ptr.Of("concurrent-1"), WorkflowName: "events"})
+				if err == nil {
+					started.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), started.Load())
+	})
+
+	t.Run("pause and resume", func(t *testing.T) {
+		start(t, wf, "events", "pause-1", "")
+		err := wf.Pause(context.Background(), &workflows.PauseRequest{InstanceID: "pause-1"})
+		require.NoError(t, err)
+		waitForStatus(t, wf, "pause-1", StatusSuspended)
+
+		raiseEvent(t, wf, "pause-1", "first", "a")
+		raiseEvent(t, wf, "pause-1", "second", "b")
+		time.Sleep(100 * time.Millisecond)
+		waitForStatus(t, wf, "pause-1", StatusSuspended)
+
+		err = wf.Pause(context.Background(), &workflows.PauseRequest{InstanceID: "pause-1"})
+		require.Error(t, err)
+
+		err = wf.Resume(context.Background(), &workflows.ResumeRequest{InstanceID: "pause-1"})
+		require.NoError(t, err)
+		res := waitForStatus(t, wf, "pause-1", StatusCompleted)
+		assert.Equal(t, "ab", res.Properties[PropertyOutput])
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		start(t, wf, "events", "purge-1", "")
+		err := wf.Purge(context.Background(), &workflows.PurgeRequest{InstanceID: "purge-1"})
+		require.Error(t, err)
+
+		err = wf.Terminate(context.Background(), &workflows.TerminateRequest{InstanceID: "purge-1"})
+		require.NoError(t, err)
+		waitForStatus(t, wf, "purge-1", StatusTerminated)
+
+		err = wf.Purge(context.Background(), &workflows.PurgeRequest{InstanceID: "purge-1"})
+		require.NoError(t, err)
+		_, err = wf.Get(context.Background(), &workflows.GetRequest{InstanceID: "purge-1"})
+		require.Error(t, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := wf.Start(context.Background(), &workflows.StartRequest{WorkflowName: "unknown"})
+		require.Error(t, err)
+
+		start(t, wf, "events", "duplicate-1", "")
+		_, err = wf.Start(context.Background(), &workflows.StartRequest{InstanceID: ptr.Of("duplicate-1"), WorkflowName: "events"})
+		require.Error(t, err)
+
+		_, err = wf.Get(context.Background(), &workflows.GetRequest{InstanceID: "unknown"})
+		require.Error(t, err)
+		err = wf.RaiseEvent(context.Background(), &workflows.RaiseEventRequest{InstanceID: "unknown", EventName: "first"})
+		require.Error(t, err)
+	})
+
+	t.Run("generated instance ID", func(t *testing.T) {
+		res, err := wf.Start(context.Background(), &workflows.StartRequest{WorkflowName: "activities", WorkflowInput: wrapperspb.String("1")})
+		require.NoError(t, err)
+		require.NotEmpty(t, res.InstanceID)
+		waitForStatus(t, wf, res.InstanceID, StatusCompleted)
+	})
+}
+
+func TestResume(t *testing.T) {
+	store := newStore(t)
+
+	var calls atomic.Int32
+	opts := []Option{
+		WithStateStore(store),
+		WithActivity("count", func(_ context.Context, input string) (string, error) {
+			return strconv.Itoa(int(calls.Add(1))), nil
+		}),
+		WithWorkflow("resumable", func(ctx *WorkflowContext) (string, error) {
+			count, err := ctx.CallActivity("count", "")
+			if err != nil {
+				return "", err
+			}
+			data, err := ctx.WaitForExternalEvent("go", 0)
+			if err != nil {
+				return "", err
+			}
+			return count + data, nil
+		}),
+	}
+
+	// Start an instance and close the engine while the workflow is waiting for the event
+	wf := newEngine(t, opts...)
+	start(t, wf, "resumable", "resume-1", "")
+	require.Eventually(t, func() bool {
+		return calls.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, wf.Close())
+
+	// The instance is resumed by a new engine, and the completed activity is not executed again
+	wf = newEngine(t, opts...)
+	waitForStatus(t, wf, "resume-1", StatusRunning)
+	raiseEvent(t, wf, "resume-1", "go", "!")
+	res := waitForStatus(t, wf, "resume-1", StatusCompleted)
+	assert.Equal(t, "1!", res.Properties[PropertyOutput])
+	assert.Equal(t, int32(1), calls.Load())
+
+	t.Run("non-deterministic workflow", func(t *testing.T) {
+		wf := newEngine(t, opts...)
+		start(t, wf, "resumable", "resume-2", "")
+		waitForStatus(t, wf, "resume-2", StatusRunning)
+		require.NoError(t, wf.Close())
+
+		wf = newEngine(t,
+			WithStateStore(store),
+			WithWorkflow("resumable", func(ctx *WorkflowContext) (string, error) {
+				return ctx.WaitForExternalEvent("go", 0)
+			}),
+		)
+		res := waitForStatus(t, wf, "resume-2", StatusFailed)
+		assert.Contains(t, res.Properties[PropertyFailureMessage], "not deterministic")
+	})
+}
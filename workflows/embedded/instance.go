@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package embedded
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/workflows"
+)
+
+const (
+	keySeparator = "||"
+
+	keySuffixMetadata = "metadata"
+	keySuffixHistory  = "history"
+	keySuffixInbox    = "inbox"
+	keyActiveIndex    = "active"
+)
+
+// Types of the steps recorded in the history of an instance.
+const (
+	stepActivity = "activity"
+	stepEvent    = "event"
+	stepTimer    = "timer"
+)
+
+// instanceMetadata is the record with the status of a workflow instance.
+type instanceMetadata struct {
+	InstanceID     string    `json:"instanceID"`
+	WorkflowName   string    `json:"workflowName"`
+	RuntimeStatus  string    `json:"runtimeStatus"`
+	Input          string    `json:"input,omitempty"`
+	Output         string    `json:"output,omitempty"`
+	CustomStatus   string    `json:"customStatus,omitempty"`
+	FailureMessage string    `json:"failureMessage,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	LastUpdatedAt  time.Time `json:"lastUpdatedAt"`
+}
+
+// historyStep is a step executed by a workflow.
+// When an instance is resumed, the workflow is executed again from the start and the results of the completed steps are returned from the history.
+type historyStep struct {
+	Type      string     `json:"type"`
+	Name      string     `json:"name,omitempty"`
+	Completed bool       `json:"completed,omitempty"`
+	Result    string     `json:"result,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	FireAt    *time.Time `json:"fireAt,omitempty"`
+}
+
+// err returns the error of a completed step.
+func (s historyStep) err() error {
+	switch s.Error {
+	case "":
+		return nil
+	case ErrTimedOut.Error():
+		return ErrTimedOut
+	default:
+		return errors.New(s.Error)
+	}
+}
+
+// inboxEvent is an event raised for an instance that has not been received by the workflow yet.
+type inboxEvent struct {
+	Name     string    `json:"name"`
+	Data     string    `json:"data,omitempty"`
+	RaisedAt time.Time `json:"raisedAt"`
+}
+
+// instanceState contains all the records of a workflow instance.
+type instanceState struct {
+	Metadata instanceMetadata
+	History  []historyStep
+	Inbox    []inboxEvent
+}
+
+func (s *instanceState) isTerminal() bool {
+	switch s.Metadata.RuntimeStatus {
+	case StatusCompleted, StatusFailed, StatusTerminated:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *instanceState) toStateResponse() *workflows.StateResponse {
+	props := map[string]string{}
+	if s.Metadata.Input != "" {
+		props[PropertyInput] = s.Metadata.Input
+	}
+	if s.Metadata.Output != "" {
+		props[PropertyOutput] = s.Metadata.Output
+	}
+	if s.Metadata.CustomStatus != "" {
+		props[PropertyCustomStatus] = s.Metadata.CustomStatus
+	}
+	if s.Metadata.FailureMessage != "" {
+		props[PropertyFailureMessage] = s.Metadata.FailureMessage
+	}
+
+	return &workflows.StateResponse{
+		Workflow: &workflows.WorkflowState{
+			InstanceID:    s.Metadata.InstanceID,
+			WorkflowName:  s.Metadata.WorkflowName,
+			CreatedAt:     s.Metadata.CreatedAt,
+			LastUpdatedAt: s.Metadata.LastUpdatedAt,
+			RuntimeStatus: s.Metadata.RuntimeStatus,
+			Properties:    props,
+		},
+	}
+}
+
+// instance is a workflow instance that is executed by the engine.
+type instance struct {
+	// Lock protecting the state, which is saved while holding the lock
+	lock  sync.Mutex
+	state *instanceState
+
+	// Channel used to wake up the workflow when an event is raised, or the instance is resumed
+	wakeCh chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newInstance(parentCtx context.Context, s *instanceState) *instance {
+	ctx, cancel := context.WithCancel(parentCtx)
+	return &instance{
+		state:  s,
+		wakeCh: make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// wake the workflow if it's waiting.
+func (i *instance) wake() {
+	select {
+	case i.wakeCh <- struct{}{}:
+	default:
+		// There's a pending notification already
+	}
+}
+
+func (e *Embedded) instanceKey(instanceID string, suffix string) string {
+	return e.metadata.KeyPrefix + keySeparator + instanceID + keySeparator + suffix
+}
+
+func (e *Embedded) activeIndexKey() string {
+	return e.metadata.KeyPrefix + keySeparator + keyActiveIndex
+}
+
+// loadState loads the records of an instance from the state store.
+// Returns nil if the instance doesn't exist.
+func (e *Embedded) loadState(ctx context.Context, instanceID string) (*instanceState, error) {
+	s := &instanceState{}
+	found, err := e.getRecord(ctx, e.instanceKey(instanceID, keySuffixMetadata), &s.Metadata)
+	if err != nil || !found {
+		return nil, err
+	}
+	_, err = e.getRecord(ctx, e.instanceKey(instanceID, keySuffixHistory), &s.History)
+	if err != nil {
+		return nil, err
+	}
+	_, err = e.getRecord(ctx, e.instanceKey(instanceID, keySuffixInbox), &s.Inbox)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// saveState saves all the records of an instance in a single transaction.
+// If the instance became active or terminal, the updated list of active instances is saved too; in that case, the caller must hold activeLock.
+func (e *Embedded) saveState(ctx context.Context, s *instanceState, activeIndex []string) error {
+	s.Metadata.LastUpdatedAt = time.Now().UTC()
+	instanceID := s.Metadata.InstanceID
+	ops := []state.TransactionalStateOperation{
+		state.SetRequest{Key: e.instanceKey(instanceID, keySuffixMetadata), Value: s.Metadata},
+		state.SetRequest{Key: e.instanceKey(instanceID, keySuffixHistory), Value: s.History},
+		state.SetRequest{Key: e.instanceKey(instanceID, keySuffixInbox), Value: s.Inbox},
+	}
+	if activeIndex != nil {
+		ops = append(ops, state.SetRequest{Key: e.activeIndexKey(), Value: activeIndex})
+	}
+	err := e.txStore.Multi(ctx, &state.TransactionalStateRequest{Operations: ops})
+	if err != nil {
+		return fmt.Errorf("failed to save workflow instance %q: %w", instanceID, err)
+	}
+	return nil
+}
+
+// deleteState deletes all the records of an instance.
+func (e *Embedded) deleteState(ctx context.Context, instanceID string) error {
+	ops := []state.TransactionalStateOperation{
+		state.DeleteRequest{Key: e.instanceKey(instanceID, keySuffixMetadata)},
+		state.DeleteRequest{Key: e.instanceKey(instanceID, keySuffixHistory)},
+		state.DeleteRequest{Key: e.instanceKey(instanceID, keySuffixInbox)},
+	}
+	err := e.txStore.Multi(ctx, &state.TransactionalStateRequest{Operations: ops})
+	if err != nil {
+		return fmt.Errorf("failed to purge workflow instance %q: %w", instanceID, err)
+	}
+	return nil
+}
+
+// loadActiveIndex loads the list of the instances that are not in a terminal state.
+func (e *Embedded) loadActiveIndex(ctx context.Context) ([]string, error) {
+	var ids []string
+	_, err := e.getRecord(ctx, e.activeIndexKey(), &ids)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (e *Embedded) getRecord(ctx context.Context, key string, dest any) (bool, error) {
+	res, err := e.store.Get(ctx, &state.GetRequest{Key: key})
+	if err != nil {
+		return false, fmt.Errorf("failed to load record %q: %w", key, err)
+	}
+	if res == nil || len(res.Data) == 0 {
+		return false, nil
+	}
+	err = json.Unmarshal(res.Data, dest)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse record %q: %w", key, err)
+	}
+	return true, nil
+}
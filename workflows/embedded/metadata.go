@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package embedded
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dapr/components-contrib/workflows"
+	kitmd "github.com/dapr/kit/metadata"
+)
+
+const defaultKeyPrefix = "workflows"
+
+type embeddedMetadata struct {
+	// Prefix for the keys of the records saved in the state store.
+	KeyPrefix string `json:"keyPrefix" mapstructure:"keyPrefix"`
+}
+
+func (m *embeddedMetadata) InitWithMetadata(meta workflows.Metadata) error {
+	m.reset()
+
+	// Decode the metadata
+	err := kitmd.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// The separator is used to build the keys, so it can't be in the prefix
+	if m.KeyPrefix == "" {
+		return errors.New("metadata property 'keyPrefix' must not be empty")
+	}
+	if strings.Contains(m.KeyPrefix, keySeparator) {
+		return errors.New("metadata property 'keyPrefix' must not contain '" + keySeparator + "'")
+	}
+
+	return nil
+}
+
+// Reset the object
+func (m *embeddedMetadata) reset() {
+	m.KeyPrefix = defaultKeyPrefix
+}